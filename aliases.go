@@ -0,0 +1,183 @@
+package turnip
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// aliasSet holds the other paths a field can be found at, as given by the aliases option of the turnip tag, like
+// `turnip:"aliases=user_id|uid"`. Equal sets share the same pointer, so path types can be compared
+type aliasSet struct {
+	// key is the paths joined by |
+	key   string
+	paths []string
+}
+
+// aliases returns the other names of the field, if any
+func (f wireField) aliases() []string {
+	var names []string
+	for _, name := range strings.Split(f.options["aliases"], "|") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// get returns the value at path, or else at the first of its aliases that's present
+func (p pathType) get(res gjson.Result, path string) gjson.Result {
+	v := p.naming.get(res, path)
+	if v.Exists() || p.aliases == nil {
+		return v
+	}
+
+	for _, alias := range p.aliases.paths {
+		v = p.naming.get(res, alias)
+		if v.Exists() {
+			return v
+		}
+	}
+
+	return v
+}
+
+// withoutAliases is the path type as found at a single path, to compare it with the paths of other candidates
+func (p pathType) withoutAliases() pathType {
+	p.aliases = nil
+	return p
+}
+
+// otherPaths returns the paths, other than curr.name, where the field can be found. Those are its aliases, and the
+// same names under the other paths of the object holding it
+func (b *pathBuilder) otherPaths(curr string, f wireField) []string {
+	var paths []string
+	for _, prefix := range b.alternates[curr] {
+		paths = append(paths, b.in.intern(appendToPath(prefix, f.name, b.naming)))
+	}
+
+	for _, alias := range f.aliases() {
+		paths = append(paths, b.in.intern(appendToPath(curr, alias, b.naming)))
+		for _, prefix := range b.alternates[curr] {
+			paths = append(paths, b.in.intern(appendToPath(prefix, alias, b.naming)))
+		}
+	}
+
+	return paths
+}
+
+// applyAliases sets the other paths found while building on the paths they belong to
+func (b *pathBuilder) applyAliases() {
+	for path, typ := range b.paths {
+		others := b.alternates[path]
+		if len(others) == 0 {
+			continue
+		}
+
+		key := strings.Join(others, "|")
+		set, ok := b.aliasSets[key]
+		if !ok {
+			set = &aliasSet{
+				key:   key,
+				paths: others,
+			}
+
+			b.aliasSets[key] = set
+		}
+
+		typ.aliases = set
+		b.paths[path] = typ
+	}
+}
+
+// getField returns the value of the field f in the object res, looking for its aliases if it's not there
+func getField(res gjson.Result, f wireField) gjson.Result {
+	v := getKey(res, f.name)
+	if v.Exists() {
+		return v
+	}
+
+	for _, alias := range f.aliases() {
+		v = getKey(res, alias)
+		if v.Exists() {
+			return v
+		}
+	}
+
+	return v
+}
+
+// renameKeys renames the keys of the payload v that match a field of t, either by naming or through an alias, to the
+// name of the field, so encoding/json decodes them
+func renameKeys(v any, t reflect.Type, naming Naming) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if _, ok := getLeafType(t); ok {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+
+		fields := wireFields(t, jsonFormat)
+		renamed := make(map[string]any, len(fields))
+		for _, f := range fields {
+			found, ok := naming.findKey(obj, f.name)
+			for _, alias := range f.aliases() {
+				if ok {
+					break
+				}
+
+				found, ok = naming.findKey(obj, alias)
+			}
+
+			if !ok {
+				continue
+			}
+
+			renamed[f.name] = renameKeys(obj[found], f.typ, naming)
+			delete(obj, found)
+		}
+
+		if naming.splitsWords() {
+			// encoding/json would still fold the keys that don't follow the naming into the fields
+			for key := range obj {
+				if slices.ContainsFunc(fields, func(f wireField) bool { return strings.EqualFold(key, f.name) }) {
+					delete(obj, key)
+				}
+			}
+		}
+
+		maps.Copy(obj, renamed)
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			return v
+		}
+
+		for i := range arr {
+			arr[i] = renameKeys(arr[i], t.Elem(), naming)
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+
+		for key := range obj {
+			obj[key] = renameKeys(obj[key], t.Elem(), naming)
+		}
+	}
+
+	return v
+}
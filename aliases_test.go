@@ -0,0 +1,51 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type aliasedUser struct {
+	UserID string `json:"user_id" turnip:"aliases=uid|userId"`
+	Name   string `json:"name"`
+}
+
+type aliasedGroup struct {
+	GroupID string `json:"group_id"`
+	Name    string `json:"name"`
+}
+
+func TestAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    any
+		wantErr error
+	}{
+		{"name", `{"user_id":"a","name":"ada"}`, &aliasedUser{UserID: "a", Name: "ada"}, nil},
+		{"first alias", `{"uid":"a","name":"ada"}`, &aliasedUser{UserID: "a", Name: "ada"}, nil},
+		{"second alias", `{"userId":"a","name":"ada"}`, &aliasedUser{UserID: "a", Name: "ada"}, nil},
+		{"name before aliases", `{"uid":"b","user_id":"a"}`, &aliasedUser{UserID: "a"}, nil},
+		{"alias with another type", `{"uid":1,"name":"ada"}`, nil, ErrNoMatch},
+		{"other candidate", `{"group_id":"g","name":"admins"}`, &aliasedGroup{GroupID: "g", Name: "admins"}, nil},
+	}
+
+	u, err := New(Candidate(aliasedUser{}), Candidate(aliasedGroup{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package turnip
+
+// Builder accumulates parameters for New, for candidate sets assembled from loops and conditionals. Its methods mirror
+// the functions of the same name:
+//
+//	b := turnip.NewBuilder().SelectOn("kind", "ping", Ping{})
+//	for _, v := range events {
+//		b.Candidate(v)
+//	}
+//
+//	u, err := b.Default(Unknown{}).Build()
+type Builder struct {
+	params []Parameter
+}
+
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Add adds any parameters, including options
+func (b *Builder) Add(params ...Parameter) *Builder {
+	b.params = append(b.params, params...)
+	return b
+}
+
+func (b *Builder) Candidate(v any, opts ...DecodeOption) *Builder {
+	return b.Add(Candidate(v, opts...))
+}
+
+func (b *Builder) Strict(v any, opts ...DecodeOption) *Builder {
+	return b.Add(Strict(v, opts...))
+}
+
+func (b *Builder) StrictMatching() *Builder {
+	return b.Add(StrictMatching())
+}
+
+func (b *Builder) Version(v any, constraint string, opts ...DecodeOption) *Builder {
+	return b.Add(Version(v, constraint, opts...))
+}
+
+func (b *Builder) SelectOn(field string, equal any, then any) *Builder {
+	return b.Add(SelectOn(field, equal, then))
+}
+
+func (b *Builder) SelectWhen(cond Condition, then any) *Builder {
+	return b.Add(SelectWhen(cond, then))
+}
+
+func (b *Builder) Default(v any) *Builder {
+	return b.Add(Default(v))
+}
+
+// Build creates the Unmarshaler with the parameters added so far. The builder can still be used afterwards
+func (b *Builder) Build() (*Unmarshaler, error) {
+	params := make([]Parameter, len(b.params))
+	copy(params, b.params)
+
+	return New(params...)
+}
@@ -0,0 +1,85 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type builderPing struct {
+	Kind string `json:"kind"`
+}
+
+type builderOrder struct {
+	ID    int     `json:"id"`
+	Total float64 `json:"total"`
+}
+
+type builderUnknown struct{}
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(b *Builder) *Builder
+		payload  string
+		want     any
+		wantErr  error
+		buildErr error
+	}{
+		{
+			name:    "candidate",
+			build:   func(b *Builder) *Builder { return b.Candidate(builderOrder{}) },
+			payload: `{"id":1,"total":2,"note":"x"}`,
+			want:    &builderOrder{ID: 1, Total: 2},
+		},
+		{
+			name:    "strict matching",
+			build:   func(b *Builder) *Builder { return b.Candidate(builderOrder{}).StrictMatching() },
+			payload: `{"id":1,"total":2,"note":"x"}`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name:    "strict matching exact shape",
+			build:   func(b *Builder) *Builder { return b.Candidate(builderOrder{}).StrictMatching() },
+			payload: `{"id":1,"total":2}`,
+			want:    &builderOrder{ID: 1, Total: 2},
+		},
+		{
+			name: "selector and default",
+			build: func(b *Builder) *Builder {
+				return b.SelectOn("kind", "ping", builderPing{}).Candidate(builderOrder{}).Default(builderUnknown{})
+			},
+			payload: `{"kind":"ping"}`,
+			want:    &builderPing{Kind: "ping"},
+		},
+		{
+			name: "strict matching and mapped fields",
+			build: func(b *Builder) *Builder {
+				return b.Add(MapField(Candidate(builderOrder{}), "order.id", "ID")).StrictMatching()
+			},
+			buildErr: ErrInvalidParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := tt.build(NewBuilder()).Build()
+			if !errors.Is(err, tt.buildErr) {
+				t.Fatalf("Build() error = %v, want %v", err, tt.buildErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package turnip
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// CacheResolutions remembers what the last n distinct payloads resolved to, so payloads seen before, like retried
+// webhook deliveries, skip resolution. It's UseCache with an LRU cache of n resolutions that never expire, only for
+// this Unmarshaler.
+//
+// Only resolution is skipped, every payload is still decoded on its own. Calls given a hint or metadata are never
+// cached, since those may change the outcome
+func CacheResolutions(n int) Parameter {
+	return cacheSize(n)
+}
+
+type cacheSize int
+
+func (n cacheSize) Name() string {
+	return "CacheResolutions"
+}
+
+// ResolutionCache stores the types payloads resolved to, by a key derived from the payload, for UseCache. Types are
+// stored by name, so they can be kept out of the process, and payloads resolving to no candidate are stored with an
+// empty name. Implementations must be safe for concurrent use
+type ResolutionCache interface {
+	// Get returns the name of the type stored for key, if there's one
+	Get(key string) (typ string, ok bool)
+	// Add stores the name of the type the payload of key resolved to
+	Add(key, typ string)
+}
+
+// UseCache makes the Unmarshaler remember resolutions in c, which can be shared by several Unmarshalers, or backed by
+// something like Redis for a fleet of them to reuse each other's resolutions.
+//
+// When resolution only depends on the shape of payloads, that is, on their keys and the types of their values, they
+// are keyed by a SHA-256 of the shape, so payloads with different values share their entry. That's not the case with
+// selectors, Version, Match, Fingerprint, coercions, enums, value formats, time layouts or UseResolver, and then the
+// SHA-256 of the whole payload is used instead, which unlike a faster hash can't be forged to pass a payload for
+// another one. Keys start with a hash of the fingerprints, so Unmarshalers with different candidates don't mix their
+// resolutions, but changes to the selectors are not seen: caches shared across them must be emptied then
+func UseCache(c ResolutionCache) Parameter {
+	return &cacheParameter{cache: c}
+}
+
+type cacheParameter struct {
+	cache ResolutionCache
+}
+
+func (c *cacheParameter) Name() string {
+	return "UseCache"
+}
+
+// NewLRUCache returns a ResolutionCache keeping up to size resolutions, forgetting the least recently used ones first.
+// With a positive ttl, resolutions are also forgotten once they are that old
+func NewLRUCache(size int, ttl time.Duration) ResolutionCache {
+	return &lruCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element, max(size, 0)),
+		order:   list.New(),
+	}
+}
+
+// lruCache is the ResolutionCache returned by NewLRUCache
+type lruCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order holds the lruEntries, most recently used first
+	order *list.List
+}
+
+type lruEntry struct {
+	key, typ string
+	// expires is zero for caches without a TTL
+	expires time.Time
+}
+
+func (c *lruCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return "", false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.typ, true
+}
+
+func (c *lruCache) Add(key, typ string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = time.Now().Add(c.ttl)
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.typ, entry.expires = typ, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, typ: typ, expires: expires})
+}
+
+// cacheKeys derives the keys of the resolution cache
+type cacheKeys struct {
+	// namespace is the start of every key, a hash of the fingerprints
+	namespace string
+	// structural is set when payloads are keyed by their shape instead of by their bytes
+	structural bool
+	// types maps the names stored in the cache back to the types
+	types map[string]reflect.Type
+}
+
+func newCacheKeys(env environment, resolver Resolver) *cacheKeys {
+	k := &cacheKeys{
+		types: make(map[string]reflect.Type, len(env.candidates)+len(env.selectors)),
+	}
+
+	for _, c := range env.candidates {
+		k.types[fullTypeName(c.typ)] = c.typ
+	}
+
+	for _, s := range env.selectors {
+		k.types[fullTypeName(s.typ)] = s.typ
+	}
+
+	h := sha256.New()
+	r, ok := resolver.(*traverseResolver)
+	if !ok {
+		h.Write([]byte(env.resolverName))
+		k.namespace = hex.EncodeToString(h.Sum(nil)[:8])
+		return k
+	}
+
+	k.structural = len(env.selectors) == 0 && env.coerce.isZero()
+	for _, fp := range r.fingerprints {
+		h.Write([]byte(fullTypeName(fp.candidate.typ)))
+		for _, path := range sortPaths(fp.paths) {
+			h.Write([]byte("\x00" + path + "\x00" + fp.paths[path].String()))
+		}
+
+		h.Write([]byte{'\n'})
+		if fp.candidate.custom() || fp.candidate.version != nil || len(fp.candidate.matchers) > 0 ||
+			!shapeOnly(fp.all) || fp.strict != nil && !shapeOnly(fp.strict.paths) {
+			k.structural = false
+		}
+	}
+
+	k.namespace = hex.EncodeToString(h.Sum(nil)[:8])
+	return k
+}
+
+// shapeOnly reports whether the paths only look at the types of values, and not at the values themselves
+func shapeOnly(paths jsonPaths) bool {
+	for _, typ := range paths {
+		if typ.oneOf != nil || typ.format != "" || typ.layout != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// key returns the key of the payload b, parsed into res
+func (k *cacheKeys) key(b []byte, res gjson.Result) string {
+	var sum [sha256.Size]byte
+	if k.structural {
+		h := sha256.New()
+		writeShape(h.Write, res)
+		h.Sum(sum[:0])
+	} else {
+		sum = sha256.Sum256(b)
+	}
+
+	return k.namespace + ":" + hex.EncodeToString(sum[:])
+}
+
+// writeShape writes the keys of res and the types of its values, with the keys of objects sorted since their order
+// doesn't change the resolution. Booleans are written alike, whether true or false
+func writeShape(write func(b []byte) (int, error), res gjson.Result) {
+	switch {
+	case res.IsObject():
+		type member struct {
+			key   string
+			value gjson.Result
+		}
+
+		var members []member
+		res.ForEach(func(key, value gjson.Result) bool {
+			members = append(members, member{key.String(), value})
+			return true
+		})
+
+		// Stable, since the first of repeated keys is the one looked at
+		slices.SortStableFunc(members, func(a, b member) int {
+			return strings.Compare(a.key, b.key)
+		})
+
+		write([]byte{'{'})
+		for _, m := range members {
+			// Keys are prefixed by their length, so no key can pass for several
+			write(strconv.AppendInt(nil, int64(len(m.key)), 10))
+			write([]byte{':'})
+			write([]byte(m.key))
+			writeShape(write, m.value)
+		}
+
+		write([]byte{'}'})
+	case res.IsArray():
+		write([]byte{'['})
+		res.ForEach(func(_, value gjson.Result) bool {
+			writeShape(write, value)
+			return true
+		})
+
+		write([]byte{']'})
+	case res.Type == gjson.True || res.Type == gjson.False:
+		write([]byte{'b'})
+	case res.Type == gjson.Number:
+		write([]byte{'n'})
+	case res.Type == gjson.String:
+		write([]byte{'s'})
+	default:
+		write([]byte{'z'})
+	}
+}
+
+// resolveCached is resolve, going through the cache for payloads without a context
+func (u *unmarshaler) resolveCached(b []byte, q *query) (reflect.Type, error) {
+	if u.cache == nil || q.ctx.Hint != "" || len(q.ctx.Metadata) > 0 {
+		return u.resolve(q)
+	}
+
+	key := u.cacheKeys.key(b, q.res)
+	if name, ok := u.cache.Get(key); ok {
+		if name == "" {
+			return nil, nil
+		}
+
+		// Names of types this Unmarshaler doesn't have are resolved anew
+		if typ, ok := u.cacheKeys.types[name]; ok {
+			return typ, nil
+		}
+	}
+
+	typ, err := u.resolve(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var name string
+	if typ != nil {
+		name = fullTypeName(typ)
+	}
+
+	u.cache.Add(key, name)
+	return typ, nil
+}
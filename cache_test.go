@@ -0,0 +1,244 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+type cacheOrder struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+type cacheRefund struct {
+	RefundOf string `json:"refund_of"`
+}
+
+func TestCacheResolutions(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		payloads  []string
+		hint      string
+		wantCalls int64
+	}{
+		{"repeated payload", 2, []string{`{"id":"a","total":1}`, `{"id":"a","total":1}`}, "", 1},
+		{"distinct payloads", 2, []string{`{"id":"a","total":1}`, `{"id":"b","total":1}`}, "", 2},
+		{"evicted", 1, []string{`{"id":"a","total":1}`, `{"id":"b","total":1}`, `{"id":"a","total":1}`}, "", 3},
+		{"hinted", 2, []string{`{"id":"a","total":1}`, `{"id":"a","total":1}`}, "order", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The matcher tells how many times the payloads were resolved
+			var calls atomic.Int64
+			u, err := New(
+				Match(Candidate(cacheOrder{}), func(gjson.Result) bool { calls.Add(1); return true }),
+				Candidate(cacheRefund{}),
+				CacheResolutions(tt.size),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, p := range tt.payloads {
+				v, err := u.UnmarshalJSONHint([]byte(p), tt.hint)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, ok := v.(*cacheOrder); !ok {
+					t.Fatalf("UnmarshalJSONHint() = %#v, want a *cacheOrder", v)
+				}
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("resolved %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCacheResolutionsParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		wantErr error
+	}{
+		{"zero size", []Parameter{CacheResolutions(0)}, ErrInvalidParameter},
+		{"twice", []Parameter{CacheResolutions(1), CacheResolutions(2)}, ErrDuplicateParameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(append(tt.params, Candidate(cacheOrder{}))...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// keysCache is a ResolutionCache remembering every key it was given
+type keysCache struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+func (c *keysCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	typ, ok := c.entries[key]
+	return typ, ok
+}
+
+func (c *keysCache) Add(key, typ string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = typ
+}
+
+func TestUseCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   []Parameter
+		payloads []string
+		wantKeys int
+	}{
+		{
+			name:     "same shape",
+			params:   []Parameter{Candidate(cacheOrder{}), Candidate(cacheRefund{})},
+			payloads: []string{`{"id":"a","total":1}`, `{"total":2,"id":"b"}`},
+			wantKeys: 1,
+		},
+		{
+			name:     "other shape",
+			params:   []Parameter{Candidate(cacheOrder{}), Candidate(cacheRefund{})},
+			payloads: []string{`{"id":"a","total":1}`, `{"id":"a","total":1,"note":"x"}`},
+			wantKeys: 2,
+		},
+		{
+			name:     "selectors key by bytes",
+			params:   []Parameter{SelectOn("refund_of", "x", cacheOrder{}), Candidate(cacheRefund{})},
+			payloads: []string{`{"refund_of":"a"}`, `{"refund_of":"b"}`},
+			wantKeys: 2,
+		},
+		{
+			name:     "no match",
+			params:   []Parameter{Candidate(cacheOrder{}), Candidate(cacheRefund{})},
+			payloads: []string{`{"other":1}`, `{"other":2}`},
+			wantKeys: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &keysCache{entries: make(map[string]string)}
+			u, err := New(append(tt.params, UseCache(c))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, p := range tt.payloads {
+				_, err := u.UnmarshalJSON([]byte(p))
+				if err != nil && !errors.Is(err, ErrNoMatch) {
+					t.Fatal(err)
+				}
+			}
+
+			if len(c.entries) != tt.wantKeys {
+				t.Errorf("cached %v, want %d keys", c.entries, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestUseCacheShared(t *testing.T) {
+	c := &keysCache{entries: make(map[string]string)}
+	orders, err := New(Candidate(cacheOrder{}), UseCache(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	both, err := New(Candidate(cacheOrder{}), Candidate(cacheRefund{}), UseCache(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"id":"a","total":1}`)
+	for _, u := range []*Unmarshaler{orders, both} {
+		_, err := u.UnmarshalJSON(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Unmarshalers with other candidates don't share resolutions
+	if len(c.entries) != 2 {
+		t.Fatalf("cached %v, want 2 keys", c.entries)
+	}
+
+	// Names of types the Unmarshaler doesn't have are resolved anew
+	for key := range c.entries {
+		c.entries[key] = "unknown.Type"
+	}
+
+	v, err := both.UnmarshalJSON(payload)
+	if _, ok := v.(*cacheOrder); !ok || err != nil {
+		t.Errorf("UnmarshalJSON() = %#v, %v, want a *cacheOrder", v, err)
+	}
+
+	for key, typ := range c.entries {
+		if strings.HasPrefix(key, both.load().cacheKeys.namespace) && typ != fullTypeName(reflect.TypeOf(cacheOrder{})) {
+			t.Errorf("cached %q for %s, want the type resolved anew", typ, key)
+		}
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		ttl  time.Duration
+		// ops are "+key" to add a key, resolved to the type named as the key, and "key" to get it
+		ops  []string
+		want map[string]bool
+	}{
+		{"kept", 2, 0, []string{"+a", "+b"}, map[string]bool{"a": true, "b": true}},
+		{"least recently added", 2, 0, []string{"+a", "+b", "+c"}, map[string]bool{"a": false, "b": true, "c": true}},
+		{"least recently used", 2, 0, []string{"+a", "+b", "a", "+c"}, map[string]bool{"a": true, "b": false, "c": true}},
+		{"added again", 2, 0, []string{"+a", "+b", "+a", "+c"}, map[string]bool{"a": true, "b": false, "c": true}},
+		{"expired", 2, time.Nanosecond, []string{"+a"}, map[string]bool{"a": false}},
+		{"not expired", 2, time.Hour, []string{"+a"}, map[string]bool{"a": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLRUCache(tt.size, tt.ttl)
+			for _, op := range tt.ops {
+				if key, ok := strings.CutPrefix(op, "+"); ok {
+					c.Add(key, key)
+				} else {
+					c.Get(key)
+				}
+			}
+
+			time.Sleep(time.Millisecond)
+			for key, want := range tt.want {
+				typ, ok := c.Get(key)
+				if ok != want || ok && typ != key {
+					t.Errorf("Get(%q) = %q, %v, want %v", key, typ, ok, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Check verifies that every candidate can still be resolved to, which New already makes sure of unless EnableLenient
+// lets some through. Candidates that can't be told apart from others are reported with ErrAmbiguous, and those whose
+// payloads are always taken by a candidate checked before them with ErrUnreachable. All the problems found are joined
+// in the error returned, nil if there are none.
+//
+// Only fingerprints are checked. Selectors depend on values, and candidates of resolvers given with UseResolver are up
+// to them
+func (u *Unmarshaler) Check() error {
+	s := u.load()
+	err := s.ready()
+	if err != nil {
+		return err
+	}
+
+	r, ok := s.resolver.(*traverseResolver)
+	if !ok {
+		return nil
+	}
+
+	return r.check()
+}
+
+func (r *traverseResolver) check() error {
+	var errs []error
+	for i, fp := range r.fingerprints {
+		if fp.candidate.routed {
+			// Reachable through the when options of its tags anyway
+			continue
+		}
+
+		if twin := r.foreignTwin(fp); twin != nil {
+			errs = append(errs, fmt.Errorf("%w: %s and %s have the same wire shape, but come from different packages",
+				ErrAmbiguous, fullTypeName(fp.candidate.typ), fullTypeName(twin.typ)))
+			continue
+		}
+
+		if len(fp.ambiguous) > 0 {
+			errs = append(errs, fmt.Errorf("%w: %s can't be told apart from %s", ErrAmbiguous, fp.candidate,
+				typeNames(fp.ambiguous)))
+			continue
+		}
+
+		for _, earlier := range r.fingerprints[:i] {
+			if earlier.shadows(fp) {
+				errs = append(errs, fmt.Errorf("%w: %s is always matched by %s first", ErrUnreachable, fp.candidate,
+					earlier.candidate))
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// shadows reports whether every payload matching other also matches f. It only looks at the paths, so fingerprints
+// with any other requirement are never said to shadow
+func (f fingerprint) shadows(other fingerprint) bool {
+	if len(f.ambiguous) > 0 || f.candidate.version != nil || f.strict != nil || f.candidate.custom() ||
+		len(f.candidate.matchers) > 0 {
+		return false
+	}
+
+	if other.anyOf || other.candidate.custom() || len(f.paths) == 0 {
+		return false
+	}
+
+	if f.anyOf {
+		for path, typ := range f.paths {
+			if other.paths[path] == typ {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for path, typ := range f.paths {
+		if otherType, ok := other.paths[path]; !ok || otherType != typ {
+			return false
+		}
+	}
+
+	return true
+}
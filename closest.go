@@ -0,0 +1,108 @@
+package turnip
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxHintPaths caps the paths listed by a hint, so a payload of something else entirely doesn't flood the error
+const maxHintPaths = 5
+
+// closestMatch describes the candidate sharing the most paths with a payload that matched nothing, along with what
+// kept it from matching, as in "closest: OrderCreated (missing: items, total; unexpected: error_code)". It's empty if
+// no candidate shares a single path with the payload
+func (r *traverseResolver) closestMatch(res gjson.Result) string {
+	var best *fingerprint
+	bestCount := 0
+	for i, fp := range r.fingerprints {
+		count := 0
+		for path, typ := range fp.all {
+			if typ.matches(typ.get(res, path)) {
+				count++
+			}
+		}
+
+		if count > bestCount {
+			best, bestCount = &r.fingerprints[i], count
+		}
+	}
+
+	if best == nil {
+		return ""
+	}
+
+	var missing, mismatched []string
+	for _, path := range sortPaths(best.all) {
+		typ := best.all[path]
+		v := typ.get(res, path)
+		switch {
+		case !v.Exists():
+			missing = append(missing, path)
+		case !typ.matches(v):
+			mismatched = append(mismatched, fmt.Sprintf("%s not %s", path, typ))
+		}
+	}
+
+	var details []string
+	if len(missing) > 0 {
+		details = append(details, "missing: "+hintList(missing))
+	}
+
+	if len(mismatched) > 0 {
+		details = append(details, "mismatched: "+hintList(mismatched))
+	}
+
+	if unexpected := unexpectedPaths(res, best.all, r.env.naming); len(unexpected) > 0 {
+		details = append(details, "unexpected: "+hintList(unexpected))
+	}
+
+	if len(details) == 0 {
+		return "closest: " + best.candidate.String()
+	}
+
+	return fmt.Sprintf("closest: %s (%s)", best.candidate, strings.Join(details, "; "))
+}
+
+// unexpectedPaths returns the paths of the payload that are no path of the candidate, nor lead to one. Objects are
+// only followed if the candidate has paths under them, so a single path stands for a whole unknown object
+func unexpectedPaths(res gjson.Result, paths jsonPaths, naming Naming) []string {
+	known := make(map[string]bool, len(paths))
+	for path, typ := range paths {
+		known[path] = true
+		if typ.aliases != nil {
+			for _, alias := range typ.aliases.paths {
+				known[alias] = true
+			}
+		}
+	}
+
+	var unexpected []string
+	var walk func(curr string, obj gjson.Result)
+	walk = func(curr string, obj gjson.Result) {
+		obj.ForEach(func(key, value gjson.Result) bool {
+			path := appendToPath(curr, key.String(), naming)
+			switch {
+			case known[path]:
+			case value.IsObject() && hasPathUnder(paths, path):
+				walk(path, value)
+			default:
+				unexpected = append(unexpected, path)
+			}
+
+			return true
+		})
+	}
+
+	walk("", res)
+	return unexpected
+}
+
+func hintList(paths []string) string {
+	if len(paths) <= maxHintPaths {
+		return strings.Join(paths, ", ")
+	}
+
+	return fmt.Sprintf("%s and %d more", strings.Join(paths[:maxHintPaths], ", "), len(paths)-maxHintPaths)
+}
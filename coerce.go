@@ -0,0 +1,180 @@
+package turnip
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// coercions are the conversions between scalars enabled by the settings, applied alike when fingerprinting and when
+// decoding so that a payload that matches a candidate also decodes into it
+type coercions struct {
+	// quotedNumbers accepts strings holding a number, like "42", for numeric fields
+	quotedNumbers bool
+	// numbersToStrings accepts numbers for string fields, taking their text as is
+	numbersToStrings bool
+	// booleans accepts "true" and "false" strings, and the numbers 0 and 1, for boolean fields
+	booleans bool
+}
+
+func coercionsOf(s settings) coercions {
+	return coercions{
+		quotedNumbers:    s.Get(coerceQuotedNumbers),
+		numbersToStrings: s.Get(coerceNumbersToStrings),
+		booleans:         s.Get(coerceBooleans),
+	}
+}
+
+func (c coercions) isZero() bool {
+	return c == coercions{}
+}
+
+// apply converts v to the type wanted by a path, if it's allowed to. Values that can't be converted are returned as
+// they are, and fail to match as usual
+func (c coercions) apply(v gjson.Result, want gjson.Type) gjson.Result {
+	switch {
+	case c.quotedNumbers && want == gjson.Number && v.Type == gjson.String && isJSONNumber(v.Str):
+		return gjson.Result{
+			Type: gjson.Number,
+			Raw:  v.Str,
+			Num:  gjson.Parse(v.Str).Num,
+		}
+	case c.numbersToStrings && want == gjson.String && v.Type == gjson.Number:
+		return gjson.Result{
+			Type: gjson.String,
+			Raw:  strconv.Quote(v.Raw),
+			Str:  v.Raw,
+		}
+	case c.booleans && (want == gjson.True || want == gjson.False):
+		b, ok := weakBool(v.Value())
+		if !ok {
+			return v
+		}
+
+		if b {
+			return gjson.Result{Type: gjson.True, Raw: "true"}
+		}
+
+		return gjson.Result{Type: gjson.False, Raw: "false"}
+	default:
+		return v
+	}
+}
+
+// coerce converts the values of the fields of t in the payload v so encoding/json accepts them, and reports whether
+// any was converted
+func (c coercions) coerce(v any, t reflect.Type) (any, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if _, ok := getLeafType(t); ok {
+		return v, false
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if s, ok := v.(string); ok && c.quotedNumbers && isJSONNumber(s) {
+			return json.Number(s), true
+		}
+	case reflect.String:
+		if n, ok := v.(json.Number); ok && c.numbersToStrings {
+			return n.String(), true
+		}
+	case reflect.Bool:
+		if b, ok := weakBool(v); ok && c.booleans {
+			return b, true
+		}
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v, false
+		}
+
+		var coerced bool
+		for _, f := range wireFields(t, jsonFormat) {
+			key, ok := findKey(obj, f.name)
+			if !ok || f.quoted {
+				continue
+			}
+
+			var changed bool
+			obj[key], changed = c.coerce(obj[key], f.typ)
+			coerced = coerced || changed
+		}
+
+		return v, coerced
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			return v, false
+		}
+
+		var coerced bool
+		for i := range arr {
+			var changed bool
+			arr[i], changed = c.coerce(arr[i], t.Elem())
+			coerced = coerced || changed
+		}
+
+		return v, coerced
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v, false
+		}
+
+		var coerced bool
+		for key := range obj {
+			var changed bool
+			obj[key], changed = c.coerce(obj[key], t.Elem())
+			coerced = coerced || changed
+		}
+
+		return v, coerced
+	}
+
+	return v, false
+}
+
+// isJSONNumber reports whether s is a number as JSON writes them, which rules out things like "0x10", "1e" or "NaN"
+func isJSONNumber(s string) bool {
+	if s == "" || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) {
+		return false
+	}
+
+	return json.Valid([]byte(s))
+}
+
+// weakBool reads the booleans of weakly typed payloads. v is a value as decoded by either gjson or encoding/json
+func weakBool(v any) (bool, bool) {
+	switch v := v.(type) {
+	case string:
+		switch v {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	case float64:
+		switch v {
+		case 1:
+			return true, true
+		case 0:
+			return false, true
+		}
+	case json.Number:
+		switch v {
+		case "1":
+			return true, true
+		case "0":
+			return false, true
+		}
+	}
+
+	return false, false
+}
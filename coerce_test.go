@@ -0,0 +1,117 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type coercedItem struct {
+	SKU      string  `json:"sku"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+func TestCoercions(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "none",
+			payload: `{"sku":"a","quantity":"2","price":1.5}`,
+			wantErr: ErrDecode,
+		},
+		{
+			name:    "quoted numbers",
+			params:  []Parameter{CoerceQuotedNumbers()},
+			payload: `{"sku":"a","quantity":"2","price":"1.5"}`,
+			want:    &coercedItem{SKU: "a", Quantity: 2, Price: 1.5},
+		},
+		{
+			name:    "quoted text",
+			params:  []Parameter{CoerceQuotedNumbers()},
+			payload: `{"sku":"a","quantity":"two","price":1.5}`,
+			wantErr: ErrDecode,
+		},
+		{
+			name:    "numbers to strings",
+			params:  []Parameter{CoerceNumbersToStrings()},
+			payload: `{"sku":1234,"quantity":2,"price":1.5}`,
+			want:    &coercedItem{SKU: "1234", Quantity: 2, Price: 1.5},
+		},
+		{
+			name:    "numbers to strings as written",
+			params:  []Parameter{CoerceNumbersToStrings()},
+			payload: `{"sku":1.50,"quantity":2,"price":1.5}`,
+			want:    &coercedItem{SKU: "1.50", Quantity: 2, Price: 1.5},
+		},
+		{
+			name:    "both ways",
+			params:  []Parameter{CoerceQuotedNumbers(), CoerceNumbersToStrings()},
+			payload: `{"sku":7,"quantity":"2","price":1.5}`,
+			want:    &coercedItem{SKU: "7", Quantity: 2, Price: 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(append(tt.params, Candidate(coercedItem{}))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+type coercedFlags struct {
+	ID     string `json:"id"`
+	Active bool   `json:"active"`
+}
+
+func TestCoerceBooleans(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    bool
+		wantErr error
+	}{
+		{"true", `{"id":"a","active":true}`, true, nil},
+		{"string true", `{"id":"a","active":"true"}`, true, nil},
+		{"string false", `{"id":"a","active":"false"}`, false, nil},
+		{"one", `{"id":"a","active":1}`, true, nil},
+		{"zero", `{"id":"a","active":0}`, false, nil},
+		{"other number", `{"id":"a","active":2}`, false, ErrDecode},
+		{"other string", `{"id":"a","active":"yes"}`, false, ErrDecode},
+	}
+
+	u, err := New(Candidate(coercedFlags{}), CoerceBooleans())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && got.(*coercedFlags).Active != tt.want {
+				t.Errorf("UnmarshalJSON() = %#v, want active %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package turnip
+
+import (
+	"cmp"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// EncodingMismatch is a path where the fingerprint of a candidate and what encoding/json writes for it disagree, as
+// returned by CompareEncoding
+type EncodingMismatch struct {
+	Type reflect.Type
+	// Path is written as in the fingerprints, following the Naming
+	Path   string
+	Reason string
+}
+
+func (m EncodingMismatch) String() string {
+	return fmt.Sprintf("%s: %s: %s", m.Type, m.Path, m.Reason)
+}
+
+// CompareEncoding checks the paths derived for every candidate against what encoding/json writes when marshaling a
+// populated value of it, every field set to something other than its zero value. Mismatches point to fields that are
+// named, promoted or encoded differently than turnip thinks, and so to payloads that won't resolve as expected. Types
+// that marshal themselves are trusted, and interfaces are left nil. Fields encoding/json can't marshal, like the
+// channels and functions of candidates allowed by EnableLenient, are left out, so they aren't reported either.
+//
+// The comparison only makes sense for the json tags, so it fails if another tag key or format is used
+func (u *Unmarshaler) CompareEncoding() ([]EncodingMismatch, error) {
+	s := u.load()
+	err := s.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.env.format != jsonFormat {
+		return nil, fmt.Errorf("can't compare with encoding/json when using format %s", s.env.format.name)
+	}
+
+	r, ok := s.resolver.(*traverseResolver)
+	if !ok {
+		return nil, fmt.Errorf("comparing needs the fingerprints, not a %T", s.resolver)
+	}
+
+	var mismatches []EncodingMismatch
+	for _, fp := range r.fingerprints {
+		if fp.candidate.dynamic != nil {
+			// Described by their fields, so there's no Go encoding to compare with
+			continue
+		}
+
+		// Lenient candidates may have fields encoding/json can't marshal, which their fingerprints skip too. The types
+		// copied without them are only left as they are where they recurse, which populate doesn't follow
+		copied := make(map[reflect.Type]bool)
+		typ, _ := marshalable(fp.candidate.typ, make(map[reflect.Type]bool), copied)
+		v := reflect.New(typ).Elem()
+		populate(v, copied)
+
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("%s: marshal: %w", fp.candidate.typ, err)
+		}
+
+		mismatches = append(mismatches, compareEncoding(fp.candidate.typ, fp.all, gjson.ParseBytes(b), s.env.naming)...)
+	}
+
+	return mismatches, nil
+}
+
+func compareEncoding(typ reflect.Type, paths jsonPaths, res gjson.Result, naming Naming) []EncodingMismatch {
+	var mismatches []EncodingMismatch
+	add := func(path, format string, args ...any) {
+		mismatches = append(mismatches, EncodingMismatch{
+			Type:   typ,
+			Path:   path,
+			Reason: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, path := range sortPaths(paths) {
+		pt := paths[path]
+		v := naming.get(res, path)
+		switch {
+		case !v.Exists():
+			add(path, "fingerprinted, but not written by encoding/json")
+		case v.Type == gjson.Null:
+			// Nil interfaces and the like, there's nothing to compare
+		case !matchesJSONType(v, pt.json):
+			add(path, "fingerprinted as %s, but written as %s", typeName(pt.json), v.Type)
+		}
+	}
+
+	var walk func(curr string, obj gjson.Result)
+	walk = func(curr string, obj gjson.Result) {
+		obj.ForEach(func(key, value gjson.Result) bool {
+			path := appendToPath(curr, key.String(), naming)
+			if _, ok := paths[path]; ok {
+				return true
+			}
+
+			if value.IsObject() && hasPathUnder(paths, path) {
+				walk(path, value)
+				return true
+			}
+
+			add(path, "written by encoding/json, but not fingerprinted")
+			return true
+		})
+	}
+
+	walk("", res)
+
+	sort.SliceStable(mismatches, func(i, j int) bool {
+		return mismatches[i].Path < mismatches[j].Path
+	})
+
+	return mismatches
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// marshalable returns t, or a copy of it without the fields encoding/json can't marshal, and false if t itself can't
+// be. Only the struct types having such fields, and those holding them, are copied, and added to copied. Copies have
+// no methods, so their embedded structs are inlined, following the rules encoding/json promotes fields with
+func marshalable(t reflect.Type, visiting, copied map[reflect.Type]bool) (reflect.Type, bool) {
+	if _, ok := getLeafType(t); ok || marshalsItself(t) {
+		return t, true
+	}
+
+	var elem reflect.Type
+	if k := t.Kind(); k == reflect.Pointer || k == reflect.Slice || k == reflect.Array || k == reflect.Map {
+		var ok bool
+		elem, ok = marshalable(t.Elem(), visiting, copied)
+		if !ok {
+			return nil, false
+		}
+
+		if elem == t.Elem() {
+			return t, true
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return nil, false
+	case reflect.Pointer:
+		return reflect.PointerTo(elem), true
+	case reflect.Slice:
+		return reflect.SliceOf(elem), true
+	case reflect.Array:
+		return reflect.ArrayOf(t.Len(), elem), true
+	case reflect.Map:
+		return reflect.MapOf(t.Key(), elem), true
+	case reflect.Struct:
+		if visiting[t] {
+			return t, true
+		}
+
+		fields, changed := marshalableFields(t, 0, visiting, copied)
+		if !changed {
+			return t, true
+		}
+
+		copied[t] = true
+		return reflect.StructOf(promoted(fields)), true
+	default:
+		return t, true
+	}
+}
+
+func marshalsItself(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	return t.Implements(jsonMarshalerType) || p.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		p.Implements(textMarshalerType)
+}
+
+// marshalableField is a field of a struct copied by marshalable, with its name on the wire
+type marshalableField struct {
+	name string
+	// opts are the options of its json tag, comma included
+	opts   string
+	typ    reflect.Type
+	depth  int
+	tagged bool
+}
+
+// marshalableFields returns the fields encoding/json marshals of the struct t, embedded at depth, with those of its
+// embedded structs inlined. It also reports whether any of them was left out or copied
+func marshalableFields(t reflect.Type, depth int, visiting, copied map[reflect.Type]bool) ([]marshalableField,
+	bool) {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	var fields []marshalableField
+	changed := false
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if opts != "" {
+			opts = "," + opts
+		}
+
+		embedded := sf.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+
+		if sf.Anonymous && name == "" && embedded.Kind() == reflect.Struct && !marshalsItself(embedded) {
+			if visiting[embedded] {
+				continue
+			}
+
+			inner, innerChanged := marshalableFields(embedded, depth+1, visiting, copied)
+			fields = append(fields, inner...)
+			changed = changed || innerChanged
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		typ, ok := marshalable(sf.Type, visiting, copied)
+		if !ok {
+			changed = true
+			continue
+		}
+
+		changed = changed || typ != sf.Type
+		fields = append(fields, marshalableField{
+			name:   cmp.Or(name, sf.Name),
+			opts:   opts,
+			typ:    typ,
+			depth:  depth,
+			tagged: name != "",
+		})
+	}
+
+	return fields, changed
+}
+
+// promoted returns the struct fields marshaling as fields do, keeping for each name the least nested field, or the
+// only one tagged among them, and none if that's still not a single one
+func promoted(fields []marshalableField) []reflect.StructField {
+	byName := make(map[string][]marshalableField)
+	for _, f := range fields {
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	var sfs []reflect.StructField
+	for _, f := range fields {
+		if !dominant(f, byName[f.name]) {
+			continue
+		}
+
+		sfs = append(sfs, reflect.StructField{
+			Name: fmt.Sprintf("F%d", len(sfs)),
+			Type: f.typ,
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:%q`, f.name+f.opts)),
+		})
+	}
+
+	return sfs
+}
+
+// dominant reports whether f is the field marshaled for its name, out of every field having it
+func dominant(f marshalableField, named []marshalableField) bool {
+	var rivals int
+	for _, other := range named {
+		switch {
+		case other.depth < f.depth:
+			return false
+		case other.depth == f.depth && other.tagged == f.tagged:
+			rivals++
+		case other.depth == f.depth && other.tagged:
+			return false
+		}
+	}
+
+	return rivals == 1
+}
+
+// populate sets every field reachable from v to a value other than its zero value, so omitempty doesn't hide it.
+// Recursive types are only followed once
+func populate(v reflect.Value, visiting map[reflect.Type]bool) {
+	t := v.Type()
+	if _, ok := getLeafType(t); ok {
+		if t == timeType {
+			v.Set(reflect.ValueOf(sampleTime))
+		}
+
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(strings.ToLower(t.Name()) + "-value")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Pointer:
+		if visiting[t.Elem()] {
+			return
+		}
+
+		p := reflect.New(t.Elem())
+		populate(p.Elem(), visiting)
+		v.Set(p)
+	case reflect.Slice:
+		if visiting[t.Elem()] {
+			return
+		}
+
+		s := reflect.MakeSlice(t, 1, 1)
+		populate(s.Index(0), visiting)
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			populate(v.Index(i), visiting)
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String || visiting[t.Elem()] {
+			return
+		}
+
+		key := reflect.New(t.Key()).Elem()
+		key.SetString("key")
+		elem := reflect.New(t.Elem()).Elem()
+		populate(elem, visiting)
+
+		m := reflect.MakeMap(t)
+		m.SetMapIndex(key, elem)
+		v.Set(m)
+	case reflect.Struct:
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() && !sf.Anonymous {
+				continue
+			}
+
+			// Embedded unexported structs can't be set as a whole, but their exported fields can
+			f := v.Field(i)
+			if f.CanSet() || f.Kind() == reflect.Struct {
+				populate(f, visiting)
+			}
+		}
+	}
+}
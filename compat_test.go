@@ -0,0 +1,112 @@
+package turnip
+
+import (
+	"reflect"
+	"testing"
+)
+
+type compatOrder struct {
+	ID    string   `json:"id"`
+	Total float64  `json:"total"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+type compatWorker struct {
+	ID   string       `json:"id"`
+	Jobs chan string  `json:"jobs"`
+	Run  func() error `json:"run"`
+}
+
+type compatChain struct {
+	ID   string       `json:"id"`
+	Jobs chan string  `json:"jobs"`
+	Next *compatChain `json:"next,omitempty"`
+}
+
+type compatHooks struct {
+	OnDone func()      `json:"on_done"`
+	Events chan string `json:"events"`
+	Name   string      `json:"name"`
+	// Hidden by the id of compatJob
+	ID int `json:"id"`
+}
+
+type compatJob struct {
+	compatHooks
+	ID     string `json:"id"`
+	Weight complex128
+}
+
+type compatNested struct {
+	ID     string       `json:"id"`
+	Worker compatWorker `json:"worker"`
+}
+
+func TestCompareEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []Parameter
+		want   []EncodingMismatch
+	}{
+		{
+			name:   "same paths",
+			params: []Parameter{Candidate(compatOrder{})},
+		},
+		{
+			name:   "lenient channels and functions",
+			params: []Parameter{Candidate(compatWorker{}), EnableLenient()},
+		},
+		{
+			name:   "lenient embedded channels and functions",
+			params: []Parameter{Candidate(compatJob{}), EnableLenient()},
+			want: []EncodingMismatch{{
+				Type:   reflect.TypeOf(compatJob{}),
+				Path:   "weight",
+				Reason: "fingerprinted, but not written by encoding/json",
+			}},
+		},
+		{
+			name:   "lenient recursive channels",
+			params: []Parameter{Candidate(compatChain{}), EnableLenient()},
+			// Recursive types are only populated once, lenient or not
+			want: []EncodingMismatch{{
+				Type:   reflect.TypeOf(compatChain{}),
+				Path:   "next",
+				Reason: "fingerprinted, but not written by encoding/json",
+			}},
+		},
+		{
+			name:   "lenient nested channels and functions",
+			params: []Parameter{Candidate(compatNested{}), EnableLenient()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.CompareEncoding()
+			if err != nil {
+				t.Fatalf("CompareEncoding() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareEncoding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareEncodingInvalid(t *testing.T) {
+	u, err := New(Candidate(compatOrder{}), TagKey("yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := u.CompareEncoding(); err == nil {
+		t.Error("CompareEncoding() with another tag key error = nil")
+	}
+}
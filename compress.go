@@ -0,0 +1,187 @@
+package turnip
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// EnableDecompression decompresses payloads compressed with gzip, or with deflate in the zlib format HTTP uses for it,
+// before resolving them. They are recognized by their header, so uncompressed payloads go through as usual. Raw
+// deflate streams have no header, and so are not recognized. Payloads can't get larger than MaxDecompressedSize
+// once decompressed
+func EnableDecompression() Parameter {
+	return enableDecompression
+}
+
+// MaxDecompressedSize limits compressed payloads to n bytes once decompressed, so a small payload can't take the
+// process down by decompressing into gigabytes. Larger payloads fail with ErrMalformed as soon as the limit is
+// crossed, without reading the rest. It defaults to 64MiB, applies to each document of a Decoder on its own, and to
+// the bodies read by DecodeResponse, compressed or not
+func MaxDecompressedSize(n int64) Parameter {
+	return decompressionLimit(n)
+}
+
+type decompressionLimit int64
+
+func (l decompressionLimit) Name() string {
+	return "MaxDecompressedSize"
+}
+
+const defaultDecompressionLimit decompressionLimit = 64 << 20
+
+// compression is a format payloads may be compressed in
+type compression int
+
+const (
+	uncompressed compression = iota
+	gzipped
+	zlibbed
+)
+
+// compressionOf tells the format of the payload starting with head, which needs at least two bytes to be recognized
+func compressionOf(head []byte) compression {
+	switch {
+	case len(head) < 2:
+		return uncompressed
+	case head[0] == 0x1f && head[1] == 0x8b:
+		return gzipped
+	case head[0]&0x0f == 8 && head[0]>>4 <= 7 && (uint(head[0])<<8|uint(head[1]))%31 == 0:
+		// Deflate with a window of up to 32KiB, and a valid check of the header. JSON starts with whitespace or a
+		// bracket, so it's never taken for one
+		return zlibbed
+	default:
+		return uncompressed
+	}
+}
+
+// newDecompressor returns a reader decompressing r, compressed in c
+func newDecompressor(r io.Reader, c compression) (io.ReadCloser, error) {
+	switch c {
+	case gzipped:
+		return gzip.NewReader(r)
+	case zlibbed:
+		return zlib.NewReader(r)
+	default:
+		return io.NopCloser(r), nil
+	}
+}
+
+// decompress returns the payload b decompressed, up to limit bytes, or as is if it's not compressed
+func decompress(b []byte, limit decompressionLimit) ([]byte, error) {
+	c := compressionOf(b)
+	if c == uncompressed {
+		return b, nil
+	}
+
+	return readDecompressed(bytes.NewReader(b), c, limit)
+}
+
+func readDecompressed(r io.Reader, c compression, limit decompressionLimit) ([]byte, error) {
+	dr, err := newDecompressor(r, c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decompress: %w", ErrMalformed, err)
+	}
+
+	defer dr.Close()
+
+	b, err := io.ReadAll(newLimitedReader(dr, limit))
+	if err != nil {
+		return nil, fmt.Errorf("%w: decompress: %w", ErrMalformed, err)
+	}
+
+	return b, nil
+}
+
+// decompressReader returns a reader of r decompressed, up to limit bytes, or of r as is if it's not compressed
+func decompressReader(r io.Reader, limit decompressionLimit) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(2)
+
+	c := compressionOf(head)
+	if c == uncompressed {
+		return br, nil
+	}
+
+	dr, err := newDecompressor(br, c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decompress: %w", ErrMalformed, err)
+	}
+
+	return newLimitedReader(dr, limit), nil
+}
+
+// limitedReader fails once more than limit bytes are read from r. Unlike io.LimitReader, it tells payloads that are
+// too large apart from those that end right at the limit
+type limitedReader struct {
+	r     io.Reader
+	limit decompressionLimit
+	// left is how many bytes can still be read
+	left int64
+}
+
+func newLimitedReader(r io.Reader, limit decompressionLimit) *limitedReader {
+	return &limitedReader{r: r, limit: limit, left: int64(limit)}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.left <= 0 {
+		// Anything left past the limit is too much
+		var b [1]byte
+		n, err := l.r.Read(b[:])
+		if n > 0 {
+			return 0, fmt.Errorf("%w: decompress: larger than %d bytes", ErrMalformed, int64(l.limit))
+		}
+
+		return 0, err
+	}
+
+	if int64(len(p)) > l.left {
+		p = p[:l.left]
+	}
+
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	return n, err
+}
+
+// lazyDecompressor decompresses r if needed, but only once it's first read, so creating it doesn't block
+type lazyDecompressor struct {
+	r       io.Reader
+	limit   decompressionLimit
+	started bool
+}
+
+func (l *lazyDecompressor) Read(p []byte) (int, error) {
+	if !l.started {
+		l.started = true
+
+		r, err := decompressReader(l.r, l.limit)
+		if err != nil {
+			l.r = errReader{err}
+			return 0, err
+		}
+
+		l.r = r
+	}
+
+	return l.r.Read(p)
+}
+
+// resetLimit lets another limit of bytes be read, for the next document of a stream
+func (l *lazyDecompressor) resetLimit() {
+	if lr, ok := l.r.(*limitedReader); ok {
+		lr.left = int64(lr.limit)
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,141 @@
+package turnip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type compressedEvent struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+}
+
+func gzipBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func zlibBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, err := w.Write(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecompression(t *testing.T) {
+	small := []byte(`{"id":1,"body":"hello"}`)
+	large := []byte(`{"id":1,"body":"` + strings.Repeat("a", 4096) + `"}`)
+
+	tests := []struct {
+		name    string
+		payload []byte
+		limit   int64
+		wantErr error
+	}{
+		{"uncompressed", small, 0, nil},
+		{"gzip", gzipBytes(t, small), 0, nil},
+		{"zlib", zlibBytes(t, small), 0, nil},
+		{"exactly the limit", gzipBytes(t, small), int64(len(small)), nil},
+		{"over the limit", gzipBytes(t, small), int64(len(small)) - 1, ErrMalformed},
+		{"large over the limit", zlibBytes(t, large), 1024, ErrMalformed},
+		{"uncompressed over the limit", large, 1024, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := []Parameter{Candidate(compressedEvent{}), EnableDecompression()}
+			if tt.limit > 0 {
+				params = append(params, MaxDecompressedSize(tt.limit))
+			}
+
+			u, err := New(params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := u.UnmarshalJSON(tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil {
+				if _, ok := v.(*compressedEvent); !ok {
+					t.Errorf("UnmarshalJSON() = %T, want *compressedEvent", v)
+				}
+			}
+
+			_, err = u.UnmarshalReader(bytes.NewReader(tt.payload), 8)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalReader() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecoderDecompressionLimit(t *testing.T) {
+	doc := []byte(`{"id":1,"body":"` + strings.Repeat("a", 512) + `"}`)
+	stream := gzipBytes(t, bytes.Repeat(doc, 64))
+
+	u, err := New(Candidate(compressedEvent{}), EnableDecompression(), MaxDecompressedSize(8*int64(len(doc))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The limit applies to each document, not to the whole stream
+	dec := u.NewDecoder(bytes.NewReader(stream))
+	for i := 0; ; i++ {
+		_, err := dec.Decode()
+		if err == io.EOF {
+			if i != 64 {
+				t.Fatalf("decoded %d documents, want 64", i)
+			}
+
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("document %d: %v", i, err)
+		}
+	}
+}
+
+func TestMaxDecompressedSizeInvalid(t *testing.T) {
+	_, err := New(Candidate(compressedEvent{}), MaxDecompressedSize(0))
+	if !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("New() error = %v, want %v", err, ErrInvalidParameter)
+	}
+
+	_, err = New(Candidate(compressedEvent{}), MaxDecompressedSize(1), MaxDecompressedSize(2))
+	if !errors.Is(err, ErrDuplicateParameter) {
+		t.Errorf("New() error = %v, want %v", err, ErrDuplicateParameter)
+	}
+}
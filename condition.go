@@ -0,0 +1,400 @@
+package turnip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+
+	"github.com/tidwall/gjson"
+)
+
+// Condition is a check over the payload, used by selectors to route it to a type before any fingerprint is looked
+// at. Paths use the gjson syntax, or are JSON Pointers (RFC 6901) if they start with a slash, like "/data/type"
+type Condition interface {
+	// compile does any preparation the condition needs, once, when the Unmarshaler is created
+	compile() error
+	matches(q *query) bool
+	// paths returns the gjson paths of the payload the condition looks at
+	paths() []string
+}
+
+// query is everything a resolution can look at: the payload, and whatever the caller knows about it
+type query struct {
+	res gjson.Result
+	ctx ResolveContext
+}
+
+// Eq matches when the value at path is equal to v
+func Eq(path string, v any) Condition {
+	c := &eqCondition{value: v}
+	c.path, c.err = gjsonPath(path)
+	return c
+}
+
+type eqCondition struct {
+	path  string
+	value any
+	// err is set for invalid JSON Pointers
+	err error
+}
+
+func (c *eqCondition) compile() error {
+	return c.err
+}
+
+func (c *eqCondition) matches(q *query) bool {
+	return equalsJSON(q.res.Get(c.path), c.value)
+}
+
+func (c *eqCondition) paths() []string {
+	return []string{c.path}
+}
+
+// Exists matches when there is a value at path, even if it's null
+func Exists(path string) Condition {
+	c := &existsCondition{}
+	c.path, c.err = gjsonPath(path)
+	return c
+}
+
+type existsCondition struct {
+	path string
+	// err is set for invalid JSON Pointers
+	err error
+}
+
+func (c *existsCondition) compile() error {
+	return c.err
+}
+
+func (c *existsCondition) matches(q *query) bool {
+	return q.res.Get(c.path).Exists()
+}
+
+func (c *existsCondition) paths() []string {
+	return []string{c.path}
+}
+
+// Query matches when the expression, in the syntax of the path engine registered as engine, finds any value in the
+// payload. It's for routing rules written in another syntax, like JSONPath:
+//
+//	turnip.SelectWhen(turnip.Query("jsonpath", "$.store.book[?(@.price < 10)]"), CheapBooks{})
+//
+// Payloads can't be scanned for the keys queries look at, so ScanKeysAbove is turned off by them
+func Query(engine, expr string) Condition {
+	return &queryCondition{
+		engine: engine,
+		expr:   expr,
+	}
+}
+
+type queryCondition struct {
+	engine, expr string
+	// The same condition can be given to several Unmarshalers being created at once, so it's only compiled once
+	once  sync.Once
+	query PathQuery
+	err   error
+}
+
+func (c *queryCondition) compile() error {
+	c.once.Do(func() {
+		c.query, c.err = compileQuery(c.engine, c.expr)
+	})
+
+	return c.err
+}
+
+func (c *queryCondition) matches(q *query) bool {
+	return len(c.query(q.res)) > 0
+}
+
+func (c *queryCondition) paths() []string {
+	return []string{unknownPath}
+}
+
+// Hint matches when the hint given to UnmarshalJSONHint or in the ResolveContext equals equal, or satisfies it if
+// it's a Predicate. Calls without a hint never match
+func Hint(equal any) Condition {
+	return &hintCondition{
+		equal: equal,
+	}
+}
+
+type hintCondition struct {
+	equal any
+}
+
+func (c *hintCondition) compile() error {
+	if pred, ok := c.equal.(Predicate); ok {
+		return pred.compile()
+	}
+
+	return nil
+}
+
+func (c *hintCondition) matches(q *query) bool {
+	if q.ctx.Hint == "" {
+		return false
+	}
+
+	return testValue(asJSON(q.ctx.Hint), c.equal)
+}
+
+func (c *hintCondition) paths() []string {
+	return nil
+}
+
+// And matches when all the conditions do
+func And(conds ...Condition) Condition {
+	return andCondition(conds)
+}
+
+type andCondition []Condition
+
+func (c andCondition) compile() error {
+	return compileAll(c)
+}
+
+func (c andCondition) matches(q *query) bool {
+	for _, cond := range c {
+		if !cond.matches(q) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c andCondition) paths() []string {
+	return pathsOf(c)
+}
+
+// Or matches when at least one of the conditions does
+func Or(conds ...Condition) Condition {
+	return orCondition(conds)
+}
+
+type orCondition []Condition
+
+func (c orCondition) compile() error {
+	return compileAll(c)
+}
+
+func (c orCondition) matches(q *query) bool {
+	for _, cond := range c {
+		if cond.matches(q) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c orCondition) paths() []string {
+	return pathsOf(c)
+}
+
+// Not matches when the condition doesn't
+func Not(cond Condition) Condition {
+	return &notCondition{
+		cond: cond,
+	}
+}
+
+type notCondition struct {
+	cond Condition
+}
+
+func (c *notCondition) compile() error {
+	if c.cond == nil {
+		return errors.New("nil condition")
+	}
+
+	return c.cond.compile()
+}
+
+func (c *notCondition) matches(q *query) bool {
+	return !c.cond.matches(q)
+}
+
+func (c *notCondition) paths() []string {
+	return c.cond.paths()
+}
+
+func pathsOf(conds []Condition) []string {
+	var paths []string
+	for _, cond := range conds {
+		paths = append(paths, cond.paths()...)
+	}
+
+	return paths
+}
+
+func compileAll(conds []Condition) error {
+	for _, cond := range conds {
+		if cond == nil {
+			return errors.New("nil condition")
+		}
+
+		err := cond.compile()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// equalsJSON compares a JSON value with a Go one, as if the Go value had been decoded from JSON
+func equalsJSON(res gjson.Result, v any) bool {
+	if !res.Exists() {
+		return false
+	}
+
+	if v == nil {
+		return res.Type == gjson.Null
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return res.Type == gjson.String && res.Str == rv.String()
+	case reflect.Bool:
+		return (res.Type == gjson.True || res.Type == gjson.False) && res.Bool() == rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return res.Type == gjson.Number && res.Num == float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return res.Type == gjson.Number && res.Num == float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return res.Type == gjson.Number && res.Num == rv.Float()
+	default:
+		// Anything else is compared by its encoding, after normalizing both sides
+		b, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+
+		var want, got any
+		if json.Unmarshal(b, &want) != nil || json.Unmarshal([]byte(res.Raw), &got) != nil {
+			return false
+		}
+
+		return reflect.DeepEqual(want, got)
+	}
+}
+
+// Predicate is a check over a single value of the payload
+type Predicate interface {
+	compile() error
+	test(v gjson.Result) bool
+}
+
+// Where matches when the value at path satisfies the predicate
+func Where(path string, pred Predicate) Condition {
+	c := &whereCondition{pred: pred}
+	c.path, c.err = gjsonPath(path)
+	return c
+}
+
+type whereCondition struct {
+	path string
+	pred Predicate
+	// err is set for invalid JSON Pointers
+	err error
+}
+
+func (c *whereCondition) compile() error {
+	if c.err != nil {
+		return c.err
+	}
+
+	if c.pred == nil {
+		return fmt.Errorf("%s: nil predicate", c.path)
+	}
+
+	err := c.pred.compile()
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.path, err)
+	}
+
+	return nil
+}
+
+func (c *whereCondition) matches(q *query) bool {
+	return c.pred.test(q.res.Get(c.path))
+}
+
+func (c *whereCondition) paths() []string {
+	return []string{c.path}
+}
+
+// GT checks for numbers greater than n
+func GT(n float64) Predicate {
+	return numberPredicate(func(v float64) bool { return v > n })
+}
+
+// GTE checks for numbers greater than or equal to n
+func GTE(n float64) Predicate {
+	return numberPredicate(func(v float64) bool { return v >= n })
+}
+
+// LT checks for numbers less than n
+func LT(n float64) Predicate {
+	return numberPredicate(func(v float64) bool { return v < n })
+}
+
+// LTE checks for numbers less than or equal to n
+func LTE(n float64) Predicate {
+	return numberPredicate(func(v float64) bool { return v <= n })
+}
+
+// Between checks for numbers in the inclusive range [min, max]
+func Between(min, max float64) Predicate {
+	return numberPredicate(func(v float64) bool { return v >= min && v <= max })
+}
+
+// numberPredicate only accepts JSON numbers, anything else fails the check
+type numberPredicate func(v float64) bool
+
+func (p numberPredicate) compile() error {
+	return nil
+}
+
+func (p numberPredicate) test(v gjson.Result) bool {
+	return v.Type == gjson.Number && p(v.Num)
+}
+
+// Regex checks for strings matching the regular expression. The expression is compiled when the Unmarshaler is
+// created, and New fails if it's not valid
+func Regex(pattern string) Predicate {
+	return &regexPredicate{
+		pattern: pattern,
+	}
+}
+
+type regexPredicate struct {
+	pattern string
+	// The same predicate can be given to several Unmarshalers being created at once, so it's only compiled once
+	once sync.Once
+	re   *regexp.Regexp
+	err  error
+}
+
+func (p *regexPredicate) compile() error {
+	p.once.Do(func() {
+		p.re, p.err = regexp.Compile(p.pattern)
+	})
+
+	if p.err != nil {
+		return fmt.Errorf("regex: %w", p.err)
+	}
+
+	return nil
+}
+
+func (p *regexPredicate) test(v gjson.Result) bool {
+	return v.Type == gjson.String && p.re.MatchString(v.Str)
+}
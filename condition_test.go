@@ -0,0 +1,171 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type selectEvent struct {
+	Type string `json:"type"`
+	Data int    `json:"data"`
+}
+
+type selectCreated struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type selectDeleted struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+func TestSelectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "select on",
+			params:  []Parameter{SelectOn("type", "created", selectCreated{}), SelectOn("type", "deleted", selectDeleted{})},
+			payload: `{"type":"deleted","id":"a"}`,
+			want:    &selectDeleted{Type: "deleted", ID: "a"},
+		},
+		{
+			name:    "eq on a nested path",
+			params:  []Parameter{SelectWhen(Eq("meta.kind", 1), selectCreated{})},
+			payload: `{"meta":{"kind":1},"id":"a"}`,
+			want:    &selectCreated{ID: "a"},
+		},
+		{
+			name:    "eq on an object",
+			params:  []Parameter{SelectWhen(Eq("meta", map[string]any{"kind": 1}), selectCreated{})},
+			payload: `{"meta":{"kind":1},"id":"a"}`,
+			want:    &selectCreated{ID: "a"},
+		},
+		{
+			name:    "exists with null",
+			params:  []Parameter{SelectWhen(Exists("deleted_at"), selectDeleted{})},
+			payload: `{"deleted_at":null,"id":"a"}`,
+			want:    &selectDeleted{ID: "a"},
+		},
+		{
+			name:    "json pointer",
+			params:  []Parameter{SelectWhen(Eq("/meta/kind", "x"), selectCreated{})},
+			payload: `{"meta":{"kind":"x"},"id":"a"}`,
+			want:    &selectCreated{ID: "a"},
+		},
+		{
+			name:    "and with one failing",
+			params:  []Parameter{SelectWhen(And(Eq("type", "created"), Exists("id")), selectCreated{})},
+			payload: `{"type":"created","data":1}`,
+			want:    &selectEvent{Type: "created", Data: 1},
+		},
+		{
+			name:    "or",
+			params:  []Parameter{SelectWhen(Or(Eq("type", "removed"), Eq("type", "deleted")), selectDeleted{})},
+			payload: `{"type":"removed","id":"a"}`,
+			want:    &selectDeleted{Type: "removed", ID: "a"},
+		},
+		{
+			name: "not",
+			params: []Parameter{
+				SelectWhen(Not(Eq("type", "created")), selectDeleted{}),
+				SelectWhen(Eq("type", "created"), selectCreated{}),
+			},
+			payload: `{"type":"created","id":"a"}`,
+			want:    &selectCreated{Type: "created", ID: "a"},
+		},
+		{
+			name: "first selector wins",
+			params: []Parameter{
+				SelectWhen(Exists("id"), selectDeleted{}),
+				SelectOn("type", "created", selectCreated{}),
+			},
+			payload: `{"type":"created","id":"a"}`,
+			want:    &selectDeleted{Type: "created", ID: "a"},
+		},
+		{
+			name:    "falls through to fingerprints",
+			params:  []Parameter{SelectOn("type", "deleted", selectDeleted{})},
+			payload: `{"type":"created","data":1}`,
+			want:    &selectEvent{Type: "created", Data: 1},
+		},
+		{
+			name:    "no selector matching",
+			params:  []Parameter{SelectOn("type", "deleted", selectDeleted{})},
+			payload: `{"kind":"created"}`,
+			wantErr: ErrNoMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The candidate is there for payloads no selector routes
+			u, err := New(append(tt.params, Candidate(selectEvent{}))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	tests := []struct {
+		name    string
+		pred    Predicate
+		payload string
+		want    bool
+	}{
+		{"gt", GT(10), `{"v":11}`, true},
+		{"gt equal", GT(10), `{"v":10}`, false},
+		{"gte equal", GTE(10), `{"v":10}`, true},
+		{"lt", LT(10), `{"v":9.5}`, true},
+		{"lt equal", LT(10), `{"v":10}`, false},
+		{"lte equal", LTE(10), `{"v":10}`, true},
+		{"between lower bound", Between(1, 5), `{"v":1}`, true},
+		{"between upper bound", Between(1, 5), `{"v":5}`, true},
+		{"between out of range", Between(1, 5), `{"v":6}`, false},
+		{"quoted number", GT(10), `{"v":"11"}`, false},
+		{"missing", LT(10), `{}`, false},
+		{"null", LTE(10), `{"v":null}`, false},
+		{"regex", Regex("^ord_[0-9]+$"), `{"v":"ord_12"}`, true},
+		{"regex not matching", Regex("^ord_[0-9]+$"), `{"v":"ord_x"}`, false},
+		{"regex on a number", Regex("1"), `{"v":1}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(SelectWhen(Where("v", tt.pred), selectCreated{}), Candidate(selectEvent{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			_, selected := got.(*selectCreated)
+			if selected != tt.want {
+				t.Errorf("UnmarshalJSON() = %#v, %v, want selected %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidRegex(t *testing.T) {
+	_, err := New(SelectWhen(Where("v", Regex("(")), selectCreated{}), Candidate(selectEvent{}))
+	if !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("New() error = %v, want %v", err, ErrInvalidParameter)
+	}
+}
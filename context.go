@@ -0,0 +1,79 @@
+package turnip
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/tidwall/gjson"
+)
+
+// ResolveContext carries what is known about a payload besides its contents, like the HTTP headers or the topic it
+// came from. Selectors can look at it through the Hint and Meta conditions
+type ResolveContext struct {
+	// Hint is a single value narrowing down what the payload is. An empty hint is no hint
+	Hint string
+	// Metadata holds arbitrary values by key
+	Metadata map[string]any
+	// Context is the context of the call, which resolution never looks at. It's only there for ContextLogger
+	Context context.Context
+}
+
+// ContextResolver is a Resolver that can also take the context of the payload into account
+type ContextResolver interface {
+	Resolver
+	ResolveJSONContext(res gjson.Result, rc ResolveContext) (reflect.Type, error)
+}
+
+// Meta matches when the metadata value under key equals equal, or satisfies it if it's a Predicate
+func Meta(key string, equal any) Condition {
+	return &metaCondition{
+		key:   key,
+		equal: equal,
+	}
+}
+
+type metaCondition struct {
+	key   string
+	equal any
+}
+
+func (c *metaCondition) compile() error {
+	if pred, ok := c.equal.(Predicate); ok {
+		return pred.compile()
+	}
+
+	return nil
+}
+
+func (c *metaCondition) matches(q *query) bool {
+	v, ok := q.ctx.Metadata[c.key]
+	if !ok {
+		return false
+	}
+
+	return testValue(asJSON(v), c.equal)
+}
+
+func (c *metaCondition) paths() []string {
+	return nil
+}
+
+// asJSON turns a Go value into a gjson.Result, so it can go through the same checks as the payload
+func asJSON(v any) gjson.Result {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return gjson.Result{}
+	}
+
+	return gjson.ParseBytes(b)
+}
+
+// testValue checks v against equal, or against the predicate if equal is one
+func testValue(v gjson.Result, equal any) bool {
+	if pred, ok := equal.(Predicate); ok {
+		return pred.test(v)
+	}
+
+	return equalsJSON(v, equal)
+}
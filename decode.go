@@ -0,0 +1,194 @@
+package turnip
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DecodeOption changes how the payloads resolved to a candidate are decoded. Options are given along with the
+// candidate, so a single type needing special handling doesn't force it on every other one:
+//
+//	turnip.Candidate(Invoice{}, turnip.UseNumber(), turnip.TimeLayout(time.DateOnly))
+type DecodeOption func(o *decodeOptions)
+
+type decodeOptions struct {
+	useNumber             bool
+	disallowUnknownFields bool
+	// timeLayout replaces RFC 3339 for the time.Time fields, if set
+	timeLayout string
+}
+
+func (o decodeOptions) isZero() bool {
+	return o == decodeOptions{}
+}
+
+func newDecodeOptions(opts []DecodeOption) decodeOptions {
+	var o decodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// UseNumber decodes numbers into interface fields as a json.Number instead of a float64
+func UseNumber() DecodeOption {
+	return func(o *decodeOptions) {
+		o.useNumber = true
+	}
+}
+
+// DisallowUnknownFields fails the decoding of payloads with fields the candidate doesn't have. Unlike Strict, it
+// doesn't affect matching
+func DisallowUnknownFields() DecodeOption {
+	return func(o *decodeOptions) {
+		o.disallowUnknownFields = true
+	}
+}
+
+// TimeLayout makes the time.Time fields of the candidate, including nested ones, use layout instead of RFC 3339. Their
+// fingerprints follow the layout too
+func TimeLayout(layout string) DecodeOption {
+	return func(o *decodeOptions) {
+		o.timeLayout = layout
+	}
+}
+
+// decodeWith is json.Unmarshal, following the options
+func decodeWith(b []byte, v any, opts decodeOptions) error {
+	if opts.timeLayout != "" {
+		var err error
+		b, err = rewriteTimes(b, reflect.TypeOf(v).Elem(), opts.timeLayout)
+		if err != nil {
+			return err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if opts.useNumber {
+		dec.UseNumber()
+	}
+
+	if opts.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	return dec.Decode(v)
+}
+
+// rewriteTimes converts the values of the time.Time fields of t from layout to RFC 3339, which is the only layout
+// time.Time decodes from
+func rewriteTimes(b []byte, t reflect.Type, layout string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err = rewriteTime(v, t, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+func rewriteTime(v any, t reflect.Type, layout string) (any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		s, ok := v.(string)
+		if !ok {
+			// Let the decoder complain about it
+			return v, nil
+		}
+
+		parsed, err := time.Parse(layout, s)
+		if err != nil {
+			return nil, fmt.Errorf("time '%s' does not follow layout '%s'", s, layout)
+		}
+
+		return parsed.Format(time.RFC3339Nano), nil
+	}
+
+	if _, ok := getLeafType(t); ok {
+		// Decodes itself, there are no fields to look into
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v, nil
+		}
+
+		for _, f := range wireFields(t, jsonFormat) {
+			key, ok := findKey(obj, f.name)
+			if !ok || f.quoted {
+				continue
+			}
+
+			sub, err := rewriteTime(obj[key], f.typ, layout)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.name, err)
+			}
+
+			obj[key] = sub
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			return v, nil
+		}
+
+		for i := range arr {
+			sub, err := rewriteTime(arr[i], t.Elem(), layout)
+			if err != nil {
+				return nil, err
+			}
+
+			arr[i] = sub
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v, nil
+		}
+
+		for key := range obj {
+			sub, err := rewriteTime(obj[key], t.Elem(), layout)
+			if err != nil {
+				return nil, err
+			}
+
+			obj[key] = sub
+		}
+	}
+
+	return v, nil
+}
+
+// findKey is getKey for already decoded objects
+func findKey(obj map[string]any, name string) (string, bool) {
+	if _, ok := obj[name]; ok {
+		return name, true
+	}
+
+	for key := range obj {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,49 @@
+package turnip
+
+import "sync"
+
+// defaultUnmarshaler is the one behind Register and Unmarshal. It's built on first use, and again after any
+// registration
+var defaultUnmarshaler struct {
+	sync.Mutex
+	params []Parameter
+	u      *Unmarshaler
+}
+
+// Register adds parameters to the default Unmarshaler used by Unmarshal, for small programs and tests that don't want
+// to pass one around. It can be called at any time, usually from init functions
+func Register(params ...Parameter) {
+	defaultUnmarshaler.Lock()
+	defer defaultUnmarshaler.Unlock()
+
+	defaultUnmarshaler.params = append(defaultUnmarshaler.params, params...)
+	defaultUnmarshaler.u = nil
+}
+
+// Unmarshal is UnmarshalJSON on the default Unmarshaler. Errors building it, like not having registered any candidate,
+// are returned here
+func Unmarshal(b []byte) (any, error) {
+	u, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+
+	return u.UnmarshalJSON(b)
+}
+
+func getDefault() (*Unmarshaler, error) {
+	defaultUnmarshaler.Lock()
+	defer defaultUnmarshaler.Unlock()
+
+	if defaultUnmarshaler.u != nil {
+		return defaultUnmarshaler.u, nil
+	}
+
+	u, err := New(defaultUnmarshaler.params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultUnmarshaler.u = u
+	return u, nil
+}
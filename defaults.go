@@ -0,0 +1,146 @@
+package turnip
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// defaultTag gives the value of a field when the payload doesn't have it, like `default:"8080"`. Strings are taken as
+// is, types implementing encoding.TextUnmarshaler and time.Duration are parsed from the text, and anything else from
+// JSON, so slices and maps can have defaults too: `default:"[\"a\",\"b\"]"`
+const defaultTag = "default"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyDefaults sets the fields of the struct v missing from res, with keys named as in the format wire, to their
+// default. Nested structs are filled too, but nil pointers to structs are left alone
+func applyDefaults(v reflect.Value, res gjson.Result, wire *format) error {
+	for _, f := range wireFields(v.Type(), wire) {
+		var sub gjson.Result
+		if res.IsObject() {
+			sub = getField(res, f)
+		}
+
+		fv := fieldByIndex(v, f.index)
+		if !fv.IsValid() {
+			continue
+		}
+
+		if tag, ok := v.Type().FieldByIndex(f.index).Tag.Lookup(defaultTag); ok {
+			if sub.Exists() {
+				continue
+			}
+
+			err := setDefault(fv, tag)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+
+			continue
+		}
+
+		for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+
+		if fv.Kind() != reflect.Struct {
+			continue
+		}
+
+		if _, ok := getLeafType(fv.Type()); ok {
+			continue
+		}
+
+		err := applyDefaults(fv, sub, wire)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+func setDefault(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Pointer {
+		elem := reflect.New(fv.Type().Elem())
+		err := setDefault(elem.Elem(), s)
+		if err != nil {
+			return err
+		}
+
+		fv.Set(elem)
+		return nil
+	}
+
+	if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch {
+	case fv.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+
+		fv.SetInt(int64(d))
+		return nil
+	case fv.Kind() == reflect.String:
+		fv.SetString(s)
+		return nil
+	default:
+		return json.Unmarshal([]byte(s), fv.Addr().Interface())
+	}
+}
+
+// checkDefaults reports whether t has fields with a default anywhere, and makes sure they can all be set
+func checkDefaults(t reflect.Type, visiting map[reflect.Type]bool) (bool, error) {
+	if visiting[t] {
+		return false, nil
+	}
+
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	found := false
+	for _, f := range wireFields(t, jsonFormat) {
+		sf := t.FieldByIndex(f.index)
+		if tag, ok := sf.Tag.Lookup(defaultTag); ok {
+			found = true
+
+			err := setDefault(reflect.New(sf.Type).Elem(), tag)
+			if err != nil {
+				return false, fmt.Errorf("%s: default: %w", f.name, err)
+			}
+
+			continue
+		}
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() != reflect.Struct {
+			continue
+		}
+
+		if _, ok := getLeafType(ft); ok {
+			continue
+		}
+
+		nested, err := checkDefaults(ft, visiting)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", f.name, err)
+		}
+
+		found = found || nested
+	}
+
+	return found, nil
+}
@@ -0,0 +1,144 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// DiffReport is how the candidates of two sets of parameters differ, as returned by Diff
+type DiffReport struct {
+	// Added are the candidates only in the new set, and Removed those only in the old one
+	Added   []reflect.Type
+	Removed []reflect.Type
+	// Changed are the candidates in both sets whose paths or fingerprints changed, in the order of the new set
+	Changed []CandidateChange
+}
+
+// CandidateChange is how a candidate changed between two sets of parameters. Paths are written as in the fingerprints,
+// following the Naming
+type CandidateChange struct {
+	Type reflect.Type
+	// AddedPaths and RemovedPaths are the paths the candidate gained and lost
+	AddedPaths   []string
+	RemovedPaths []string
+	// RetypedPaths are the paths whose expected value changed, as in "amount: Number -> String"
+	RetypedPaths []string
+	// OldFingerprint and NewFingerprint are the paths telling the candidate apart, if they or their types changed
+	OldFingerprint []string
+	NewFingerprint []string
+	// Ambiguous are the candidates it can no longer be told apart from, nil if it still can or already couldn't
+	Ambiguous []reflect.Type
+}
+
+// Breaking reports whether payloads resolving to a candidate of the old set may stop doing so with the new one: a
+// candidate was removed, or its fingerprint changed, or it can't be told apart anymore
+func (r *DiffReport) Breaking() bool {
+	if len(r.Removed) > 0 {
+		return true
+	}
+
+	for _, c := range r.Changed {
+		if len(c.Ambiguous) > 0 || len(c.OldFingerprint) > 0 || len(c.NewFingerprint) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Diff compares the fingerprints of the candidates of two sets of parameters, as given to New, so changes to them can
+// be checked before they are deployed. Both sets are built as with EnableLenient, so that candidates New would reject
+// for being ambiguous are reported instead. Selectors are not compared, and neither set can use UseResolver
+func Diff(oldParams, newParams []Parameter) (*DiffReport, error) {
+	before, err := diffResolver(oldParams)
+	if err != nil {
+		return nil, fmt.Errorf("old parameters: %w", err)
+	}
+
+	after, err := diffResolver(newParams)
+	if err != nil {
+		return nil, fmt.Errorf("new parameters: %w", err)
+	}
+
+	old := make(map[reflect.Type]fingerprint, len(before.fingerprints))
+	for _, fp := range before.fingerprints {
+		old[fp.candidate.typ] = fp
+	}
+
+	report := &DiffReport{}
+	seen := make(map[reflect.Type]bool, len(after.fingerprints))
+	for _, fp := range after.fingerprints {
+		typ := fp.candidate.typ
+		seen[typ] = true
+
+		previous, ok := old[typ]
+		if !ok {
+			report.Added = append(report.Added, typ)
+			continue
+		}
+
+		if change, changed := compareFingerprints(previous, fp); changed {
+			report.Changed = append(report.Changed, change)
+		}
+	}
+
+	for _, fp := range before.fingerprints {
+		if !seen[fp.candidate.typ] {
+			report.Removed = append(report.Removed, fp.candidate.typ)
+		}
+	}
+
+	return report, nil
+}
+
+func diffResolver(params []Parameter) (*traverseResolver, error) {
+	env, err := newEnv(append(slices.Clip(params), EnableLenient()))
+	if err != nil {
+		return nil, err
+	}
+
+	if env.resolverFactory != nil {
+		return nil, errors.New("only fingerprints can be compared, not resolvers given with UseResolver")
+	}
+
+	return newTraverseResolver(env)
+}
+
+func compareFingerprints(before, after fingerprint) (CandidateChange, bool) {
+	change := CandidateChange{Type: after.candidate.typ}
+	for _, path := range sortPaths(after.all) {
+		previous, ok := before.all[path]
+		switch {
+		case !ok:
+			change.AddedPaths = append(change.AddedPaths, path)
+		case previous.String() != after.all[path].String():
+			change.RetypedPaths = append(change.RetypedPaths,
+				fmt.Sprintf("%s: %s -> %s", path, previous, after.all[path]))
+		}
+	}
+
+	for _, path := range sortPaths(before.all) {
+		if _, ok := after.all[path]; !ok {
+			change.RemovedPaths = append(change.RemovedPaths, path)
+		}
+	}
+
+	// Fingerprints change with the types of their paths too, even if the paths are the same
+	oldPaths, newPaths := sortPaths(before.paths), sortPaths(after.paths)
+	if !maps.EqualFunc(before.paths, after.paths, func(a, b pathType) bool { return a.String() == b.String() }) {
+		change.OldFingerprint, change.NewFingerprint = oldPaths, newPaths
+	}
+
+	if len(before.ambiguous) == 0 && len(after.ambiguous) > 0 {
+		for _, rival := range after.ambiguous {
+			change.Ambiguous = append(change.Ambiguous, rival.typ)
+		}
+	}
+
+	changed := len(change.AddedPaths) > 0 || len(change.RemovedPaths) > 0 || len(change.RetypedPaths) > 0 ||
+		change.OldFingerprint != nil || len(change.Ambiguous) > 0
+	return change, changed
+}
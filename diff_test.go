@@ -0,0 +1,158 @@
+package turnip
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type diffOrder struct {
+	ID    string  `json:"id"`
+	Total float64 `json:"total"`
+}
+
+type diffRefund struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type diffRefundCopy struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type diffReturn struct {
+	ID     string  `json:"id"`
+	Total  float64 `json:"total"`
+	Reason string  `json:"reason"`
+}
+
+type diffEvent struct {
+	Kind string    `json:"kind"`
+	At   time.Time `json:"at"`
+}
+
+type diffKind struct {
+	Kind string `json:"kind"`
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name         string
+		oldParams    []Parameter
+		newParams    []Parameter
+		want         *DiffReport
+		wantBreaking bool
+	}{
+		{
+			name:      "unchanged",
+			oldParams: []Parameter{Candidate(diffOrder{}), Candidate(diffRefund{})},
+			newParams: []Parameter{Candidate(diffRefund{}), Candidate(diffOrder{})},
+			want:      &DiffReport{},
+		},
+		{
+			name:      "added",
+			oldParams: []Parameter{Candidate(diffOrder{}), Candidate(diffRefund{})},
+			newParams: []Parameter{Candidate(diffOrder{}), Candidate(diffRefund{}), Candidate(diffEvent{})},
+			want:      &DiffReport{Added: []reflect.Type{reflect.TypeOf(diffEvent{})}},
+		},
+		{
+			name:         "removed",
+			oldParams:    []Parameter{Candidate(diffOrder{}), Candidate(diffRefund{}), Candidate(diffEvent{})},
+			newParams:    []Parameter{Candidate(diffOrder{}), Candidate(diffRefund{})},
+			want:         &DiffReport{Removed: []reflect.Type{reflect.TypeOf(diffEvent{})}},
+			wantBreaking: true,
+		},
+		{
+			name:      "added and removed paths",
+			oldParams: []Parameter{Candidate(diffOrder{}), Candidate(diffRefund{})},
+			newParams: []Parameter{MapField(Candidate(diffOrder{}), "amount.total", "Total"), Candidate(diffRefund{})},
+			want: &DiffReport{Changed: []CandidateChange{{
+				Type:           reflect.TypeOf(diffOrder{}),
+				AddedPaths:     []string{"amount.total"},
+				RemovedPaths:   []string{"total"},
+				OldFingerprint: []string{"total"},
+				NewFingerprint: []string{"amount.total"},
+			}}},
+			wantBreaking: true,
+		},
+		{
+			name:      "retyped fingerprint",
+			oldParams: []Parameter{Candidate(diffEvent{}), Candidate(diffKind{})},
+			newParams: []Parameter{Candidate(diffEvent{}, TimeLayout(time.DateOnly)), Candidate(diffKind{})},
+			want: &DiffReport{Changed: []CandidateChange{{
+				Type:           reflect.TypeOf(diffEvent{}),
+				RetypedPaths:   []string{"at: String(rfc3339) -> String(layout=2006-01-02)"},
+				OldFingerprint: []string{"at"},
+				NewFingerprint: []string{"at"},
+			}}},
+			wantBreaking: true,
+		},
+		{
+			name:      "fingerprint",
+			oldParams: []Parameter{Candidate(diffReturn{}), Candidate(diffOrder{})},
+			newParams: []Parameter{Candidate(diffReturn{}), Candidate(diffOrder{}), Candidate(diffRefund{})},
+			want: &DiffReport{
+				Added: []reflect.Type{reflect.TypeOf(diffRefund{})},
+				Changed: []CandidateChange{{
+					Type:           reflect.TypeOf(diffReturn{}),
+					OldFingerprint: []string{"reason"},
+					NewFingerprint: []string{"reason", "total"},
+				}},
+			},
+			wantBreaking: true,
+		},
+		{
+			name:      "ambiguous",
+			oldParams: []Parameter{Candidate(diffOrder{}), Candidate(diffRefund{})},
+			newParams: []Parameter{Candidate(diffOrder{}), Candidate(diffRefund{}), Candidate(diffRefundCopy{})},
+			want: &DiffReport{
+				Added: []reflect.Type{reflect.TypeOf(diffRefundCopy{})},
+				Changed: []CandidateChange{{
+					Type:           reflect.TypeOf(diffRefund{}),
+					OldFingerprint: []string{"reason"},
+					NewFingerprint: []string{},
+					Ambiguous:      []reflect.Type{reflect.TypeOf(diffRefundCopy{})},
+				}},
+			},
+			wantBreaking: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(tt.oldParams, tt.newParams)
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+
+			if got.Breaking() != tt.wantBreaking {
+				t.Errorf("Breaking() = %v, want %v", got.Breaking(), tt.wantBreaking)
+			}
+		})
+	}
+}
+
+func TestDiffInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		oldParams []Parameter
+		newParams []Parameter
+	}{
+		{"old", []Parameter{Candidate(diffOrder{}), Naming(0)}, []Parameter{Candidate(diffOrder{})}},
+		{"new", []Parameter{Candidate(diffOrder{})}, []Parameter{Candidate(diffOrder{}), UseResolver("unknown")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Diff(tt.oldParams, tt.newParams)
+			if err == nil {
+				t.Error("Diff() error = nil, want an error")
+			}
+		})
+	}
+}
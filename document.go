@@ -0,0 +1,125 @@
+package turnip
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Document returns a Markdown document describing the payload shapes the Unmarshaler takes: for each candidate, in the
+// order they are checked, the paths of its fields with their types, and what tells it apart from the rest. It's
+// generated from the actual configuration, for teams to publish alongside their APIs. Selectors are only mentioned,
+// since their conditions are code, and candidates of resolvers given with UseResolver can't be described
+func (u *Unmarshaler) Document() ([]byte, error) {
+	s := u.load()
+	err := s.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := s.resolver.(*traverseResolver)
+	if !ok {
+		return nil, fmt.Errorf("documents need the paths of the candidates, not a %T", s.resolver)
+	}
+
+	selected := make(map[string]int)
+	for _, sel := range s.env.selectors {
+		selected[fullTypeName(sel.typ)]++
+	}
+
+	// The when options of tags are selectors too, but they are noted along with the fingerprint
+	for _, c := range s.env.candidates {
+		if c.routed {
+			selected[fullTypeName(c.typ)]--
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Payload shapes\n")
+	for _, fp := range r.fingerprints {
+		c := fp.candidate
+		fmt.Fprintf(&buf, "\n## %s\n\n", c)
+		if group := s.env.groups[c.typ]; group != "" {
+			fmt.Fprintf(&buf, "Group: %s\n\n", group)
+		}
+
+		if c.deprecated != "" {
+			fmt.Fprintf(&buf, "Deprecated: %s\n\n", c.deprecated)
+		}
+
+		buf.WriteString("| Path | Type |\n| --- | --- |\n")
+		for _, path := range sortPaths(fp.all) {
+			fmt.Fprintf(&buf, "| `%s` | %s |\n", markdownCell(path), markdownCell(fp.all[path].String()))
+		}
+
+		buf.WriteString("\n")
+		writeFingerprint(&buf, fp)
+
+		if selected[fullTypeName(c.typ)] > 0 {
+			buf.WriteString("\nAlso routed to by selectors, checked before any fingerprint.\n")
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeFingerprint describes what tells the candidate of fp apart from the rest
+func writeFingerprint(buf *bytes.Buffer, fp fingerprint) {
+	c := fp.candidate
+	switch {
+	case c.custom() && len(c.queries) == 0:
+		buf.WriteString("Matched by the functions given to Match alone.\n")
+	case c.custom():
+		syntax := "gjson"
+		if c.engine != "" {
+			syntax = c.engine
+		}
+
+		fmt.Fprintf(buf, "Matched when every one of these %s queries finds something:\n\n", syntax)
+		for _, q := range c.queries {
+			fmt.Fprintf(buf, "- `%s`\n", q)
+		}
+	case len(fp.ambiguous) > 0:
+		fmt.Fprintf(buf, "Never matched by its fields: it can't be told apart from %s.\n", typeNames(fp.ambiguous))
+	default:
+		quantifier := "all"
+		if fp.anyOf {
+			quantifier = "any"
+		}
+
+		fmt.Fprintf(buf, "Matched when %s of these are present with the right type:\n\n", quantifier)
+		for _, path := range sortPaths(fp.paths) {
+			fmt.Fprintf(buf, "- `%s` (%s)\n", path, fp.paths[path])
+		}
+	}
+
+	var notes []string
+	if fp.strict != nil {
+		notes = append(notes, "Strict: payloads with fields it doesn't have are not matched.")
+	}
+
+	if c.tuple {
+		notes = append(notes, "Sent as an array, with the paths being the positions of the elements.")
+	}
+
+	if c.version != nil {
+		notes = append(notes, fmt.Sprintf("Only for versions `%s` at `%s`.", c.version.raw, c.version.path))
+	}
+
+	if len(c.matchers) > 0 && (len(c.queries) > 0 || !c.custom()) {
+		notes = append(notes, fmt.Sprintf("Also checked by %d functions given to Match.", len(c.matchers)))
+	}
+
+	if c.routed {
+		notes = append(notes, "Also routed to by the when options of its tags.")
+	}
+
+	if len(notes) > 0 {
+		fmt.Fprintf(buf, "\n%s\n", strings.Join(notes, "\n"))
+	}
+}
+
+// markdownCell escapes the pipes of s, which would otherwise end the cell of a table
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,85 @@
+package turnip
+
+import (
+	"strings"
+	"testing"
+)
+
+type documentedOrder struct {
+	ID    string  `json:"id"`
+	Total float64 `json:"total"`
+}
+
+type documentedRefund struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type documentedQuote struct {
+	Symbol string
+	Bid    bool
+}
+
+func TestDocument(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []Parameter
+		want   string
+	}{
+		{
+			name:   "candidates",
+			params: []Parameter{Candidate(documentedOrder{}), Candidate(documentedRefund{})},
+			want: "# Payload shapes\n\n" +
+				"## turnip.documentedOrder\n\n" +
+				"| Path | Type |\n| --- | --- |\n| `id` | String |\n| `total` | Number |\n\n" +
+				"Matched when all of these are present with the right type:\n\n- `total` (Number)\n\n" +
+				"## turnip.documentedRefund\n\n" +
+				"| Path | Type |\n| --- | --- |\n| `id` | String |\n| `reason` | String |\n\n" +
+				"Matched when all of these are present with the right type:\n\n- `reason` (String)\n",
+		},
+		{
+			name: "selectors",
+			params: []Parameter{
+				Candidate(documentedOrder{}),
+				Candidate(documentedRefund{}),
+				SelectWhen(Eq("type", "refund"), documentedRefund{}),
+			},
+			want: "- `reason` (String)\n\nAlso routed to by selectors, checked before any fingerprint.\n",
+		},
+		{
+			name:   "strict",
+			params: []Parameter{Strict(documentedOrder{}), Candidate(documentedRefund{})},
+			want:   "- `total` (Number)\n\nStrict: payloads with fields it doesn't have are not matched.\n",
+		},
+		{
+			name:   "tuple",
+			params: []Parameter{Tuple(documentedQuote{}), Candidate(documentedOrder{})},
+			want: "| `0` | String |\n| `1` | Boolean |\n\n" +
+				"Matched when all of these are present with the right type:\n\n- `0` (String)\n\n" +
+				"Sent as an array, with the paths being the positions of the elements.\n",
+		},
+		{
+			name:   "group",
+			params: []Parameter{Group("billing", Candidate(documentedRefund{})), Candidate(documentedOrder{})},
+			want:   "## turnip.documentedRefund\n\nGroup: billing\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.Document()
+			if err != nil {
+				t.Fatalf("Document() error = %v", err)
+			}
+
+			if !strings.Contains(string(got), tt.want) {
+				t.Errorf("Document() = %s, want it to contain %s", got, tt.want)
+			}
+		})
+	}
+}
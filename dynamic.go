@@ -0,0 +1,193 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// FieldType is the type of a field of a dynamic candidate
+type FieldType interface {
+	reflectType() reflect.Type
+}
+
+type scalarFieldType struct {
+	typ reflect.Type
+}
+
+func (t scalarFieldType) reflectType() reflect.Type {
+	return t.typ
+}
+
+var (
+	Number FieldType = scalarFieldType{typ: reflect.TypeOf(float64(0))}
+	String FieldType = scalarFieldType{typ: reflect.TypeOf("")}
+	Bool   FieldType = scalarFieldType{typ: reflect.TypeOf(false)}
+	// Any matches any value, as long as it's present
+	Any FieldType = scalarFieldType{typ: rawMessageType}
+)
+
+// Object is a nested object with the given fields
+func Object(fields ...DynamicField) FieldType {
+	return objectFieldType(fields)
+}
+
+type objectFieldType []DynamicField
+
+func (t objectFieldType) reflectType() reflect.Type {
+	return structOf("", t)
+}
+
+// Array is an array of elements of the given type
+func Array(elem FieldType) FieldType {
+	return arrayFieldType{elem: elem}
+}
+
+type arrayFieldType struct {
+	elem FieldType
+}
+
+func (t arrayFieldType) reflectType() reflect.Type {
+	return reflect.SliceOf(t.elem.reflectType())
+}
+
+// DynamicField is a field of a dynamic candidate, with its name on the wire
+type DynamicField struct {
+	name string
+	typ  FieldType
+}
+
+// Field declares a field of a dynamic candidate. Its name can be any key but an empty one, "-" or one with a comma,
+// which New rejects
+func Field(name string, typ FieldType) DynamicField {
+	return DynamicField{
+		name: name,
+		typ:  typ,
+	}
+}
+
+// Dynamic declares a candidate from a description of its fields instead of a Go struct, for sets of candidates only
+// known at runtime. It's fingerprinted just like a struct with those fields would be, and payloads resolved to it are
+// decoded into a map[string]any:
+//
+//	turnip.Dynamic("invoice", turnip.Field("id", turnip.Number), turnip.Field("lines", turnip.Array(turnip.Any)))
+func Dynamic(name string, fields ...DynamicField) Parameter {
+	return DynamicFunc(name, nil, fields...)
+}
+
+// DynamicFunc is Dynamic, but payloads resolved to it are handed to decode as a map[string]any, and the value it
+// returns is used instead
+func DynamicFunc(name string, decode func(m map[string]any) (any, error), fields ...DynamicField) Parameter {
+	return &candidate{
+		typ: structOf(name, fields),
+		dynamic: &dynamicCandidate{
+			name:   name,
+			decode: decode,
+			fields: fields,
+		},
+	}
+}
+
+type dynamicCandidate struct {
+	name   string
+	decode func(m map[string]any) (any, error)
+	fields []DynamicField
+}
+
+// validate checks that every field, nested ones included, has a name encoding/json can take as it is. Names are
+// given as struct tags, where a comma starts the options and "-" skips the field
+func (d *dynamicCandidate) validate() error {
+	return validateFields(d.fields)
+}
+
+func validateFields(fields []DynamicField) error {
+	for _, f := range fields {
+		switch {
+		case f.name == "":
+			return errors.New("field name can't be empty")
+		case f.name == "-":
+			return errors.New("field name can't be '-'")
+		case strings.Contains(f.name, ","):
+			return fmt.Errorf("field name '%s' can't have a comma", f.name)
+		}
+
+		typ := f.typ
+		for {
+			array, ok := typ.(arrayFieldType)
+			if !ok {
+				break
+			}
+
+			typ = array.elem
+		}
+
+		if object, ok := typ.(objectFieldType); ok {
+			err := validateFields(object)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// structOf builds the struct a dynamic candidate stands for. Unnamed structs with the same fields are the same type,
+// so the name is added as an ignored field to keep the candidates apart
+func structOf(name string, fields []DynamicField) reflect.Type {
+	sfs := make([]reflect.StructField, 0, len(fields)+1)
+	if name != "" {
+		sfs = append(sfs, reflect.StructField{
+			Name: "Dynamic" + identifier(name),
+			Type: reflect.TypeOf(struct{}{}),
+			Tag:  `json:"-"`,
+		})
+	}
+
+	for i, f := range fields {
+		if f.typ == nil {
+			// Caught when validating, as a candidate without usable fields if it's the only one
+			continue
+		}
+
+		sfs = append(sfs, reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: f.typ.reflectType(),
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:%q`, f.name)),
+		})
+	}
+
+	return reflect.StructOf(sfs)
+}
+
+// identifier keeps only the letters and digits of s, so it can be part of a field name
+func identifier(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+
+		return '_'
+	}, s)
+}
+
+func (d *dynamicCandidate) unmarshal(b []byte, opts decodeOptions) (any, error) {
+	var m map[string]any
+	err := decodeWith(b, &m, decodeOptions{useNumber: opts.useNumber})
+	if err != nil {
+		return nil, decodeError(err, b)
+	}
+
+	if d.decode == nil {
+		return m, nil
+	}
+
+	v, err := d.decode(m)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrDecode, d.name, err)
+	}
+
+	return v, nil
+}
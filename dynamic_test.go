@@ -0,0 +1,76 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDynamic(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []DynamicField
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "fields",
+			fields:  []DynamicField{Field("id", Number), Field("tags", Array(String))},
+			payload: `{"id":1,"tags":["a"]}`,
+			want:    map[string]any{"id": float64(1), "tags": []any{"a"}},
+		},
+		{
+			name:    "names with quotes and spaces",
+			fields:  []DynamicField{Field(`"id"`, Number), Field("line items", Any)},
+			payload: `{"\"id\"":1,"line items":[]}`,
+			want:    map[string]any{`"id"`: float64(1), "line items": []any{}},
+		},
+		{
+			name:    "other fields",
+			fields:  []DynamicField{Field("id", Number)},
+			payload: `{"number":1}`,
+			wantErr: ErrNoMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(Dynamic("invoice", tt.fields...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDynamicInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []DynamicField
+	}{
+		{"empty name", []DynamicField{Field("", Number)}},
+		{"skipped name", []DynamicField{Field("-", Number)}},
+		{"name with a comma", []DynamicField{Field("id,omitempty", Number)}},
+		{"nested name", []DynamicField{Field("customer", Object(Field("-", String)))}},
+		{"name in an array", []DynamicField{Field("lines", Array(Array(Object(Field("a,b", Number)))))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Dynamic("invoice", tt.fields...))
+			if !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("New() error = %v, want %v", err, ErrInvalidParameter)
+			}
+		})
+	}
+}
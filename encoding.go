@@ -0,0 +1,48 @@
+package turnip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+)
+
+// toUTF8 strips the byte order mark of the payload, and transcodes it to UTF-8 if it's UTF-16. Payloads without a mark
+// are still recognized as UTF-16 by their first character, which in JSON is always ASCII and so has a zero byte next
+// to it
+func toUTF8(b []byte) []byte {
+	switch {
+	case bytes.HasPrefix(b, bomUTF8):
+		return b[len(bomUTF8):]
+	case bytes.HasPrefix(b, bomUTF16BE):
+		return fromUTF16(b[len(bomUTF16BE):], binary.BigEndian)
+	case bytes.HasPrefix(b, bomUTF16LE):
+		return fromUTF16(b[len(bomUTF16LE):], binary.LittleEndian)
+	case len(b) >= 2 && b[0] == 0 && b[1] != 0:
+		return fromUTF16(b, binary.BigEndian)
+	case len(b) >= 2 && b[0] != 0 && b[1] == 0:
+		return fromUTF16(b, binary.LittleEndian)
+	default:
+		return b
+	}
+}
+
+func fromUTF16(b []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = order.Uint16(b[2*i:])
+	}
+
+	out := make([]byte, 0, len(units))
+	for _, r := range utf16.Decode(units) {
+		out = utf8.AppendRune(out, r)
+	}
+
+	return out
+}
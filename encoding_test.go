@@ -0,0 +1,59 @@
+package turnip
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+func utf16Bytes(s string, order binary.AppendByteOrder) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = order.AppendUint16(b, u)
+	}
+
+	return b
+}
+
+type encodedUser struct {
+	Name string `json:"name"`
+}
+
+func TestToUTF8(t *testing.T) {
+	const payload = `{"name":"José 🌵"}`
+
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"utf-8", []byte(payload)},
+		{"utf-8 mark", append([]byte{0xEF, 0xBB, 0xBF}, payload...)},
+		{"utf-16be mark", append([]byte{0xFE, 0xFF}, utf16Bytes(payload, binary.BigEndian)...)},
+		{"utf-16le mark", append([]byte{0xFF, 0xFE}, utf16Bytes(payload, binary.LittleEndian)...)},
+		{"utf-16be", utf16Bytes(payload, binary.BigEndian)},
+		{"utf-16le", utf16Bytes(payload, binary.LittleEndian)},
+	}
+
+	u, err := New(Candidate(encodedUser{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(toUTF8(tt.in)); got != payload {
+				t.Errorf("toUTF8() = %q, want %q", got, payload)
+			}
+
+			got, err := u.UnmarshalJSON(tt.in)
+			if err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+
+			if want := (&encodedUser{Name: "José 🌵"}); !reflect.DeepEqual(got, want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
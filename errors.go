@@ -0,0 +1,150 @@
+package turnip
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Errors returned while unmarshaling fall into one of these categories, which can be told apart with errors.Is. They
+// tell queue consumers what to do with a payload: malformed ones and those matching nothing won't get any better by
+// retrying, while internal errors point to a problem with the Unmarshaler itself
+var (
+	// ErrMalformed is returned for payloads that are not a JSON object
+	ErrMalformed = errors.New("invalid json")
+	// ErrNoMatch is returned for payloads that match no selector, candidate nor default. The message names the
+	// candidate closest to the payload, if any, and what kept it from matching
+	ErrNoMatch = errors.New("no match")
+	// ErrAmbiguous is returned, along with ErrNoMatch, for payloads that only match candidates that can't be told
+	// apart, as tolerated by EnableLenient
+	ErrAmbiguous = errors.New("ambiguous")
+	// ErrDecode is returned when the payload resolved to a type, but can't be decoded into it
+	ErrDecode = errors.New("unmarshall")
+	// ErrValidation is returned, as a *ValidationError, when the payload decoded but failed the checks of UseValidator
+	ErrValidation = errors.New("validation failed")
+	// ErrInternal is returned when the Unmarshaler could not be built or failed while resolving
+	ErrInternal = errors.New("internal error")
+)
+
+// DecodeError is where in the payload encoding/json failed, returned along with ErrMalformed or ErrDecode for its
+// syntax and type errors. The error of encoding/json is kept, and can still be taken out with errors.As
+type DecodeError struct {
+	// Offset is the byte offset in the payload the error was found at, and Line and Column, both starting at 1, are
+	// the same position in lines and bytes. They are only set when they point into the payload as given, so not for
+	// payloads whose keys or values were rewritten for aliases, a Naming or coercions
+	Offset int64
+	Line   int
+	Column int
+	// Path is the path of the field being decoded, as encoding/json writes it, if known
+	Path string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	var where []string
+	if e.Line > 0 {
+		where = append(where, fmt.Sprintf("line %d, column %d (offset %d)", e.Line, e.Column, e.Offset))
+	}
+
+	if e.Path != "" {
+		where = append(where, "at "+e.Path)
+	}
+
+	if len(where) == 0 {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", strings.Join(where, ", "), e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeError puts an error from encoding/json in its category. Syntax errors are only found by the decoder, since
+// the payload is never fully validated before. b is the payload the offsets of the error point into, or nil if they
+// don't point into the payload as given
+func decodeError(err error, b []byte) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("%w: %w", ErrMalformed, locate(err, syntaxErr.Offset, "", b))
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("%w: %w", ErrDecode, locate(err, typeErr.Offset, typeErr.Field, b))
+	}
+
+	return fmt.Errorf("%w: %w", ErrDecode, err)
+}
+
+// locate wraps err in a DecodeError, with the line and column of offset if it's within b
+func locate(err error, offset int64, path string, b []byte) *DecodeError {
+	e := &DecodeError{
+		Path: path,
+		Err:  err,
+	}
+
+	if b == nil || offset < 0 || offset > int64(len(b)) {
+		return e
+	}
+
+	before := b[:offset]
+	e.Offset = offset
+	e.Line = bytes.Count(before, []byte{'\n'}) + 1
+	e.Column = len(before) - bytes.LastIndexByte(before, '\n')
+	return e
+}
+
+// Errors returned by New for configurations that can't work
+var (
+	ErrNoCandidates = errors.New("at least one candidate must be defined")
+	// ErrInvalidParameter is the category of ParameterErrors for parameters that are wrong by themselves
+	ErrInvalidParameter = errors.New("invalid parameter")
+	// ErrDuplicateParameter is the category of ParameterErrors for parameters that can only be given once
+	ErrDuplicateParameter = errors.New("duplicate parameter")
+)
+
+// ParameterError is returned by New for a parameter that can't be used. Err is either ErrInvalidParameter or
+// ErrDuplicateParameter, and can be checked with errors.Is
+type ParameterError struct {
+	// Parameter is the offending parameter, which is nil when a nil parameter was given
+	Parameter Parameter
+	Err       error
+	Reason    string
+}
+
+func (e *ParameterError) Error() string {
+	if e.Parameter == nil {
+		return e.Reason
+	}
+
+	return fmt.Sprintf("%s: %s", e.Parameter.Name(), e.Reason)
+}
+
+func (e *ParameterError) Unwrap() error {
+	return e.Err
+}
+
+func invalidParameter(p Parameter, format string, args ...any) error {
+	return &ParameterError{
+		Parameter: p,
+		Err:       ErrInvalidParameter,
+		Reason:    fmt.Sprintf(format, args...),
+	}
+}
+
+func duplicateParameter(p Parameter) error {
+	return &ParameterError{
+		Parameter: p,
+		Err:       ErrDuplicateParameter,
+		Reason:    "can only be used once",
+	}
+}
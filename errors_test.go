@@ -0,0 +1,148 @@
+package turnip
+
+import (
+	"errors"
+	"testing"
+)
+
+type erroredOrder struct {
+	ID    string  `json:"id"`
+	Total float64 `json:"total"`
+	Items []int   `json:"items"`
+}
+
+type erroredRefund struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type erroredCopy struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+func TestErrorCategories(t *testing.T) {
+	categories := []error{ErrMalformed, ErrNoMatch, ErrAmbiguous, ErrDecode, ErrValidation, ErrInternal}
+
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    []error
+	}{
+		{"not json", nil, `id`, []error{ErrMalformed}},
+		{"not an object", nil, `"id"`, []error{ErrMalformed}},
+		{"syntax error", nil, `{"id":"a","total":1,"items":[1,2}`, []error{ErrMalformed}},
+		{"no match", nil, `{"other":1}`, []error{ErrNoMatch}},
+		{"wrong type", nil, `{"id":"a","total":1,"items":[1,"x"]}`, []error{ErrDecode}},
+		{
+			name:    "ambiguous",
+			params:  []Parameter{EnableLenient(), Candidate(erroredCopy{})},
+			payload: `{"id":"a","reason":"x"}`,
+			want:    []error{ErrNoMatch, ErrAmbiguous},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(append(tt.params, Candidate(erroredOrder{}), Candidate(erroredRefund{}))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = u.UnmarshalJSON([]byte(tt.payload))
+			for _, category := range categories {
+				want := false
+				for _, w := range tt.want {
+					want = want || w == category
+				}
+
+				if errors.Is(err, category) != want {
+					t.Errorf("UnmarshalJSON() error = %v, is %v: %v, want %v", err, category, !want, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{
+			name:    "missing, mismatched and unexpected",
+			payload: `{"id":"a","total":"x","extra":1}`,
+			want: "no match: closest: turnip.erroredOrder " +
+				"(missing: items; mismatched: total not Number; unexpected: extra)",
+		},
+		{
+			name:    "too many to list",
+			payload: `{"id":"a","a":1,"b":1,"c":1,"d":1,"e":1,"f":1,"g":1}`,
+			want: "no match: closest: turnip.erroredOrder " +
+				"(missing: items, total; unexpected: a, b, c, d, e and 2 more)",
+		},
+		{"nothing in common", `{"other":1}`, "no match"},
+	}
+
+	u, err := New(Candidate(erroredOrder{}), Candidate(erroredRefund{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := u.UnmarshalJSON([]byte(tt.payload))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("UnmarshalJSON() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    DecodeError
+		wantErr error
+	}{
+		{
+			name:    "type error",
+			payload: `{"id":"a","total":1,"items":[1,"x"]}`,
+			want:    DecodeError{Offset: 34, Line: 1, Column: 35, Path: "items.1"},
+			wantErr: ErrDecode,
+		},
+		{
+			name:    "syntax error",
+			payload: "{\n  \"id\": \"a\",\n  \"total\": 1,\n  \"items\": [1,2}",
+			want:    DecodeError{Offset: 45, Line: 4, Column: 17},
+			wantErr: ErrMalformed,
+		},
+	}
+
+	u, err := New(Candidate(erroredOrder{}), Candidate(erroredRefund{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var got *DecodeError
+			if !errors.As(err, &got) {
+				t.Fatalf("UnmarshalJSON() error = %v, want a DecodeError", err)
+			}
+
+			if got.Offset != tt.want.Offset || got.Line != tt.want.Line || got.Column != tt.want.Column ||
+				got.Path != tt.want.Path {
+				t.Errorf("UnmarshalJSON() error = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
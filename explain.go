@@ -0,0 +1,217 @@
+package turnip
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// Explanation describes what drove the resolution of a payload, for audit systems to justify routing decisions
+type Explanation struct {
+	// Type is the type the payload was resolved to, nil if it went through the defaults
+	Type reflect.Type
+	// By is what resolved the payload: "selector", "fingerprint", "fuzzy", "default", or "resolver" for resolvers
+	// given with UseResolver, which can't be looked into
+	By string
+	// Paths are the paths of the payload that were looked at and found, with what was there. Conditions and queries
+	// whose paths can't be known, like Query and Expr, leave no paths
+	Paths []MatchedPath
+}
+
+// MatchedPath is a path of the payload that drove its resolution, with the value found at it
+type MatchedPath struct {
+	Path string
+	// Type is the JSON type of the value: String, Number, True, False, Null, Object or Array
+	Type string
+	// Value is the value as found, but with the values of redacted fields replaced by "[REDACTED]", as they are in
+	// errors
+	Value json.RawMessage
+}
+
+// UnmarshalJSONExplained is UnmarshalJSON also returning the Explanation of why the payload was resolved to its type.
+// It takes another pass over the selectors and fingerprints, so it's meant for the payloads that need auditing
+func (u *Unmarshaler) UnmarshalJSONExplained(b []byte) (any, *Explanation, error) {
+	return u.load().unmarshalExplained(b, &query{})
+}
+
+func (u *unmarshaler) unmarshalExplained(b []byte, q *query) (any, *Explanation, error) {
+	start := time.Now()
+	parsed, res, err := u.parse(b)
+	if err != nil {
+		u.record(u.loggerFor(q), start, b, len(b), nil, err)
+		return nil, nil, err
+	}
+
+	v, err := u.unmarshalParsed(parsed, res, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	typ, err := u.resolve(q)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: resolve: %w", ErrInternal, err)
+	}
+
+	return v, u.explain(q, typ), nil
+}
+
+// explain tells how the payload of q was resolved to typ
+func (u *unmarshaler) explain(q *query, typ reflect.Type) *Explanation {
+	e := &Explanation{Type: typ}
+	r, ok := u.resolver.(*traverseResolver)
+	switch {
+	case typ == nil:
+		e.By = "default"
+	case !ok:
+		e.By = "resolver"
+	default:
+		r.explain(r.scanned(q), e)
+	}
+
+	if typ != nil && u.redacts(typ) {
+		u.redactPaths(e.Paths, q.res, typ)
+	}
+
+	return e
+}
+
+// redactPaths replaces the values of the paths that are redacted in the payload res, resolved to typ
+func (u *unmarshaler) redactPaths(paths []MatchedPath, res gjson.Result, typ reflect.Type) {
+	secrets := make(map[string]bool)
+	for _, secret := range u.secrets(res, typ, jsonFormat) {
+		secrets[secret] = true
+	}
+
+	if len(secrets) == 0 {
+		return
+	}
+
+	for i, p := range paths {
+		paths[i].Value = redactValue(gjson.ParseBytes(p.Value), secrets)
+	}
+}
+
+// redactValue returns v with the scalars among secrets replaced, keeping everything else as it was written
+func redactValue(v gjson.Result, secrets map[string]bool) json.RawMessage {
+	switch {
+	case v.IsObject() || v.IsArray():
+		var buf bytes.Buffer
+		buf.WriteByte(v.Raw[0])
+		v.ForEach(func(key, value gjson.Result) bool {
+			if buf.Len() > 1 {
+				buf.WriteByte(',')
+			}
+
+			if v.IsObject() {
+				buf.WriteString(key.Raw)
+				buf.WriteByte(':')
+			}
+
+			buf.Write(redactValue(value, secrets))
+			return true
+		})
+
+		buf.WriteByte(v.Raw[len(v.Raw)-1])
+		return buf.Bytes()
+	case v.Type == gjson.String && secrets[v.Str], v.Type != gjson.String && v.Type != gjson.Null && secrets[v.Raw]:
+		return json.RawMessage(strconv.Quote(redactedValue))
+	default:
+		return json.RawMessage(v.Raw)
+	}
+}
+
+func (r *traverseResolver) explain(q *query, e *Explanation) {
+	for _, s := range r.env.selectors {
+		if s.cond.matches(q) {
+			// The first selector matching is the one that resolved it, whatever its type
+			e.By = "selector"
+			e.Paths = foundPaths(q.res, s.cond.paths(), gjson.Result.Get)
+			return
+		}
+	}
+
+	for _, fp := range r.fingerprints {
+		if fp.candidate.typ != e.Type {
+			continue
+		}
+
+		e.By = "fingerprint"
+		if !fp.matches(q.res) {
+			e.By = "fuzzy"
+		}
+
+		var paths []string
+		get := gjson.Result.Get
+		switch {
+		case fp.candidate.custom():
+			paths = fp.candidate.queries
+			if fp.candidate.engine != "" {
+				paths = nil
+			}
+		case e.By == "fuzzy":
+			paths = sortPaths(fp.all)
+			get = fp.all.get
+		default:
+			paths = sortPaths(fp.paths)
+			get = fp.paths.get
+		}
+
+		if fp.candidate.version != nil {
+			paths = append(slices.Clip(paths), fp.candidate.version.path)
+		}
+
+		e.Paths = foundPaths(q.res, paths, get)
+		return
+	}
+}
+
+// get looks up path in res as the path type says, or as gjson does for paths it doesn't have
+func (paths jsonPaths) get(res gjson.Result, path string) gjson.Result {
+	typ, ok := paths[path]
+	if !ok {
+		return res.Get(path)
+	}
+
+	return typ.get(res, path)
+}
+
+// foundPaths returns the paths present in res, with what's there
+func foundPaths(res gjson.Result, paths []string, get func(res gjson.Result, path string) gjson.Result) []MatchedPath {
+	var found []MatchedPath
+	for _, path := range paths {
+		if path == unknownPath || path == "" {
+			continue
+		}
+
+		v := get(res, path)
+		if !v.Exists() {
+			continue
+		}
+
+		found = append(found, MatchedPath{
+			Path:  path,
+			Type:  jsonTypeName(v),
+			Value: json.RawMessage(v.Raw),
+		})
+	}
+
+	return found
+}
+
+// jsonTypeName names the type of v as gjson does, telling objects and arrays apart
+func jsonTypeName(v gjson.Result) string {
+	switch {
+	case v.IsObject():
+		return "Object"
+	case v.IsArray():
+		return "Array"
+	default:
+		return v.Type.String()
+	}
+}
@@ -0,0 +1,125 @@
+package turnip
+
+import (
+	"strings"
+	"testing"
+)
+
+type explainLogin struct {
+	User     string `json:"user"`
+	Password string `json:"password" turnip:"redact"`
+}
+
+type explainSignup struct {
+	User  string `json:"user"`
+	Email string `json:"email"`
+}
+
+type explainSession struct {
+	Token   string `json:"token"`
+	Expires int    `json:"expires"`
+}
+
+type explainBearer struct {
+	Bearer struct {
+		Value string `json:"value"`
+		Kind  string `json:"kind"`
+	} `json:"bearer"`
+}
+
+func TestExplanationRedaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		by      string
+		path    string
+		want    string
+	}{
+		{
+			name:    "tagged field",
+			payload: `{"user":"ann","password":"hunter2"}`,
+			by:      "fingerprint",
+			path:    "password",
+			want:    `"[REDACTED]"`,
+		},
+		{
+			name:    "Redact hook",
+			params:  []Parameter{Redact(func(path string) bool { return path == "expires" })},
+			payload: `{"token":"s3cr3t","expires":60}`,
+			by:      "fingerprint",
+			path:    "expires",
+			want:    `"[REDACTED]"`,
+		},
+		{
+			name:    "unredacted field",
+			params:  []Parameter{Redact(func(path string) bool { return path == "token" })},
+			payload: `{"token":"s3cr3t","expires":60}`,
+			by:      "fingerprint",
+			path:    "expires",
+			want:    `60`,
+		},
+		{
+			name:    "selector",
+			params:  []Parameter{SelectWhen(Exists("password"), explainLogin{})},
+			payload: `{"user":"ann","password":"hunter2"}`,
+			by:      "selector",
+			path:    "password",
+			want:    `"[REDACTED]"`,
+		},
+		{
+			name: "nested values",
+			params: []Parameter{
+				SelectWhen(Exists("bearer"), explainBearer{}),
+				Redact(func(path string) bool { return path == "bearer.value" }),
+			},
+			payload: `{"bearer":{"value":"s3cr3t","kind":"bearer"}}`,
+			by:      "selector",
+			path:    "bearer",
+			want:    `{"value":"[REDACTED]","kind":"bearer"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := append([]Parameter{
+				Candidate(explainLogin{}),
+				Candidate(explainSignup{}),
+				Candidate(explainSession{}),
+				Candidate(explainBearer{}),
+			}, tt.params...)
+
+			u, err := New(params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, e, err := u.UnmarshalJSONExplained([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if e.By != tt.by {
+				t.Errorf("By = %q, want %q", e.By, tt.by)
+			}
+
+			var found bool
+			for _, p := range e.Paths {
+				if strings.Contains(string(p.Value), "hunter2") || strings.Contains(string(p.Value), "s3cr3t") {
+					t.Errorf("%s = %s, want it redacted", p.Path, p.Value)
+				}
+
+				if p.Path == tt.path {
+					found = true
+					if string(p.Value) != tt.want {
+						t.Errorf("%s = %s, want %s", p.Path, p.Value, tt.want)
+					}
+				}
+			}
+
+			if !found {
+				t.Errorf("Paths = %v, want %s among them", e.Paths, tt.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package turnip
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+)
+
+// SelectExpr routes payloads for which the CEL expression holds to the type of then, like SelectWhen does:
+//
+//	turnip.SelectExpr(`payload.type == "user" && payload.version >= 2`, User{})
+//
+// Expressions are in CEL, the Common Expression Language, with its standard functions and macros, over three variables:
+// payload, the payload as JSON, and hint and meta, what the ResolveContext says about it. As in CEL's own mapping of
+// JSON, the numbers of the payload are doubles, which compare to integers by value.
+//
+// Expressions are compiled when the Unmarshaler is created, and New fails if they can't be, or are known not to be
+// boolean. Errors while evaluating one, like looking up a key that's not there, make the selector not match, unless
+// absorbed by || or && when the other side decides
+func SelectExpr(expr string, then any) Parameter {
+	return SelectWhen(Expr(expr), then)
+}
+
+// Expr is the Condition of SelectExpr, for expressions to be combined with other conditions
+func Expr(expr string) Condition {
+	return &exprCondition{expr: expr}
+}
+
+type exprCondition struct {
+	expr string
+	// The same condition can be given to several Unmarshalers being created at once, so it's only compiled once
+	once    sync.Once
+	program cel.Program
+	err     error
+}
+
+// exprEnv declares the variables expressions can use
+var exprEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("payload", cel.DynType),
+		cel.Variable("hint", cel.StringType),
+		cel.Variable("meta", cel.MapType(cel.StringType, cel.DynType)),
+	)
+})
+
+func (c *exprCondition) compile() error {
+	c.once.Do(func() {
+		c.program, c.err = compileExpr(c.expr)
+		if c.err != nil {
+			c.err = fmt.Errorf("expression %s: %w", c.expr, c.err)
+		}
+	})
+
+	return c.err
+}
+
+func compileExpr(expr string) (cel.Program, error) {
+	env, err := exprEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+
+	if !ast.OutputType().IsAssignableType(cel.BoolType) {
+		return nil, fmt.Errorf("evaluates to %s, not bool", ast.OutputType())
+	}
+
+	return env.Program(ast)
+}
+
+func (c *exprCondition) matches(q *query) bool {
+	meta := q.ctx.Metadata
+	if meta == nil {
+		meta = map[string]any{}
+	}
+
+	out, _, err := c.program.Eval(map[string]any{
+		"payload": q.res.Value(),
+		"hint":    q.ctx.Hint,
+		"meta":    meta,
+	})
+
+	return err == nil && out.Value() == true
+}
+
+func (c *exprCondition) paths() []string {
+	return []string{unknownPath}
+}
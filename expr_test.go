@@ -0,0 +1,92 @@
+package turnip
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestExpr(t *testing.T) {
+	payload := `{"type":"user","version":2,"tags":["a","b"],"name":"ada lovelace","admin":false,"meta":{"id":null}}`
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`payload.type == "user"`, true},
+		{`payload.type != "user"`, false},
+		{`payload.version >= 2 && payload.version < 3`, true},
+		{`payload.version == 2.0`, true},
+		{`payload.version > 2 || payload.type == "user"`, true},
+		{`!payload.admin`, true},
+		{`payload.tags[1] == "b"`, true},
+		{`payload["type"] == "user"`, true},
+		{`"a" in payload.tags`, true},
+		{`payload.type in ["group", "team"]`, false},
+		{`size(payload.tags) == 2`, true},
+		{`size(payload.name) == 12`, true},
+		{`payload.name.startsWith("ada")`, true},
+		{`payload.name.endsWith("ada")`, false},
+		{`payload.name.contains("love")`, true},
+		{`has(payload.meta.id)`, true},
+		{`has(payload.meta.other)`, false},
+		{`payload.meta.id == null`, true},
+		{`hint == "users"`, true},
+		{`meta.source == "queue"`, true},
+		{`(payload.version == 2)`, true},
+		{`payload.missing == 1`, false},
+		{`payload.missing == 1 || payload.version == 2`, true},
+		{`payload.missing == 1 && payload.version == 3`, false},
+		{`payload.type`, false},
+		{`payload.version + 1.0 == 3.0`, true},
+		{`payload.version + 1 == 3`, false},
+		{`payload.tags.exists(t, t == "b")`, true},
+		{`payload.tags.all(t, t.size() == 1)`, true},
+		{`payload.name.matches("^ada")`, true},
+		{`type(payload.version) == double`, true},
+		{`int(payload.version) == 2`, true},
+	}
+
+	q := &query{
+		res: gjson.Parse(payload),
+		ctx: ResolveContext{Hint: "users", Metadata: map[string]any{"source": "queue"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c := Expr(tt.expr)
+			err := c.compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := c.matches(q); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprInvalid(t *testing.T) {
+	tests := []string{
+		``,
+		`payload.type ==`,
+		`payload.type == "user`,
+		`(payload.type == "user"`,
+		`unknown.type == "user"`,
+		`payload.name.reverse()`,
+		`payload.type = "user"`,
+		`payload.type + 1`,
+		`size(payload.tags)`,
+		`hint == 1`,
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			_, err := New(SelectExpr(expr, fpOrder{}), Candidate(fpShipment{}))
+			if !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("New() error = %v, want %v", err, ErrInvalidParameter)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package turnip
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// FormatUnmarshaler unmarshals payloads of one format with the candidates and settings of an Unmarshaler, for
+// gateways taking the same payloads in several protocols to configure them once. It's safe for concurrent use
+type FormatUnmarshaler struct {
+	u      *Unmarshaler
+	format formatName
+
+	// mu keeps the state from being built more than once for the same state of u
+	mu      sync.Mutex
+	current atomic.Pointer[formatState]
+}
+
+// formatState is the state of a FormatUnmarshaler, built from the parameters of a state of its Unmarshaler
+type formatState struct {
+	base  *unmarshaler
+	state *unmarshaler
+	err   error
+}
+
+// Format returns the FormatUnmarshaler for the format registered as name, as if the Unmarshaler had been given
+// UseFormat(name) instead of its own format. It follows reloads of the Unmarshaler, sharing its fingerprints as long
+// as the format names every field of the candidates as the Unmarshaler does, and finding its own otherwise. Unknown
+// formats, and formats other than JSON with no converter to JSON, fail every call with ErrInvalidParameter
+func (u *Unmarshaler) Format(name string) *FormatUnmarshaler {
+	f, _ := u.formats.LoadOrStore(name, &FormatUnmarshaler{u: u, format: formatName(name)})
+	return f.(*FormatUnmarshaler)
+}
+
+// JSON returns the FormatUnmarshaler for JSON payloads
+func (u *Unmarshaler) JSON() *FormatUnmarshaler {
+	return u.Format(jsonFormat.name)
+}
+
+// YAML returns the FormatUnmarshaler for YAML payloads. It fails every call until the format is registered again with
+// a converter to JSON by RegisterFormat, since turnip doesn't parse YAML itself
+func (u *Unmarshaler) YAML() *FormatUnmarshaler {
+	return u.Format(yamlFormat.name)
+}
+
+// Msgpack returns the FormatUnmarshaler for MessagePack payloads. As with YAML, it fails every call until the format
+// is registered again with a converter to JSON by RegisterFormat
+func (u *Unmarshaler) Msgpack() *FormatUnmarshaler {
+	return u.Format(msgpackFormat.name)
+}
+
+// Unmarshal is Unmarshaler.Unmarshal for payloads of the format. Decoded values get the same defaults, validation,
+// AfterDecode functions, migrations and redaction as those of the Unmarshaler, whatever decodes them
+func (f *FormatUnmarshaler) Unmarshal(b []byte) (any, error) {
+	s := f.load()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return s.state.unmarshalFormat(b)
+}
+
+// load returns the state following the current state of the Unmarshaler, building it if it's not there yet
+func (f *FormatUnmarshaler) load() *formatState {
+	base := f.u.load()
+	if s := f.current.Load(); s != nil && s.base == base {
+		return s
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if s := f.current.Load(); s != nil && s.base == base {
+		return s
+	}
+
+	s := &formatState{base: base}
+	s.state, s.err = base.withFormat(f.format)
+	f.current.Store(s)
+	return s
+}
+
+// withFormat returns the state for payloads of the format, with the parameters of u. It shares the resolver of u if
+// the format gives every candidate the same paths, which is the case unless the candidates have tags of the format
+func (u *unmarshaler) withFormat(name formatName) (*unmarshaler, error) {
+	f, err := lookupFormat(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidParameter, err)
+	}
+
+	if f.toJSON == nil && f != jsonFormat {
+		return nil, fmt.Errorf("%w: format '%s' has no converter to JSON, register one with RegisterFormat",
+			ErrInvalidParameter, f.name)
+	}
+
+	if u.env.format.name == f.name {
+		return u, nil
+	}
+
+	params := make([]Parameter, 0, len(u.params)+2)
+	for _, p := range u.params {
+		if _, ok := p.(formatName); !ok {
+			params = append(params, p)
+		}
+	}
+
+	state, err := newState(append(params, name))
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := u.resolver.(*traverseResolver)
+	if !ok {
+		return state, state.ready()
+	}
+
+	built, same, err := r.pathsFor(state.env)
+	if err != nil {
+		return nil, err
+	}
+
+	if same {
+		state.shared = r
+	} else {
+		// The paths are built already, only the fingerprints are left to find
+		state.env.builtPaths = built
+	}
+
+	return state, state.ready()
+}
+
+// pathsFor builds the paths of the candidates of r for env, which only differs in its format, and reports whether
+// they are the same paths r has
+func (r *traverseResolver) pathsFor(env environment) (builtPaths, bool, error) {
+	b := newPathBuilder(env, newInterner(), r.logger)
+	built := make(builtPaths, len(env.candidates))
+	same := true
+	for _, c := range env.candidates {
+		b.timeLayout = c.decode.timeLayout
+		paths, err := b.build(c.typ)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: %s: %w", ErrInvalidCandidate, c.typ, err)
+		}
+
+		built[pathsKey{typ: c.typ, config: pathsConfig(env, c)}] = paths
+		same = same && maps.EqualFunc(paths, r.built[pathsKey{typ: c.typ, config: pathsConfig(r.env, c)}], samePath)
+	}
+
+	return built, same, nil
+}
+
+// samePath reports whether two path types, of builders with the same settings, check values the same way
+func samePath(a, b pathType) bool {
+	if (a.aliases == nil) != (b.aliases == nil) || a.aliases != nil && a.aliases.key != b.aliases.key {
+		return false
+	}
+
+	return a.String() == b.String()
+}
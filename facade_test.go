@@ -0,0 +1,167 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type facadeCharge struct {
+	ID       string `json:"id"`
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency" default:"USD"`
+}
+
+type facadeRefund struct {
+	RefundOf string `json:"refund_of"`
+}
+
+func TestFormatUnmarshaler(t *testing.T) {
+	u, err := New(
+		Candidate(facadeCharge{}),
+		AfterDecode(facadeCharge{}, func(v any) (any, error) {
+			v.(*facadeCharge).ID = strings.TrimSpace(v.(*facadeCharge).ID)
+			return v, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		f       *FormatUnmarshaler
+		payload string
+		want    facadeCharge
+		wantErr error
+	}{
+		{"json", u.JSON(), `{"id":" a ","amount":1}`, facadeCharge{ID: "a", Amount: 1, Currency: "USD"}, nil},
+		{"plugin", u.Format("plugintest"), `{"id":" a ","amount":1}`, facadeCharge{ID: "a", Amount: 1, Currency: "USD"}, nil},
+		{"plugin failing", u.Format("plugintest"), `{"id":"a","amount":1,"fail":true}`, facadeCharge{}, ErrDecode},
+		{"no match", u.Format("plugintest"), `{"other":1}`, facadeCharge{}, ErrNoMatch},
+		{"unknown format", u.Format("unknown"), `{"id":"a","amount":1}`, facadeCharge{}, ErrInvalidParameter},
+		{"yaml without converter", u.YAML(), "id: a\namount: 1", facadeCharge{}, ErrInvalidParameter},
+		{"msgpack without converter", u.Msgpack(), `{"id":"a","amount":1}`, facadeCharge{}, ErrInvalidParameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := tt.f.Unmarshal([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unmarshal() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got, ok := v.(*facadeCharge)
+			if !ok || *got != tt.want {
+				t.Errorf("Unmarshal() = %#v, want %#v", v, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatUnmarshalerFollowsReloads(t *testing.T) {
+	u, err := New(Candidate(facadeCharge{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := u.Format("plugintest")
+	payload := []byte(`{"refund_of":"a"}`)
+	_, err = f.Unmarshal(payload)
+	if !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("Unmarshal() error = %v, want %v", err, ErrNoMatch)
+	}
+
+	err = u.Add(Candidate(facadeRefund{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := f.Unmarshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := v.(*facadeRefund); !ok {
+		t.Errorf("Unmarshal() = %T, want *facadeRefund", v)
+	}
+}
+
+type facadeTagged struct {
+	ID     string `json:"id" facadetest:"charge_id"`
+	Amount int    `json:"amount"`
+}
+
+func init() {
+	RegisterFormat(FormatSpec{
+		Name:            "facadetest",
+		TagKeys:         []string{"facadetest", "json"},
+		PromoteEmbedded: true,
+		ToJSON: func(b []byte) ([]byte, error) {
+			return b, nil
+		},
+	})
+}
+
+func TestFormatUnmarshalerSharesFingerprints(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidate  any
+		format     string
+		payload    string
+		want       reflect.Type
+		wantShared bool
+	}{
+		{
+			name:       "same paths",
+			candidate:  facadeCharge{},
+			format:     "plugintest",
+			payload:    `{"id":"a","amount":1}`,
+			want:       reflect.TypeOf(&facadeCharge{}),
+			wantShared: true,
+		},
+		{
+			name:       "same tags",
+			candidate:  facadeCharge{},
+			format:     "facadetest",
+			payload:    `{"id":"a","amount":1}`,
+			want:       reflect.TypeOf(&facadeCharge{}),
+			wantShared: true,
+		},
+		{
+			name:      "tags of the format",
+			candidate: facadeTagged{},
+			format:    "facadetest",
+			payload:   `{"charge_id":"a","amount":1}`,
+			want:      reflect.TypeOf(&facadeTagged{}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(Candidate(tt.candidate), Candidate(facadeRefund{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f := u.Format(tt.format)
+			got, err := f.Unmarshal([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			if reflect.TypeOf(got) != tt.want {
+				t.Errorf("Unmarshal() = %T, want %v", got, tt.want)
+			}
+
+			if shared := f.load().state.resolver == u.load().resolver; shared != tt.wantShared {
+				t.Errorf("Unmarshal() shared fingerprints = %v, want %v", shared, tt.wantShared)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package turnip
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// wireField is a struct field as seen by a decoder, after applying tags and embedded struct promotion
+type wireField struct {
+	name   string
+	tagged bool
+	// quoted is set by the ",string" json tag option, which makes scalars travel as JSON strings
+	quoted bool
+	index  []int
+	typ    reflect.Type
+	// options are the ones given in the turnip tag
+	options map[string]string
+}
+
+// wireFields returns the fields the decoder of the format would decode for the struct t. It follows the rules of
+// encoding/json: fields of embedded structs are promoted unless the embedded field is tagged with a name, and when
+// several fields end up with the same name the shallowest one wins, with tagged fields breaking ties. If a tie can't
+// be broken, all of them are dropped
+func wireFields(t reflect.Type, format *format) []wireField {
+	var current []wireField
+	next := []wireField{{typ: t}}
+
+	// Count of embedded types queued at the current and next level
+	var count, nextCount map[reflect.Type]int
+
+	visited := make(map[reflect.Type]bool)
+
+	var fields []wireField
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, make(map[reflect.Type]int)
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+
+			visited[f.typ] = true
+
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+
+					// Embedded unexported structs still have their exported fields promoted
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag, key := format.tag(sf)
+				if tag == jsonIgnoreTag {
+					continue
+				}
+
+				name, opts, _ := strings.Cut(tag, ",")
+				if !isValidTagName(name) {
+					name = ""
+				}
+
+				index := make([]int, len(f.index)+1)
+				copy(index, f.index)
+				index[len(f.index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+
+				if name != "" || ft.Kind() != reflect.Struct || !format.promotes(sf, opts) {
+					field := wireField{
+						name:    name,
+						tagged:  name != "",
+						quoted:  key == "json" && hasTagOption(opts, "string") && isQuotable(ft),
+						index:   index,
+						typ:     sf.Type,
+						options: parseTurnipTag(sf.Tag.Get(turnipTag)),
+					}
+
+					if field.name == "" {
+						field.name = sf.Name
+					}
+
+					fields = append(fields, field)
+					if count[f.typ] > 1 {
+						// The same embedded type was found more than once at this level, so the field is added twice
+						// for the name conflict to annihilate it
+						fields = append(fields, field)
+					}
+
+					continue
+				}
+
+				// An untagged embedded or inlined struct, its fields get promoted on the next level
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, wireField{name: ft.Name(), index: index, typ: ft})
+				}
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		x := fields
+		if x[i].name != x[j].name {
+			return x[i].name < x[j].name
+		}
+
+		if len(x[i].index) != len(x[j].index) {
+			return len(x[i].index) < len(x[j].index)
+		}
+
+		if x[i].tagged != x[j].tagged {
+			return x[i].tagged
+		}
+
+		return indexLess(x[i].index, x[j].index)
+	})
+
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		name := fields[i].name
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != name {
+				break
+			}
+		}
+
+		if advance == 1 {
+			out = append(out, fields[i])
+			continue
+		}
+
+		if dominant, ok := dominantField(fields[i : i+advance]); ok {
+			out = append(out, dominant)
+		}
+	}
+
+	fields = out
+	sort.Slice(fields, func(i, j int) bool {
+		return indexLess(fields[i].index, fields[j].index)
+	})
+
+	return fields
+}
+
+// dominantField picks the field that wins a name conflict. The fields must be sorted by depth and then by tagging,
+// so it's enough to check the first two
+func dominantField(fields []wireField) (wireField, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) && fields[0].tagged == fields[1].tagged {
+		return wireField{}, false
+	}
+
+	return fields[0], true
+}
+
+func indexLess(a, b []int) bool {
+	for k, x := range a {
+		if k >= len(b) {
+			return false
+		}
+
+		if x != b[k] {
+			return x < b[k]
+		}
+	}
+
+	return len(a) < len(b)
+}
+
+// parseTurnipTag reads the options of the turnip tag. They are comma separated, and either flags or key=value pairs,
+// like `turnip:"format=rfc3339,redact"`
+func parseTurnipTag(tag string) map[string]string {
+	if tag == "" {
+		return nil
+	}
+
+	options := make(map[string]string)
+	for _, opt := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		if key != "" {
+			options[key] = value
+		}
+	}
+
+	return options
+}
+
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasTurnipOption reports whether t has fields with the option in their turnip tag anywhere, nested or in elements
+func hasTurnipOption(t reflect.Type, option string, visiting map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || visiting[t] {
+		return false
+	}
+
+	if _, ok := getLeafType(t); ok {
+		return false
+	}
+
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for _, f := range wireFields(t, jsonFormat) {
+		if _, ok := f.options[option]; ok || hasTurnipOption(f.typ, option, visiting) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isQuotable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.String:
+		return true
+	default:
+		return false
+	}
+}
+
+// isValidTagName mirrors the check done by encoding/json. Names it would reject fall back to the field name
+func isValidTagName(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but otherwise any punctuation chars are allowed in a tag name
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+
+	return true
+}
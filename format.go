@@ -0,0 +1,70 @@
+package turnip
+
+import "reflect"
+
+// format describes how a serialization format maps struct fields to keys. Structs are usually shared across formats,
+// so each one falls back to the json tag when its own tag is missing
+type format struct {
+	name string
+	// tagKeys are looked up in order, the first one present on a field is used
+	tagKeys []string
+	// promoteEmbedded is set when untagged embedded structs have their fields promoted, as encoding/json does
+	promoteEmbedded bool
+	// inline is set when the ",inline" tag option promotes the fields of a struct, embedded or not
+	inline bool
+	// toJSON converts payloads of the format to JSON for resolving them. Without it, payloads must already be JSON
+	toJSON func(b []byte) ([]byte, error)
+	// unmarshal decodes payloads of the format once resolved. Without it, the JSON is decoded by encoding/json
+	unmarshal func(b []byte, v any) error
+}
+
+var (
+	jsonFormat = &format{
+		name:            "json",
+		tagKeys:         []string{"json"},
+		promoteEmbedded: true,
+	}
+	yamlFormat = &format{
+		name:    "yaml",
+		tagKeys: []string{"yaml", "json"},
+		inline:  true,
+	}
+	tomlFormat = &format{
+		name:            "toml",
+		tagKeys:         []string{"toml", "json"},
+		promoteEmbedded: true,
+	}
+	msgpackFormat = &format{
+		name:            "msgpack",
+		tagKeys:         []string{"msgpack", "json"},
+		promoteEmbedded: true,
+		inline:          true,
+	}
+)
+
+// withTagKey returns a copy of the format that looks up key before any of its own tag keys
+func (f *format) withTagKey(key string) *format {
+	withKey := *f
+	withKey.tagKeys = append([]string{key}, f.tagKeys...)
+	return &withKey
+}
+
+// tag returns the tag of the field for this format, and the key it was found under
+func (f *format) tag(sf reflect.StructField) (string, string) {
+	for _, key := range f.tagKeys {
+		if tag, ok := sf.Tag.Lookup(key); ok {
+			return tag, key
+		}
+	}
+
+	return "", ""
+}
+
+// promotes reports whether the fields of an untagged struct field are promoted to the parent
+func (f *format) promotes(sf reflect.StructField, opts string) bool {
+	if f.inline && hasTagOption(opts, "inline") {
+		return true
+	}
+
+	return sf.Anonymous && f.promoteEmbedded
+}
@@ -0,0 +1,117 @@
+package turnip
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Group bundles related candidates and selectors under a name, so they can be declared together and reused as one
+// parameter:
+//
+//	var Payments = turnip.Group("payments",
+//		turnip.Candidate(Charge{}),
+//		turnip.Candidate(Refund{}),
+//		turnip.StrictMatching(),
+//	)
+//
+// Groups can hold other groups, which are then named after both, like "payments/refunds". The group of the type a
+// payload is unmarshaled into is given to the functions of Observe, to scope metrics, and GroupTypes looks the types
+// of a group up by its name. StrictMatching only makes the candidates of the group strict, those of the groups nested
+// in it included. Other settings can't be grouped, since they apply to every candidate alike
+func Group(name string, params ...Parameter) Parameter {
+	return &group{
+		name:   name,
+		params: params,
+	}
+}
+
+type group struct {
+	name   string
+	params []Parameter
+}
+
+func (g *group) Name() string {
+	return "Group"
+}
+
+// addGroup adds the parameters of the group g, named after parent if it's nested in another group
+func (env *environment) addGroup(g *group, parent string) error {
+	if g.name == "" {
+		return invalidParameter(g, "name can't be empty")
+	}
+
+	name := g.name
+	if parent != "" {
+		name = parent + "/" + g.name
+	}
+
+	start := len(env.candidates)
+	strict := false
+	for _, p := range g.params {
+		var typ reflect.Type
+		switch p := p.(type) {
+		case setting:
+			if p != strictMatching {
+				return invalidParameter(g, "%s: only StrictMatching can be grouped, not %s", name, p)
+			}
+
+			strict = true
+			continue
+		case *candidate:
+			typ = p.typ
+		case *selector:
+			typ = p.typ
+		case *group:
+			err := env.addGroup(p, name)
+			if err != nil {
+				return err
+			}
+
+			continue
+		case nil:
+			return invalidParameter(g, "%s: nil parameter", name)
+		default:
+			return invalidParameter(g, "%s: only candidates, selectors, groups and StrictMatching can be grouped, not %s", name,
+				p.Name())
+		}
+
+		err := env.add(p)
+		if err != nil {
+			return err
+		}
+
+		// Selectors can route to candidates of another group, which keep theirs
+		if _, ok := env.groups[typ]; !ok && typ != nil {
+			env.groups[typ] = name
+		}
+	}
+
+	if strict {
+		for _, c := range env.candidates[start:] {
+			err := c.makeStrict()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GroupTypes returns the types of the candidates and selectors of the group with the name, including those of the
+// groups nested in it, sorted by name. It's nil if there's no such group
+func (u *Unmarshaler) GroupTypes(name string) []reflect.Type {
+	var types []reflect.Type
+	for typ, group := range u.load().env.groups {
+		if group == name || strings.HasPrefix(group, name+"/") {
+			types = append(types, typ)
+		}
+	}
+
+	slices.SortFunc(types, func(a, b reflect.Type) int {
+		return strings.Compare(fullTypeName(a), fullTypeName(b))
+	})
+
+	return types
+}
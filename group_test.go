@@ -0,0 +1,91 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type groupCharge struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+type groupRefund struct {
+	RefundOf string `json:"refund_of"`
+}
+
+type groupPing struct {
+	Ping bool `json:"ping"`
+}
+
+func TestGroupStrictMatching(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+		newErr  error
+	}{
+		{
+			name:    "grouped candidate",
+			params:  []Parameter{Group("payments", Candidate(groupCharge{}), StrictMatching())},
+			payload: `{"id":"a","amount":1,"note":"x"}`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name:    "grouped candidate exact shape",
+			params:  []Parameter{Group("payments", Candidate(groupCharge{}), StrictMatching())},
+			payload: `{"id":"a","amount":1}`,
+			want:    &groupCharge{ID: "a", Amount: 1},
+		},
+		{
+			name:    "nested group",
+			params:  []Parameter{Group("payments", Group("refunds", Candidate(groupRefund{})), StrictMatching())},
+			payload: `{"refund_of":"a","note":"x"}`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name: "candidate out of the group",
+			params: []Parameter{
+				Group("payments", Candidate(groupCharge{}), StrictMatching()),
+				Candidate(groupPing{}),
+			},
+			payload: `{"ping":true,"note":"x"}`,
+			want:    &groupPing{Ping: true},
+		},
+		{
+			name:   "other setting",
+			params: []Parameter{Group("payments", Candidate(groupCharge{}), EnableJSONC())},
+			newErr: ErrInvalidParameter,
+		},
+		{
+			name:   "tuple",
+			params: []Parameter{Group("payments", Tuple(groupCharge{}), StrictMatching())},
+			newErr: ErrInvalidParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if !errors.Is(err, tt.newErr) {
+				t.Fatalf("New() error = %v, want %v", err, tt.newErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package turnip
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/tidwall/gjson"
+)
+
+// BeforeDecode rewrites the payloads resolved to the type of v before they are decoded, for compatibility shims that
+// rename keys, unwrap envelopes or fill in values without changing the type. Resolution still looks at the payload as
+// given. Rewrites for the same type are applied in the order given, and must return a JSON object.
+//
+// The rewrite applies whether the type was resolved by a candidate, a selector or a default, and payloads resolved to
+// it are no longer decoded as they are read by UnmarshalReader
+func BeforeDecode(v any, rewrite func(b []byte) ([]byte, error)) Parameter {
+	return &rewriteHook{
+		typ:     reflect.TypeOf(v),
+		rewrite: rewrite,
+	}
+}
+
+type rewriteHook struct {
+	typ     reflect.Type
+	rewrite func(b []byte) ([]byte, error)
+}
+
+func (h *rewriteHook) Name() string {
+	return "BeforeDecode"
+}
+
+// rewriteHooks are the rewrites given, by the type they apply to
+type rewriteHooks map[reflect.Type][]*rewriteHook
+
+// apply rewrites the payload b, about to be decoded into typ, reporting whether there was any rewrite for typ
+func (h rewriteHooks) apply(b []byte, typ reflect.Type) ([]byte, bool, error) {
+	hooks := h[typ]
+	if len(hooks) == 0 {
+		return b, false, nil
+	}
+
+	for _, hook := range hooks {
+		var err error
+		b, err = hook.rewrite(b)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: rewrite before decoding: %w", ErrDecode, err)
+		}
+
+		if !gjson.ValidBytes(b) || !gjson.ParseBytes(b).IsObject() {
+			return nil, false, fmt.Errorf("%w: rewrite before decoding: not an object", ErrDecode)
+		}
+	}
+
+	return b, true, nil
+}
+
+// AfterDecode calls normalize with every value decoded into the type of v, a pointer to it, and returns what it
+// returns instead, so values can be canonicalized in a single place: strings trimmed, times moved to UTC, derived
+// fields computed. Functions for the same type are called in the order given, each with the value of the previous one.
+// They run after the value is validated, and before it's migrated.
+//
+// Like BeforeDecode, it applies whether the type was resolved by a candidate, a selector or a default. Errors and nil
+// values fail the decoding
+func AfterDecode(v any, normalize func(v any) (any, error)) Parameter {
+	return &normalizeHook{
+		typ:       reflect.TypeOf(v),
+		normalize: normalize,
+	}
+}
+
+type normalizeHook struct {
+	typ       reflect.Type
+	normalize func(v any) (any, error)
+}
+
+func (h *normalizeHook) Name() string {
+	return "AfterDecode"
+}
+
+// normalizeHooks are the normalizations given, by the type they apply to
+type normalizeHooks map[reflect.Type][]*normalizeHook
+
+// apply normalizes the value v, just decoded into typ
+func (h normalizeHooks) apply(v any, typ reflect.Type) (any, error) {
+	for _, hook := range h[typ] {
+		var err error
+		v, err = hook.normalize(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: normalize after decoding: %w", ErrDecode, err)
+		}
+
+		if v == nil {
+			return nil, fmt.Errorf("%w: normalize after decoding: nil value", ErrDecode)
+		}
+	}
+
+	return v, nil
+}
@@ -0,0 +1,192 @@
+package turnip
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type hookAccount struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+type hookUnknown struct {
+	Raw string `json:"raw"`
+}
+
+// renameKey returns a rewrite renaming the key from to to
+func renameKey(from, to string) func(b []byte) ([]byte, error) {
+	return func(b []byte) ([]byte, error) {
+		return bytes.Replace(b, []byte(`"`+from+`"`), []byte(`"`+to+`"`), 1), nil
+	}
+}
+
+func TestBeforeDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "renamed key",
+			params:  []Parameter{BeforeDecode(hookAccount{}, renameKey("mail", "email"))},
+			payload: `{"id":"a","mail":"a@example.com"}`,
+			want:    &hookAccount{ID: "a", Email: "a@example.com"},
+		},
+		{
+			name: "in order",
+			params: []Parameter{
+				BeforeDecode(hookAccount{}, renameKey("mail", "e_mail")),
+				BeforeDecode(hookAccount{}, renameKey("e_mail", "email")),
+			},
+			payload: `{"id":"a","mail":"a@example.com"}`,
+			want:    &hookAccount{ID: "a", Email: "a@example.com"},
+		},
+		{
+			name:    "other type",
+			params:  []Parameter{BeforeDecode(hookUnknown{}, renameKey("mail", "email"))},
+			payload: `{"id":"a","mail":"a@example.com"}`,
+			want:    &hookAccount{ID: "a"},
+		},
+		{
+			name:    "default",
+			params:  []Parameter{BeforeDecode(hookUnknown{}, renameKey("body", "raw"))},
+			payload: `{"body":"x"}`,
+			want:    &hookUnknown{Raw: "x"},
+		},
+		{
+			name: "failing",
+			params: []Parameter{BeforeDecode(hookAccount{}, func([]byte) ([]byte, error) {
+				return nil, errors.New("unreadable")
+			})},
+			payload: `{"id":"a"}`,
+			wantErr: ErrDecode,
+		},
+		{
+			name: "not an object",
+			params: []Parameter{BeforeDecode(hookAccount{}, func([]byte) ([]byte, error) {
+				return []byte(`[1]`), nil
+			})},
+			payload: `{"id":"a"}`,
+			wantErr: ErrDecode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(append(tt.params, Candidate(hookAccount{}), Default(hookUnknown{}))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			unmarshalers := map[string]func(b []byte) (any, error){
+				"UnmarshalJSON":   u.UnmarshalJSON,
+				"UnmarshalReader": func(b []byte) (any, error) { return u.UnmarshalReader(bytes.NewReader(b), 8) },
+			}
+
+			for name, unmarshal := range unmarshalers {
+				got, err := unmarshal([]byte(tt.payload))
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("%s() error = %v, want %v", name, err, tt.wantErr)
+				}
+
+				if err == nil && !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("%s() = %#v, want %#v", name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// lowerEmail normalizes the email of an account
+func lowerEmail(v any) (any, error) {
+	a := v.(*hookAccount)
+	a.Email = strings.ToLower(a.Email)
+	return a, nil
+}
+
+func TestAfterDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "normalized",
+			params:  []Parameter{AfterDecode(hookAccount{}, lowerEmail)},
+			payload: `{"id":"a","email":"A@Example.com"}`,
+			want:    &hookAccount{ID: "a", Email: "a@example.com"},
+		},
+		{
+			name: "in order",
+			params: []Parameter{
+				AfterDecode(hookAccount{}, lowerEmail),
+				AfterDecode(hookAccount{}, func(v any) (any, error) {
+					return &hookAccount{ID: "id-" + v.(*hookAccount).ID, Email: v.(*hookAccount).Email}, nil
+				}),
+			},
+			payload: `{"id":"a","email":"A@Example.com"}`,
+			want:    &hookAccount{ID: "id-a", Email: "a@example.com"},
+		},
+		{
+			name: "default",
+			params: []Parameter{AfterDecode(hookUnknown{}, func(v any) (any, error) {
+				return &hookUnknown{Raw: strings.TrimSpace(v.(*hookUnknown).Raw)}, nil
+			})},
+			payload: `{"raw":" x "}`,
+			want:    &hookUnknown{Raw: "x"},
+		},
+		{
+			name: "failing",
+			params: []Parameter{AfterDecode(hookAccount{}, func(any) (any, error) {
+				return nil, errors.New("banned")
+			})},
+			payload: `{"id":"a"}`,
+			wantErr: ErrDecode,
+		},
+		{
+			name:    "nil value",
+			params:  []Parameter{AfterDecode(hookAccount{}, func(any) (any, error) { return nil, nil })},
+			payload: `{"id":"a"}`,
+			wantErr: ErrDecode,
+		},
+		{
+			name: "migrated after",
+			params: []Parameter{
+				AfterDecode(hookUnknown{}, func(v any) (any, error) {
+					return &hookUnknown{Raw: strings.ToUpper(v.(*hookUnknown).Raw)}, nil
+				}),
+				Migrate(hookUnknown{}, hookAccount{}, func(u *hookUnknown) *hookAccount {
+					return &hookAccount{ID: u.Raw}
+				}),
+			},
+			payload: `{"raw":"x"}`,
+			want:    &hookAccount{ID: "X"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(append(tt.params, Candidate(hookAccount{}), Default(hookUnknown{}))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
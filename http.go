@@ -0,0 +1,79 @@
+package turnip
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Metadata keys set by DecodeResponse
+const (
+	MetaStatusCode  = "status"
+	MetaContentType = "content-type"
+)
+
+// DecodeResponse reads, closes and unmarshals the body of an API response. The status code and content type are given
+// as metadata, so the bodies of errors can be told apart from the rest with selectors:
+//
+//	turnip.SelectWhen(turnip.Meta(turnip.MetaStatusCode, turnip.GTE(400)), APIError{})
+//
+// Responses with a content type other than JSON fail without being resolved. A missing content type is taken as JSON.
+// Bodies still compressed with gzip or deflate, as told by their content encoding, are decompressed first. Bodies are
+// limited to MaxDecompressedSize, before and after decompressing, and larger ones fail with ErrMalformed without being
+// read whole
+func (u *Unmarshaler) DecodeResponse(resp *http.Response) (any, error) {
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, fmt.Errorf("%w: content type: %w", ErrMalformed, err)
+		}
+
+		if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+			return nil, fmt.Errorf("%w: content type: expected JSON, got '%s'", ErrMalformed, mediaType)
+		}
+	}
+
+	limit := u.load().env.decompressionLimit
+	// A byte past the limit tells bodies that are too large apart from those that end right at it
+	b, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	if int64(len(b)) > int64(limit) {
+		return nil, fmt.Errorf("%w: body: larger than %d bytes", ErrMalformed, int64(limit))
+	}
+
+	b, err = decodeContent(b, resp.Header.Get("Content-Encoding"), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.UnmarshalJSONContext(b, ResolveContext{
+		Metadata: map[string]any{
+			MetaStatusCode:  resp.StatusCode,
+			MetaContentType: contentType,
+		},
+	})
+}
+
+// decodeContent undoes the content encoding of a body. The transport of net/http already decompresses the bodies it
+// asked compressed, and drops the header, so only bodies compressed on someone else's request get here
+func decodeContent(b []byte, encoding string, limit decompressionLimit) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return b, nil
+	case "gzip", "x-gzip":
+		return readDecompressed(bytes.NewReader(b), gzipped, limit)
+	case "deflate":
+		return readDecompressed(bytes.NewReader(b), zlibbed, limit)
+	default:
+		return nil, fmt.Errorf("%w: content encoding: unsupported '%s'", ErrMalformed, encoding)
+	}
+}
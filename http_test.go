@@ -0,0 +1,150 @@
+package turnip
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type httpUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type httpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestDecodeResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  http.Header
+		body    string
+		want    any
+		wantErr error
+	}{
+		{
+			name:   "json",
+			status: http.StatusOK,
+			header: http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+			body:   `{"id":1,"name":"ada"}`,
+			want:   &httpUser{ID: 1, Name: "ada"},
+		},
+		{
+			name:   "json suffix",
+			status: http.StatusOK,
+			header: http.Header{"Content-Type": {"application/vnd.api+json"}},
+			body:   `{"id":1,"name":"ada"}`,
+			want:   &httpUser{ID: 1, Name: "ada"},
+		},
+		{
+			name:   "no content type",
+			status: http.StatusOK,
+			body:   `{"id":1,"name":"ada"}`,
+			want:   &httpUser{ID: 1, Name: "ada"},
+		},
+		{
+			name:   "error status",
+			status: http.StatusNotFound,
+			header: http.Header{"Content-Type": {"application/json"}},
+			body:   `{"code":404,"message":"not found"}`,
+			want:   &httpError{Code: 404, Message: "not found"},
+		},
+		{
+			name:   "gzip",
+			status: http.StatusOK,
+			header: http.Header{"Content-Encoding": {"gzip"}},
+			body:   string(gzipBytes(t, []byte(`{"id":1,"name":"ada"}`))),
+			want:   &httpUser{ID: 1, Name: "ada"},
+		},
+		{
+			name:    "other content type",
+			status:  http.StatusBadGateway,
+			header:  http.Header{"Content-Type": {"text/html"}},
+			body:    `<html></html>`,
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "unsupported content encoding",
+			status:  http.StatusOK,
+			header:  http.Header{"Content-Encoding": {"br"}},
+			body:    `{"id":1,"name":"ada"}`,
+			wantErr: ErrMalformed,
+		},
+	}
+
+	u, err := New(SelectWhen(Meta(MetaStatusCode, GTE(400)), httpError{}), Candidate(httpUser{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.DecodeResponse(&http.Response{
+				StatusCode: tt.status,
+				Header:     tt.header,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecodeResponse() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeResponse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeResponseLimit(t *testing.T) {
+	body := `{"id":1,"name":"ada"}`
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    string
+		limit   int64
+		wantErr error
+	}{
+		{
+			name:  "at the limit",
+			body:  body,
+			limit: int64(len(body)),
+		},
+		{
+			name:    "larger than the limit",
+			body:    body,
+			limit:   int64(len(body)) - 1,
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "larger than the limit once decompressed",
+			header:  http.Header{"Content-Encoding": {"gzip"}},
+			body:    string(gzipBytes(t, []byte(body+strings.Repeat(" ", 64)))),
+			limit:   int64(len(body)) + 32,
+			wantErr: ErrMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(Candidate(httpUser{}), MaxDecompressedSize(tt.limit))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = u.DecodeResponse(&http.Response{
+				StatusCode: http.StatusOK,
+				Header:     tt.header,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DecodeResponse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package turnip
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Implementations registers the concrete types that can be found in fields of the interface type I. Once the outer
+// candidate is resolved, the JSON of each such field is resolved against the implementations, and the field decoded
+// into the one that matches:
+//
+//	turnip.Implementations[EventPayload](UserPayload{}, OrderPayload{})
+//
+// Slices and arrays of I are resolved element by element, so an array mixing several implementations decodes into each
+// of them. Maps of I are not, since encoding/json decodes their values from scratch
+func Implementations[I any](impls ...any) Parameter {
+	i := &implementations{
+		iface: reflect.TypeOf((*I)(nil)).Elem(),
+	}
+
+	for _, impl := range impls {
+		i.candidates = append(i.candidates, &candidate{typ: reflect.TypeOf(impl)})
+	}
+
+	return i
+}
+
+// Mixed is an interface for fields that can hold values of unrelated types, with no interface of their own.
+// Registering the types it can hold with Implementations[turnip.Mixed] lets them be resolved, as in an activity feed
+// of mixed items:
+//
+//	type Feed struct {
+//		Items []turnip.Mixed `json:"items"`
+//	}
+//
+//	turnip.New(turnip.Candidate(Feed{}), turnip.Implementations[turnip.Mixed](Comment{}, Like{}, Share{}))
+type Mixed interface{}
+
+type implementations struct {
+	iface      reflect.Type
+	candidates []*candidate
+}
+
+func (i *implementations) Name() string {
+	return "Implementations"
+}
+
+func (i *implementations) validate() error {
+	if i.iface.Kind() != reflect.Interface {
+		return fmt.Errorf("%s is not an interface", i.iface)
+	}
+
+	if len(i.candidates) == 0 {
+		return fmt.Errorf("no implementations given for %s", i.iface)
+	}
+
+	for _, c := range i.candidates {
+		if c.typ == nil {
+			// Reported when validating the candidates
+			continue
+		}
+
+		// Fields are always filled with a pointer, which also has the methods of the value
+		if !reflect.PointerTo(c.typ).Implements(i.iface) {
+			return fmt.Errorf("%s does not implement %s", c.typ, i.iface)
+		}
+	}
+
+	return nil
+}
+
+// interfaceResolvers resolves the concrete type of interface fields, by interface type
+type interfaceResolvers map[reflect.Type]Resolver
+
+func newInterfaceResolvers(env environment) (interfaceResolvers, error) {
+	resolvers := make(interfaceResolvers, len(env.implementations))
+	for iface, impls := range env.implementations {
+		// Implementations are resolved just like candidates are, they only have a different set of rivals
+		implEnv := env
+		implEnv.candidates = impls.candidates
+		implEnv.selectors = nil
+		implEnv.logger = env.logger.Named(iface.String())
+
+		r, err := newTraverseResolver(implEnv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", iface, err)
+		}
+
+		resolvers[iface] = r
+	}
+
+	return resolvers, nil
+}
+
+// populate walks v looking for interface fields with registered implementations, and fills each one with a pointer to
+// the implementation its JSON resolves to. encoding/json then decodes into that pointer, instead of failing to decode
+// into a bare interface
+func (ir interfaceResolvers) populate(v reflect.Value, res gjson.Result) error {
+	if !res.Exists() || res.Type == gjson.Null {
+		// encoding/json leaves these alone, so do we
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return ir.populate(v.Elem(), res)
+	case reflect.Struct:
+		if !res.IsObject() {
+			return nil
+		}
+
+		for _, f := range wireFields(v.Type(), jsonFormat) {
+			sub := getKey(res, f.name)
+			if !sub.Exists() || f.quoted {
+				continue
+			}
+
+			fv := fieldByIndex(v, f.index)
+			if !fv.IsValid() {
+				continue
+			}
+
+			err := ir.populate(fv, sub)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+		}
+
+		return nil
+	case reflect.Slice:
+		if !res.IsArray() || !ir.holds(v.Type().Elem()) {
+			return nil
+		}
+
+		// encoding/json decodes into the elements already there, as long as the length doesn't change
+		elems := res.Array()
+		s := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+		for i, elem := range elems {
+			err := ir.populate(s.Index(i), elem)
+			if err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+
+		v.Set(s)
+		return nil
+	case reflect.Array:
+		if !res.IsArray() || !ir.holds(v.Type().Elem()) {
+			return nil
+		}
+
+		for i, elem := range res.Array() {
+			if i >= v.Len() {
+				break
+			}
+
+			err := ir.populate(v.Index(i), elem)
+			if err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+
+		return nil
+	case reflect.Interface:
+		r, ok := ir[v.Type()]
+		if !ok {
+			return nil
+		}
+
+		typ, err := r.ResolveJSON(res)
+		if err != nil {
+			return err
+		}
+
+		if typ == nil {
+			return fmt.Errorf("%w: no implementation of %s", ErrNoMatch, v.Type())
+		}
+
+		impl := reflect.New(typ)
+		err = ir.populate(impl.Elem(), res)
+		if err != nil {
+			return err
+		}
+
+		v.Set(impl)
+		return nil
+	default:
+		return nil
+	}
+}
+
+// holds reports whether values of t may have interfaces to populate. Structs are assumed to, since telling would mean
+// walking all of their fields
+func (ir interfaceResolvers) holds(t reflect.Type) bool {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			t = t.Elem()
+		case reflect.Interface:
+			_, ok := ir[t]
+			return ok
+		case reflect.Struct:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but allocates the nil embedded pointers on the way, as encoding/json
+// would. Pointers to unexported embedded structs can't be allocated, in which case the returned value is invalid
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}
+				}
+
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// getKey looks up a key the way encoding/json matches them: an exact match is preferred, but any key equal under
+// case folding is accepted otherwise
+func getKey(res gjson.Result, name string) gjson.Result {
+	var exact, folded gjson.Result
+	res.ForEach(func(key, value gjson.Result) bool {
+		if key.String() == name {
+			exact = value
+			return false
+		}
+
+		if !folded.Exists() && strings.EqualFold(key.String(), name) {
+			folded = value
+		}
+
+		return true
+	})
+
+	if exact.Exists() {
+		return exact
+	}
+
+	return folded
+}
@@ -0,0 +1,141 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type ifacePayload interface {
+	isPayload()
+}
+
+type ifaceUser struct {
+	Name string `json:"name"`
+}
+
+func (ifaceUser) isPayload() {}
+
+type ifaceOrder struct {
+	Total int `json:"total"`
+}
+
+func (ifaceOrder) isPayload() {}
+
+type ifaceEvent struct {
+	Type    string       `json:"type"`
+	Payload ifacePayload `json:"payload"`
+}
+
+func TestImplementations(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    any
+		wantErr error
+	}{
+		{"first", `{"type":"a","payload":{"name":"ada"}}`, &ifaceEvent{Type: "a", Payload: &ifaceUser{Name: "ada"}}, nil},
+		{"second", `{"type":"a","payload":{"total":1}}`, &ifaceEvent{Type: "a", Payload: &ifaceOrder{Total: 1}}, nil},
+		{"null", `{"type":"a","payload":null}`, &ifaceEvent{Type: "a"}, nil},
+		{"missing", `{"type":"a"}`, &ifaceEvent{Type: "a"}, nil},
+		{"no implementation", `{"type":"a","payload":{"other":1}}`, nil, ErrNoMatch},
+	}
+
+	u, err := New(Candidate(ifaceEvent{}), Implementations[ifacePayload](ifaceUser{}, ifaceOrder{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImplementationsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []Parameter
+	}{
+		{"not an interface", []Parameter{Implementations[ifaceUser](ifaceOrder{})}},
+		{"not implementing", []Parameter{Implementations[ifacePayload](ifaceEvent{})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(append(tt.params, Candidate(ifaceEvent{}))...)
+			if !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("New() error = %v, want %v", err, ErrInvalidParameter)
+			}
+		})
+	}
+}
+
+type ifaceComment struct {
+	Text string `json:"text"`
+}
+
+type ifaceLike struct {
+	By string `json:"by"`
+}
+
+type ifaceFeed struct {
+	Items []Mixed  `json:"items"`
+	Pair  [2]Mixed `json:"pair"`
+}
+
+func TestImplementationsElements(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "slice",
+			payload: `{"items":[{"text":"hi"},{"by":"ada"}]}`,
+			want:    &ifaceFeed{Items: []Mixed{&ifaceComment{Text: "hi"}, &ifaceLike{By: "ada"}}},
+		},
+		{
+			name:    "array",
+			payload: `{"pair":[{"by":"ada"},{"text":"hi"}]}`,
+			want:    &ifaceFeed{Pair: [2]Mixed{&ifaceLike{By: "ada"}, &ifaceComment{Text: "hi"}}},
+		},
+		{
+			name:    "empty slice",
+			payload: `{"items":[]}`,
+			want:    &ifaceFeed{Items: []Mixed{}},
+		},
+		{
+			name:    "element with no implementation",
+			payload: `{"items":[{"text":"hi"},{"other":1}]}`,
+			wantErr: ErrNoMatch,
+		},
+	}
+
+	u, err := New(Candidate(ifaceFeed{}), Implementations[Mixed](ifaceComment{}, ifaceLike{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
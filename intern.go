@@ -0,0 +1,88 @@
+package turnip
+
+import "sync"
+
+// interner deduplicates strings so that equal paths found across many candidates share the same backing memory
+type interner struct {
+	mu      sync.Mutex
+	strings map[string]string
+	// parent is the interner shared with other Unmarshalers, nil for one of its own. Strings are interned with it once
+	// each, and given back to it by release
+	parent *interner
+	// refs counts the children holding each string, for interners with children
+	refs map[string]int
+}
+
+func newInterner() *interner {
+	return &interner{
+		strings: make(map[string]string),
+	}
+}
+
+// child returns an interner sharing the strings of i, which keeps them as long as any of its children holds them
+func (i *interner) child() *interner {
+	c := newInterner()
+	c.parent = i
+	return c
+}
+
+func (i *interner) intern(s string) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if interned, ok := i.strings[s]; ok {
+		return interned
+	}
+
+	if i.parent != nil {
+		s = i.parent.acquire(s)
+	}
+
+	i.strings[s] = s
+	return s
+}
+
+// acquire interns s for a child
+func (i *interner) acquire(s string) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.refs == nil {
+		i.refs = make(map[string]int)
+	}
+
+	i.refs[s]++
+	if interned, ok := i.strings[s]; ok {
+		return interned
+	}
+
+	i.strings[s] = s
+	return s
+}
+
+// release gives the strings of the child i back to its parent, which forgets those no other child holds
+func (i *interner) release() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	p := i.parent
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for s := range i.strings {
+		p.refs[s]--
+		if p.refs[s] <= 0 {
+			delete(p.refs, s)
+			delete(p.strings, s)
+		}
+	}
+
+	clear(i.strings)
+}
+
+func (i *interner) len() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return len(i.strings)
+}
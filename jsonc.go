@@ -0,0 +1,83 @@
+package turnip
+
+// stripJSONC turns JSON with comments and trailing commas into plain JSON. Both are replaced by spaces rather than
+// removed, so offsets in the result still point to the same place in the original. Newlines inside block comments are
+// kept for the same reason
+func stripJSONC(b []byte) []byte {
+	out := make([]byte, len(b))
+	copy(out, b)
+
+	inString := false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		if inString {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+			}
+
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := i + 2
+			for end < len(out) && !(out[end] == '*' && end+1 < len(out) && out[end+1] == '/') {
+				end++
+			}
+
+			end = min(end+2, len(out))
+			for ; i < end; i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+
+			i--
+		}
+	}
+
+	// Comments are gone, so trailing commas are only followed by whitespace now
+	inString = false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		if inString {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+			}
+
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case ',':
+			next := i + 1
+			for next < len(out) && isJSONSpace(out[next]) {
+				next++
+			}
+
+			if next < len(out) && (out[next] == '}' || out[next] == ']') {
+				out[i] = ' '
+			}
+		}
+	}
+
+	return out
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,77 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type commentedConfig struct {
+	Name  string `json:"name"`
+	Ports []int  `json:"ports"`
+}
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", `{"a":1}`, `{"a":1}`},
+		{"line comment", "{\"a\":1 // one\n}", "{\"a\":1       \n}"},
+		{"block comment", `{/* a */"a":1}`, `{       "a":1}`},
+		{"block comment over lines", "{/* a\nb */\"a\":1}", "{    \n    \"a\":1}"},
+		{"trailing comma", `{"a":[1,2,],}`, `{"a":[1,2 ] }`},
+		{"comma before a comment", "{\"a\":1, // one\n}", "{\"a\":1        \n}"},
+		{"comment in a string", `{"a":"// not /* a comment */"}`, `{"a":"// not /* a comment */"}`},
+		{"comma in a string", `{"a":",}"}`, `{"a":",}"}`},
+		{"escaped quote", `{"a":"\"//"}`, `{"a":"\"//"}`},
+		{"unterminated block comment", `{"a":1}/* a`, `{"a":1}    `},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(stripJSONC([]byte(tt.in)))
+			if got != tt.want {
+				t.Errorf("stripJSONC() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnableJSONC(t *testing.T) {
+	payload := `{
+		// the service name
+		"name": "api", /* the ports
+		it listens on */
+		"ports": [80, 443,],
+	}`
+
+	tests := []struct {
+		name    string
+		params  []Parameter
+		want    any
+		wantErr error
+	}{
+		{"enabled", []Parameter{EnableJSONC()}, &commentedConfig{Name: "api", Ports: []int{80, 443}}, nil},
+		{"disabled", nil, nil, ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(append(tt.params, Candidate(commentedConfig{}))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
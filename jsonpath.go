@@ -0,0 +1,312 @@
+package turnip
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// jsonPathEngine is the PathEngine registered as "jsonpath". It covers the JSONPath most routing rules are written in:
+// the root $, child names as .name or ['name'], wildcards, indexes (negative ones counting from the end), recursive
+// descent with .., and filters comparing a path of each element with a literal, like [?(@.price < 10)], or checking
+// that it exists, like [?(@.isbn)]
+func jsonPathEngine(expr string) (PathQuery, error) {
+	steps, err := parseJSONPath(expr)
+	if err != nil {
+		return nil, fmt.Errorf("jsonpath %s: %w", expr, err)
+	}
+
+	return func(res gjson.Result) []gjson.Result {
+		nodes := []gjson.Result{res}
+		for _, s := range steps {
+			nodes = s.apply(nodes)
+			if len(nodes) == 0 {
+				break
+			}
+		}
+
+		return nodes
+	}, nil
+}
+
+type jsonPathStep struct {
+	// recursive is set for steps after .., which apply to every object and array below the nodes too
+	recursive bool
+	wildcard  bool
+	name      string
+	// index is used when name is empty and the step is neither a wildcard nor a filter
+	index  int
+	filter *jsonPathFilter
+}
+
+type jsonPathFilter struct {
+	// path is the gjson path of the value compared, relative to each element. Empty for the element itself
+	path string
+	// op is empty for filters that only check the value exists
+	op      string
+	literal any
+}
+
+var (
+	// jsonPathFilterSyntax matches the inside of filters: @ followed by a path, and optionally a comparison
+	jsonPathFilterSyntax = regexp.MustCompile(`^@((?:\.[^.\[\s=!<>]+|\['[^']*'\])*)\s*(?:(==|!=|<=|>=|<|>)\s*(.+?))?\s*$`)
+	// jsonPathFilterKeys matches each key of the path of a filter
+	jsonPathFilterKeys = regexp.MustCompile(`\.([^.\[]+)|\['([^']*)'\]`)
+)
+
+func parseJSONPath(expr string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, errors.New("must start with $")
+	}
+
+	var steps []jsonPathStep
+	for i := 1; i < len(expr); {
+		var s jsonPathStep
+		switch {
+		case strings.HasPrefix(expr[i:], ".."):
+			s.recursive = true
+			i += 2
+			if i < len(expr) && expr[i] == '[' {
+				break
+			}
+
+			fallthrough
+		case expr[i] == '.':
+			if !s.recursive {
+				i++
+			}
+
+			end := i
+			for end < len(expr) && expr[end] != '.' && expr[end] != '[' {
+				end++
+			}
+
+			if end == i {
+				return nil, fmt.Errorf("empty name at %d", i)
+			}
+
+			s.name, s.wildcard = expr[i:end], expr[i:end] == "*"
+			if s.wildcard {
+				s.name = ""
+			}
+
+			i = end
+			steps = append(steps, s)
+			continue
+		case expr[i] != '[':
+			return nil, fmt.Errorf("unexpected %q at %d", expr[i], i)
+		}
+
+		end, err := closingBracket(expr, i)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.parseBracket(strings.TrimSpace(expr[i+1 : end]))
+		if err != nil {
+			return nil, err
+		}
+
+		i = end + 1
+		steps = append(steps, s)
+	}
+
+	return steps, nil
+}
+
+// closingBracket returns the position of the bracket closing the one at start, skipping quoted strings
+func closingBracket(expr string, start int) (int, error) {
+	var quote byte
+	depth := 0
+	for i := start; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("unclosed [ at %d", start)
+}
+
+func (s *jsonPathStep) parseBracket(inner string) error {
+	switch {
+	case inner == "*":
+		s.wildcard = true
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		s.name = inner[1 : len(inner)-1]
+	case strings.HasPrefix(inner, "?(") && strings.HasSuffix(inner, ")"):
+		f, err := parseJSONPathFilter(strings.TrimSpace(inner[2 : len(inner)-1]))
+		if err != nil {
+			return err
+		}
+
+		s.filter = f
+	default:
+		index, err := strconv.Atoi(inner)
+		if err != nil {
+			return fmt.Errorf("unsupported selector [%s]", inner)
+		}
+
+		s.index = index
+	}
+
+	return nil
+}
+
+func parseJSONPathFilter(inner string) (*jsonPathFilter, error) {
+	m := jsonPathFilterSyntax.FindStringSubmatch(inner)
+	if m == nil {
+		return nil, fmt.Errorf("unsupported filter ?(%s)", inner)
+	}
+
+	var keys []string
+	for _, part := range jsonPathFilterKeys.FindAllStringSubmatch(m[1], -1) {
+		keys = append(keys, escapeKey(part[1]+part[2]))
+	}
+
+	f := &jsonPathFilter{
+		path: strings.Join(keys, "."),
+		op:   m[2],
+	}
+
+	if f.op == "" {
+		return f, nil
+	}
+
+	literal := m[3]
+	if len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'' {
+		f.literal = literal[1 : len(literal)-1]
+		return f, nil
+	}
+
+	err := json.Unmarshal([]byte(literal), &f.literal)
+	if err != nil {
+		return nil, fmt.Errorf("invalid literal %s in filter", literal)
+	}
+
+	return f, nil
+}
+
+func (s jsonPathStep) apply(nodes []gjson.Result) []gjson.Result {
+	if s.recursive {
+		var all []gjson.Result
+		for _, n := range nodes {
+			all = appendContainers(all, n)
+		}
+
+		nodes = all
+	}
+
+	var out []gjson.Result
+	for _, n := range nodes {
+		switch {
+		case s.wildcard:
+			n.ForEach(func(_, v gjson.Result) bool {
+				out = append(out, v)
+				return true
+			})
+		case s.filter != nil:
+			n.ForEach(func(_, v gjson.Result) bool {
+				if s.filter.matches(v) {
+					out = append(out, v)
+				}
+
+				return true
+			})
+		case s.name != "":
+			if v := n.Get(escapeKey(s.name)); n.IsObject() && v.Exists() {
+				out = append(out, v)
+			}
+		case n.IsArray():
+			elems := n.Array()
+			i := s.index
+			if i < 0 {
+				i += len(elems)
+			}
+
+			if i >= 0 && i < len(elems) {
+				out = append(out, elems[i])
+			}
+		}
+	}
+
+	return out
+}
+
+// appendContainers appends n, and every object and array below it, to nodes
+func appendContainers(nodes []gjson.Result, n gjson.Result) []gjson.Result {
+	if !n.IsObject() && !n.IsArray() {
+		return nodes
+	}
+
+	nodes = append(nodes, n)
+	n.ForEach(func(_, v gjson.Result) bool {
+		nodes = appendContainers(nodes, v)
+		return true
+	})
+
+	return nodes
+}
+
+func (f *jsonPathFilter) matches(elem gjson.Result) bool {
+	v := elem
+	if f.path != "" {
+		v = elem.Get(f.path)
+	}
+
+	switch f.op {
+	case "":
+		return v.Exists()
+	case "==":
+		return equalsJSON(v, f.literal)
+	case "!=":
+		return v.Exists() && !equalsJSON(v, f.literal)
+	}
+
+	var order int
+	switch literal := f.literal.(type) {
+	case float64:
+		if v.Type != gjson.Number {
+			return false
+		}
+
+		order = cmp.Compare(v.Num, literal)
+	case string:
+		if v.Type != gjson.String {
+			return false
+		}
+
+		order = strings.Compare(v.Str, literal)
+	default:
+		return false
+	}
+
+	switch f.op {
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	default:
+		return order >= 0
+	}
+}
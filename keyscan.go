@@ -0,0 +1,159 @@
+package turnip
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// ScanKeysAbove resolves payloads larger than size bytes from a copy holding only the keys the selectors and
+// candidates look at. The keys of the payload are scanned once to build it, instead of going through the whole payload
+// for every path looked up, which pays off for multi-megabyte payloads. Decoding still reads the whole payload.
+//
+// Keys are only dropped where every path is known: the first key of gjson paths given to selectors, Version or
+// Fingerprint keeps all of its value, and strict candidates, which need every key, turn scanning off. So do resolvers
+// given with UseResolver, paths starting with gjson syntax other than plain keys, expressions of path engines and the
+// functions given to Match
+func ScanKeysAbove(size int) Parameter {
+	return scanThreshold(size)
+}
+
+type scanThreshold int
+
+func (t scanThreshold) Name() string {
+	return "ScanKeysAbove"
+}
+
+// unknownPath stands for the paths of conditions that can't be told, and turns scanning off
+const unknownPath = "*"
+
+// keyTree holds the keys that must be kept, nested as in the payload and put in the form of the Naming
+type keyTree struct {
+	// whole is set when the whole value is looked at
+	whole    bool
+	children map[string]*keyTree
+}
+
+// newKeyTree returns the keys resolution looks at, or nil if they can't be known
+func newKeyTree(env environment, fingerprints []fingerprint) *keyTree {
+	t := &keyTree{}
+	for _, s := range env.selectors {
+		for _, path := range s.cond.paths() {
+			if !t.addQuery(path) {
+				return nil
+			}
+		}
+	}
+
+	for _, fp := range fingerprints {
+		c := fp.candidate
+		if c.strict || c.engine != "" || len(c.matchers) > 0 {
+			return nil
+		}
+
+		if c.version != nil && !t.addQuery(c.version.path) {
+			return nil
+		}
+
+		for _, q := range c.queries {
+			if !t.addQuery(q) {
+				return nil
+			}
+		}
+
+		for path, typ := range fp.all {
+			t.add(strings.Split(path, "."))
+			if typ.aliases != nil {
+				for _, alias := range typ.aliases.paths {
+					t.add(strings.Split(alias, "."))
+				}
+			}
+		}
+	}
+
+	return t
+}
+
+// add adds the path made of keys, already in the form of the Naming, or written as in the payload for queries
+func (t *keyTree) add(keys []string) {
+	for _, key := range keys {
+		if t.whole {
+			return
+		}
+
+		if t.children == nil {
+			t.children = make(map[string]*keyTree)
+		}
+
+		child, ok := t.children[key]
+		if !ok {
+			child = &keyTree{}
+			t.children[key] = child
+		}
+
+		t = child
+	}
+
+	t.whole = true
+	t.children = nil
+}
+
+// addQuery keeps the whole value at the first key of the gjson path, since anything may be queried below it. The key is
+// kept as written, since gjson looks it up that way. It returns false if the path doesn't start with a plain key
+func (t *keyTree) addQuery(path string) bool {
+	key, ok := queryKey(path)
+	if !ok {
+		return false
+	}
+
+	t.add([]string{key})
+	return true
+}
+
+// queryKey returns the first key of the gjson path, if it's a plain key
+func queryKey(path string) (string, bool) {
+	key, _, _ := strings.Cut(path, ".")
+	if key == "" || strings.ContainsAny(key, `*?#@|\!=<>%[]{}(),`) {
+		return "", false
+	}
+
+	return key, true
+}
+
+// prune returns a copy of the object res with only the keys of the tree. Keys are matched following the Naming, and
+// written as in the payload, so both gjson and Naming lookups find them
+func (t *keyTree) prune(res gjson.Result, naming Naming) gjson.Result {
+	return gjson.Parse(string(t.appendPruned(nil, res, naming)))
+}
+
+func (t *keyTree) appendPruned(buf []byte, res gjson.Result, naming Naming) []byte {
+	buf = append(buf, '{')
+	first := true
+	res.ForEach(func(key, value gjson.Result) bool {
+		child, ok := t.children[key.String()]
+		if wire, follows := naming.wireKey(key.String()); !ok && follows {
+			child, ok = t.children[wire]
+		}
+
+		if !ok {
+			return true
+		}
+
+		if !first {
+			buf = append(buf, ',')
+		}
+
+		first = false
+		buf = append(buf, key.Raw...)
+		buf = append(buf, ':')
+		if child.whole || !value.IsObject() {
+			buf = append(buf, value.Raw...)
+		} else {
+			buf = child.appendPruned(buf, value, naming)
+		}
+
+		return true
+	})
+
+	return append(buf, '}')
+}
@@ -0,0 +1,151 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type scannedOrder struct {
+	ID    string  `json:"id"`
+	Total float64 `json:"total"`
+}
+
+type scannedRefund struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type scannedCustomer struct {
+	Tier string `json:"tier"`
+}
+
+type scannedProfile struct {
+	Customer scannedCustomer `json:"customer"`
+	Name     string          `json:"name"`
+}
+
+type scannedStarred struct {
+	Rating int    `json:"rating*"`
+	Name   string `json:"name"`
+}
+
+type scannedAccount struct {
+	UserID string
+	Plan   string `json:"plan"`
+}
+
+// scanFiller is a key no candidate looks at, to push payloads over the threshold
+var scanFiller = `"filler":"` + strings.Repeat("x", 256) + `"`
+
+func TestScanKeysAbove(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   []Parameter
+		payloads []string
+	}{
+		{
+			name:   "fingerprints",
+			params: []Parameter{Candidate(scannedOrder{}), Candidate(scannedRefund{})},
+			payloads: []string{
+				`{"id":"a","total":1}`,
+				`{"id":"a","reason":"x"}`,
+				`{"id":"a"}`,
+				`{"id":"a","total":"1"}`,
+			},
+		},
+		{
+			name:   "nested keys",
+			params: []Parameter{Candidate(scannedProfile{}), Candidate(scannedRefund{})},
+			payloads: []string{
+				`{"customer":{"tier":"gold","since":2020},"name":"ada"}`,
+				`{"customer":{"since":2020},"name":"ada","reason":"x","id":"a"}`,
+				`{"customer":"gold","id":"a","reason":"x"}`,
+			},
+		},
+		{
+			name:   "case folded keys",
+			params: []Parameter{Candidate(scannedOrder{}), Candidate(scannedRefund{})},
+			payloads: []string{
+				`{"ID":"a","TOTAL":1}`,
+				`{"Id":"a","Reason":"x"}`,
+			},
+		},
+		{
+			name:   "word split keys",
+			params: []Parameter{Candidate(scannedAccount{}), Candidate(scannedRefund{}), CamelCase},
+			payloads: []string{
+				`{"userId":"a","plan":"p"}`,
+				`{"user_id":"a","plan":"p"}`,
+			},
+		},
+		{
+			name:   "escaped keys",
+			params: []Parameter{Candidate(scannedStarred{}), Candidate(scannedProfile{})},
+			payloads: []string{
+				`{"rating*":5,"name":"ada"}`,
+				`{"rating\u002a":5,"name":"ada"}`,
+				`{"ratings":5,"name":"ada","customer":{"tier":"gold"}}`,
+				`{"customer":{"t\u0069er":"gold"},"name":"ada"}`,
+			},
+		},
+		{
+			name: "selectors",
+			params: []Parameter{
+				SelectWhen(Eq("meta.kind", "refund"), scannedRefund{}),
+				Candidate(scannedOrder{}),
+			},
+			payloads: []string{
+				`{"meta":{"kind":"refund"},"id":"a","total":1}`,
+				`{"meta":{"kind":"order"},"id":"a","total":1}`,
+				`{"META":{"kind":"refund"},"id":"a","total":1}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, err := New(tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scanned, err := New(append(tt.params, ScanKeysAbove(128))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if r := scanned.load().resolver.(*traverseResolver); r.scan == nil {
+				t.Fatal("ScanKeysAbove() turned scanning off")
+			}
+
+			for _, payload := range tt.payloads {
+				large := payload[:len(payload)-1] + "," + scanFiller + "}"
+				for _, b := range []string{payload, large} {
+					want, wantErr := plain.UnmarshalJSON([]byte(b))
+					got, gotErr := scanned.UnmarshalJSON([]byte(b))
+					if !errors.Is(gotErr, unwrapCategory(wantErr)) || !reflect.DeepEqual(got, want) {
+						t.Errorf("UnmarshalJSON(%s) = %#v, %v, want %#v, %v", b, got, gotErr, want, wantErr)
+					}
+				}
+			}
+		})
+	}
+}
+
+// unwrapCategory returns the category of err, for errors whose messages may differ
+func unwrapCategory(err error) error {
+	for _, category := range []error{ErrMalformed, ErrNoMatch, ErrDecode} {
+		if errors.Is(err, category) {
+			return category
+		}
+	}
+
+	if err != nil {
+		panic(fmt.Sprintf("uncategorized error %v", err))
+	}
+
+	return nil
+}
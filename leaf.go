@@ -0,0 +1,98 @@
+package turnip
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	rawMessageType      = reflect.TypeOf(json.RawMessage{})
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+var leafTypes = struct {
+	sync.RWMutex
+	types map[reflect.Type]gjson.Type
+	// generation counts the registrations, so paths built before one aren't taken for paths built after it
+	generation int
+}{
+	types: make(map[reflect.Type]gjson.Type),
+}
+
+// RegisterLeafType declares how values of t look on the wire, for types turnip can't figure out by itself. Fields of
+// a registered type are fingerprinted as jsonType instead of being traversed. Registering a type again replaces the
+// previous registration, and only affects Unmarshalers built afterwards
+func RegisterLeafType(t reflect.Type, jsonType gjson.Type) {
+	if t == nil {
+		panic("turnip: RegisterLeafType with nil type")
+	}
+
+	for t.Kind() == reflect.Pointer {
+		// Fields are dereferenced before looking them up
+		t = t.Elem()
+	}
+
+	if jsonType == gjson.False {
+		jsonType = gjson.True
+	}
+
+	leafTypes.Lock()
+	defer leafTypes.Unlock()
+
+	leafTypes.types[t] = jsonType
+	leafTypes.generation++
+}
+
+// leafGeneration returns the number of leaf types registered so far
+func leafGeneration() int {
+	leafTypes.RLock()
+	defer leafTypes.RUnlock()
+
+	return leafTypes.generation
+}
+
+// getLeafType reports whether t decodes itself instead of being decoded field by field, and if so, how it looks on
+// the wire. The builder must not descend into these, since their Go layout has nothing to do with their JSON
+func getLeafType(t reflect.Type) (pathType, bool) {
+	leafTypes.RLock()
+	jsonType, ok := leafTypes.types[t]
+	leafTypes.RUnlock()
+
+	if ok {
+		return pathType{json: jsonType}, true
+	}
+
+	if t == timeType {
+		// time.Time only accepts RFC 3339, which can tell it apart from other strings
+		return pathType{json: gjson.String, format: formatRFC3339}, true
+	}
+
+	if t == rawMessageType {
+		// Raw messages hold whatever is there, be it a scalar, an array or an object
+		return pathType{json: anyJSONType}, true
+	}
+
+	if implements(t, jsonUnmarshalerType) {
+		// There's no way to know what the implementation accepts, but strings are by far the most common
+		return pathType{json: gjson.String}, true
+	}
+
+	if implements(t, textUnmarshalerType) {
+		// encoding/json only hands JSON strings to UnmarshalText, so this one we know for sure
+		return pathType{json: gjson.String}, true
+	}
+
+	return pathType{}, false
+}
+
+// implements checks both t and *t, since encoding/json takes the address of fields to call pointer methods
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
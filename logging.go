@@ -0,0 +1,140 @@
+package turnip
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SampleLogs limits the debug logging done for every payload, so a traffic spike doesn't turn it into a flood. Only
+// one in every n debug entries is written, and no more than perSecond of them each second. Either can be 0 to leave it
+// unlimited. Entries of other levels, like those of building the Unmarshaler, are always written
+func SampleLogs(n, perSecond int) Parameter {
+	return &logSampling{
+		every:     n,
+		perSecond: perSecond,
+	}
+}
+
+type logSampling struct {
+	every     int
+	perSecond int
+}
+
+func (s *logSampling) Name() string {
+	return "SampleLogs"
+}
+
+// logSampler applies a logSampling, keeping its counts. Every core it wraps shares them, so the limits hold for the
+// Unmarshaler as a whole, whatever logger the entries go to
+type logSampler struct {
+	sampling *logSampling
+	// count is the number of debug entries seen so far
+	count atomic.Uint64
+	limit rateLimit
+}
+
+func newLogSampler(sampling *logSampling) *logSampler {
+	return &logSampler{
+		sampling: sampling,
+		limit:    rateLimit{max: sampling.perSecond},
+	}
+}
+
+func (s *logSampler) wrap(core zapcore.Core) zapcore.Core {
+	return &sampledCore{
+		Core:    core,
+		sampler: s,
+	}
+}
+
+func (s *logSampler) sample() bool {
+	n := s.count.Add(1)
+	if s.sampling.every > 1 && (n-1)%uint64(s.sampling.every) != 0 {
+		return false
+	}
+
+	return s.sampling.perSecond <= 0 || s.limit.allow(time.Now())
+}
+
+// sampledCore drops the debug entries its sampler doesn't let through
+type sampledCore struct {
+	zapcore.Core
+	sampler *logSampler
+}
+
+func (c *sampledCore) With(fields []zapcore.Field) zapcore.Core {
+	return c.sampler.wrap(c.Core.With(fields))
+}
+
+func (c *sampledCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	if ent.Level <= zapcore.DebugLevel && !c.sampler.sample() {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// rateLimit lets through at most max events every second
+type rateLimit struct {
+	mu  sync.Mutex
+	max int
+	// second is the second being counted, in Unix time, and count how many events it let through
+	second int64
+	count  int
+}
+
+func (l *rateLimit) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if s := now.Unix(); s != l.second {
+		l.second, l.count = s, 0
+	}
+
+	if l.count >= l.max {
+		return false
+	}
+
+	l.count++
+	return true
+}
+
+// ContextLogger takes the logger of each call from the Context of its ResolveContext, like one carrying the trace ID
+// of the request, instead of using the logger of WithLogger or EnableDebug for everything. Calls without a Context, or
+// for which loggerOf returns nil, use the latter. SampleLogs applies to both, sharing its limits
+func ContextLogger(loggerOf func(ctx context.Context) *zap.Logger) Parameter {
+	return contextLogger(loggerOf)
+}
+
+type contextLogger func(ctx context.Context) *zap.Logger
+
+func (l contextLogger) Name() string {
+	return "ContextLogger"
+}
+
+// loggerFor returns the logger of the call making the query
+func (u *unmarshaler) loggerFor(q *query) *zap.SugaredLogger {
+	if u.env.contextLogger == nil || q.ctx.Context == nil {
+		return u.env.logger
+	}
+
+	logger := u.env.contextLogger(q.ctx.Context)
+	if logger == nil {
+		return u.env.logger
+	}
+
+	if u.env.sampler != nil {
+		logger = logger.WithOptions(zap.WrapCore(u.env.sampler.wrap))
+	}
+
+	return logger.Sugar().Named("turnip")
+}
@@ -0,0 +1,74 @@
+package turnip
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	zapobserver "go.uber.org/zap/zaptest/observer"
+)
+
+type sampledOrder struct {
+	ID string `json:"id"`
+}
+
+func TestSampleLogs(t *testing.T) {
+	tests := []struct {
+		name      string
+		every     int
+		perSecond int
+		// want is how many of 10 entries are written, or at most wantMax if the second turns while logging them
+		want    int
+		wantMax int
+	}{
+		{"unlimited", 0, 0, 10, 10},
+		{"one in every", 3, 0, 4, 4},
+		{"per second", 0, 2, 2, 4},
+		{"both", 2, 3, 3, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := zapobserver.New(zapcore.DebugLevel)
+			u, err := New(Candidate(sampledOrder{}), WithLogger(zap.New(core)), SampleLogs(tt.every, tt.perSecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Entries logged while building the Unmarshaler aren't sampled
+			logs.TakeAll()
+
+			for range 10 {
+				_, _ = u.UnmarshalJSON([]byte(`{"id":"a"}`))
+			}
+
+			got := logs.FilterMessage("unmarshaled").Len()
+			if got < tt.want || got > tt.wantMax {
+				t.Errorf("SampleLogs() wrote %d entries, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	l := rateLimit{max: 2}
+	now := time.Unix(100, 0)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"first", now, true},
+		{"second", now.Add(time.Millisecond), true},
+		{"over the limit", now.Add(2 * time.Millisecond), false},
+		{"next second", now.Add(time.Second), true},
+	}
+
+	for _, tt := range tests {
+		if got := l.allow(tt.at); got != tt.want {
+			t.Errorf("allow() %s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package turnip
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// MapField declares that the field of candidate c is found at path in the payloads, instead of where the layout of
+// the struct puts it, so a value deep in an envelope can be decoded without declaring the envelope:
+//
+//	turnip.MapField(turnip.Candidate(Event{}), "payload.data.user_id", "UserID")
+//
+// The field is named as in Go, with dots for nested fields like "Address.City", and the path is made of keys joined by
+// dots, or is a JSON Pointer like "/payload/data/user_id". Keys are matched following the Naming. Both the
+// fingerprint and the decoding use the path, along with everything below it for struct fields. MapField can be applied
+// several times, but not to Strict candidates
+func MapField(c Parameter, path, field string) Parameter {
+	mapping := &fieldMapping{
+		path:  path,
+		field: field,
+	}
+
+	cand, ok := c.(*candidate)
+	if !ok {
+		// Rejected once given to New
+		mapping.of = c
+		return mapping
+	}
+
+	mapped := *cand
+	mapped.mappings = append(slices.Clip(cand.mappings), mapping)
+	return &mapped
+}
+
+type fieldMapping struct {
+	path  string
+	field string
+	// of is the parameter given to MapField when it's not a candidate
+	of Parameter
+}
+
+func (m *fieldMapping) Name() string {
+	return "MapField"
+}
+
+// mappedField is a fieldMapping resolved for the format
+type mappedField struct {
+	// keys are the keys of the path in the payload
+	keys []string
+	// wire are the names of the field and the fields holding it, as the decoder of the format knows them
+	wire []string
+}
+
+// mapFields resolves the mappings of the candidate for the format
+func (c *candidate) mapFields(format *format) ([]mappedField, error) {
+	mapped := make([]mappedField, 0, len(c.mappings))
+	for _, m := range c.mappings {
+		if m.path == "" {
+			return nil, fmt.Errorf("empty path for field %q", m.field)
+		}
+
+		wire, err := wirePath(c.typ, m.field, format)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := strings.Split(m.path, ".")
+		if isPointer(m.path) {
+			keys, err = pointerKeys(m.path)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		mapped = append(mapped, mappedField{
+			keys: keys,
+			wire: wire,
+		})
+	}
+
+	return mapped, nil
+}
+
+// wirePath returns the names the decoder of the format knows the field of t by, and every field holding it
+func wirePath(t reflect.Type, field string, format *format) ([]string, error) {
+	var wire []string
+	for _, name := range strings.Split(field, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("field %q: %s is not a struct", field, t)
+		}
+
+		fields := wireFields(t, format)
+		i := slices.IndexFunc(fields, func(f wireField) bool {
+			return t.FieldByIndex(f.index).Name == name
+		})
+
+		if i < 0 {
+			return nil, fmt.Errorf("field %q: %s has no field %s that's decoded", field, t, name)
+		}
+
+		f := fields[i]
+		wire = append(wire, f.name)
+		t = f.typ
+	}
+
+	return wire, nil
+}
+
+// remapPaths moves the paths of the mapped fields, and those below them, to where the payload has them
+func remapPaths(paths jsonPaths, mapped []mappedField, naming Naming, in *interner) jsonPaths {
+	for _, m := range mapped {
+		var from, to string
+		for _, name := range m.wire {
+			from = appendToPath(from, name, naming)
+		}
+
+		for _, key := range m.keys {
+			to = appendToPath(to, key, naming)
+		}
+
+		moved := make(jsonPaths)
+		for path, typ := range paths {
+			if path != from && !strings.HasPrefix(path, from+".") {
+				continue
+			}
+
+			delete(paths, path)
+
+			// Aliases point next to where the field would have been
+			typ.aliases = nil
+			moved[in.intern(to+strings.TrimPrefix(path, from))] = typ
+		}
+
+		for path, typ := range moved {
+			paths[path] = typ
+		}
+	}
+
+	return paths
+}
+
+// applyMappings copies the values at the mapped paths of the payload res into dst, where the fields are decoded from.
+// It reports whether anything was copied
+func applyMappings(dst []byte, res gjson.Result, mapped []mappedField, naming Naming) ([]byte, bool, error) {
+	copied := false
+	for _, m := range mapped {
+		var path string
+		for _, key := range m.keys {
+			path = appendToPath(path, key, naming)
+		}
+
+		v := naming.get(res, path)
+		if !v.Exists() {
+			continue
+		}
+
+		escaped := make([]string, len(m.wire))
+		for i, name := range m.wire {
+			escaped[i] = escapeKey(name)
+		}
+
+		var err error
+		dst, err = sjson.SetRawBytes(dst, strings.Join(escaped, "."), []byte(v.Raw))
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: map %s: %w", ErrInternal, path, err)
+		}
+
+		copied = true
+	}
+
+	return dst, copied, nil
+}
+
+// pathSyntax are the characters gjson and sjson take for path syntax
+const pathSyntax = `.*?|#@\!=<>%[]{}(),`
+
+// escapeKey escapes the path syntax in key
+func escapeKey(key string) string {
+	if !strings.ContainsAny(key, pathSyntax) {
+		return key
+	}
+
+	var sb strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(pathSyntax, r) {
+			sb.WriteByte('\\')
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
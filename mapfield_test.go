@@ -0,0 +1,124 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type mappedAddress struct {
+	City string `json:"city"`
+}
+
+type mappedEvent struct {
+	UserID string `json:"user_id"`
+	Kind   string `json:"kind"`
+}
+
+type mappedVisit struct {
+	Address mappedAddress `json:"address"`
+}
+
+type mappedOther struct {
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+func TestMapField(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate Parameter
+		payload   string
+		want      any
+		wantErr   error
+	}{
+		{
+			name:      "nested path",
+			candidate: MapField(Candidate(mappedEvent{}), "payload.data.user_id", "UserID"),
+			payload:   `{"payload":{"data":{"user_id":"a"}},"kind":"x"}`,
+			want:      &mappedEvent{UserID: "a", Kind: "x"},
+		},
+		{
+			name:      "json pointer",
+			candidate: MapField(Candidate(mappedEvent{}), "/payload/data/user_id", "UserID"),
+			payload:   `{"payload":{"data":{"user_id":"a"}}}`,
+			want:      &mappedEvent{UserID: "a"},
+		},
+		{
+			name:      "nested field",
+			candidate: MapField(Candidate(mappedVisit{}), "location.city_name", "Address.City"),
+			payload:   `{"location":{"city_name":"Lima"}}`,
+			want:      &mappedVisit{Address: mappedAddress{City: "Lima"}},
+		},
+		{
+			name:      "struct field",
+			candidate: MapField(Candidate(mappedVisit{}), "meta.where", "Address"),
+			payload:   `{"meta":{"where":{"city":"Lima"}}}`,
+			want:      &mappedVisit{Address: mappedAddress{City: "Lima"}},
+		},
+		{
+			name: "several fields",
+			candidate: MapField(MapField(Candidate(mappedEvent{}), "data.id", "UserID"),
+				"data.type", "Kind"),
+			payload: `{"data":{"id":"a","type":"x"}}`,
+			want:    &mappedEvent{UserID: "a", Kind: "x"},
+		},
+		{
+			name:      "unmapped path",
+			candidate: MapField(Candidate(mappedEvent{}), "payload.data.user_id", "UserID"),
+			payload:   `{"user_id":"a"}`,
+			wantErr:   ErrNoMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.candidate, Candidate(mappedOther{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapFieldInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		wantErr error
+	}{
+		{
+			name:    "unknown field",
+			params:  []Parameter{MapField(Candidate(mappedEvent{}), "data.id", "ID")},
+			wantErr: ErrInvalidCandidate,
+		},
+		{
+			name:    "not a candidate",
+			params:  []Parameter{Candidate(mappedEvent{}), MapField(Default(mappedOther{}), "data.id", "Kind")},
+			wantErr: ErrInvalidParameter,
+		},
+		{
+			name:    "strict",
+			params:  []Parameter{MapField(Strict(mappedEvent{}), "data.id", "UserID")},
+			wantErr: ErrInvalidParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.params...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
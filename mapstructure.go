@@ -0,0 +1,59 @@
+package turnip
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// UseMapstructure decodes resolved payloads with mapstructure instead of encoding/json, for its coercions. Weakly
+// typed input converts between scalars, like "42" into an int, and hooks run in order on every value, such as
+// mapstructure.StringToTimeHookFunc(time.DateOnly). Fields are still named by their json tags.
+//
+// Only the decoding changes, resolving is still done on the payload as is
+func UseMapstructure(weaklyTyped bool, hooks ...mapstructure.DecodeHookFunc) Parameter {
+	return &mapstructureBackend{
+		weaklyTyped: weaklyTyped,
+		hooks:       hooks,
+	}
+}
+
+type mapstructureBackend struct {
+	weaklyTyped bool
+	hooks       []mapstructure.DecodeHookFunc
+}
+
+func (m *mapstructureBackend) Name() string {
+	return "UseMapstructure"
+}
+
+// decode goes through a generic value first, which is what mapstructure decodes from. The options of the candidate
+// still apply, unknown fields being the unused keys for mapstructure, except for TimeLayout which is up to the hooks
+func (m *mapstructureBackend) decode(b []byte, v any, opts decodeOptions) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if opts.useNumber {
+		dec.UseNumber()
+	}
+
+	var generic any
+	err := dec.Decode(&generic)
+	if err != nil {
+		return err
+	}
+
+	config := &mapstructure.DecoderConfig{
+		Result:           v,
+		TagName:          "json",
+		WeaklyTypedInput: m.weaklyTyped,
+		ErrorUnused:      opts.disallowUnknownFields,
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(m.hooks...),
+	}
+
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(generic)
+}
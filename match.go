@@ -0,0 +1,39 @@
+package turnip
+
+import (
+	"slices"
+
+	"github.com/tidwall/gjson"
+)
+
+// Match attaches hand-written matching logic to candidate c, for payloads the structure alone can't tell apart. The
+// candidate only matches when match returns true for the payload, on top of its fingerprint:
+//
+//	turnip.Match(turnip.Candidate(Refund{}), func(res gjson.Result) bool {
+//		return res.Get("amount").Num < 0
+//	})
+//
+// Candidates still need fields telling them apart from the rest. For those declared with Fingerprint the matchers can
+// replace the fingerprint instead, by giving no queries: Match(Fingerprint(T{}), fn) matches T whenever fn returns
+// true. Match can be applied several times, and all the functions must hold. They are called concurrently, and can't
+// be seen through by ScanKeysAbove, which is turned off by them
+func Match(c Parameter, match func(res gjson.Result) bool) Parameter {
+	cand, ok := c.(*candidate)
+	if !ok {
+		// Rejected once given to New
+		return &candidateMatcher{of: c}
+	}
+
+	matched := *cand
+	matched.matchers = append(slices.Clip(cand.matchers), match)
+	return &matched
+}
+
+// candidateMatcher stands for a Match given something other than a candidate
+type candidateMatcher struct {
+	of Parameter
+}
+
+func (m *candidateMatcher) Name() string {
+	return "Match"
+}
@@ -0,0 +1,116 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+type matchCharge struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+type matchRefund struct {
+	RefundOf string `json:"refund_of"`
+	Reason   string `json:"reason"`
+}
+
+type matchAny struct {
+	Kind string `json:"kind"`
+}
+
+func negative(res gjson.Result) bool {
+	return res.Get("amount").Num < 0
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "matching",
+			params:  []Parameter{Match(Candidate(matchCharge{}), negative), Candidate(matchRefund{})},
+			payload: `{"id":"a","amount":-1}`,
+			want:    &matchCharge{ID: "a", Amount: -1},
+		},
+		{
+			name:    "not matching",
+			params:  []Parameter{Match(Candidate(matchCharge{}), negative), Candidate(matchRefund{})},
+			payload: `{"id":"a","amount":1}`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name: "on top of the fingerprint",
+			params: []Parameter{
+				Match(Candidate(matchCharge{}), func(gjson.Result) bool { return true }),
+				Candidate(matchRefund{}),
+			},
+			payload: `{"other":-1}`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name: "every function",
+			params: []Parameter{
+				Match(Match(Candidate(matchCharge{}), negative), func(res gjson.Result) bool {
+					return res.Get("id").Str == "b"
+				}),
+				Candidate(matchRefund{}),
+			},
+			payload: `{"id":"a","amount":-1}`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name: "instead of the fingerprint",
+			params: []Parameter{
+				Match(Fingerprint(matchAny{}), func(res gjson.Result) bool { return res.Get("kind").Str == "any" }),
+				Candidate(matchCharge{}),
+			},
+			payload: `{"kind":"any","id":"a","amount":1}`,
+			want:    &matchAny{Kind: "any"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []Parameter
+	}{
+		{"not a candidate", []Parameter{Candidate(matchCharge{}), Match(Default(matchAny{}), negative)}},
+		{"nil function", []Parameter{Match(Candidate(matchCharge{}), nil)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.params...)
+			if !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("New() error = %v, want %v", err, ErrInvalidParameter)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package turnip
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Merge returns an Unmarshaler resolving the union of the candidates of us, so libraries can export their unions of
+// events and applications can compose them:
+//
+//	all, err := turnip.Merge(billing.Events, shipping.Events)
+//
+// It's as if New had been given the parameters of each one, in order, with those given to several of them, like the
+// same Naming or a shared Parameter value, given only once. Those that can only be given once must otherwise agree,
+// so two different Namings fail. The paths of each candidate are reused from the Unmarshalers already
+// built, as long as they were built with the same settings, while the fingerprints are found anew, since they depend
+// on which other candidates there are. The merged Unmarshaler doesn't follow later reloads of us
+func Merge(us ...*Unmarshaler) (*Unmarshaler, error) {
+	var params []Parameter
+	for i, u := range us {
+		if u == nil {
+			return nil, fmt.Errorf("%w: unmarshaler #%d is nil", ErrInvalidParameter, i)
+		}
+
+		state := u.load()
+		params = mergeParams(params, state.params)
+
+		// Failures to build are left for the merged Unmarshaler to report, with everything else
+		if state.init() != nil {
+			continue
+		}
+
+		if r, ok := state.resolver.(*traverseResolver); ok {
+			params = append(params, builtPaths(r.built))
+		}
+	}
+
+	return New(params...)
+}
+
+// mergeParams appends params to merged, leaving out those equal to one already there
+func mergeParams(merged, params []Parameter) []Parameter {
+	for _, p := range params {
+		// Functions and maps can't be compared, and are given as they are
+		if p == nil || !reflect.TypeOf(p).Comparable() || !slices.Contains(merged, p) {
+			merged = append(merged, p)
+		}
+	}
+
+	return merged
+}
+
+// builtPaths are paths already built by another Unmarshaler, given by Merge to reuse them
+type builtPaths map[pathsKey]jsonPaths
+
+func (p builtPaths) Name() string {
+	return "builtPaths"
+}
+
+// pathsKey identifies the paths of a type built with a given configuration of the pathBuilder
+type pathsKey struct {
+	typ    reflect.Type
+	config string
+}
+
+// pathsConfig describes everything the paths built for a candidate depend on, other than its type. Leaf types are
+// global, so paths built before registering one are told apart by the number registered
+func pathsConfig(env environment, c *candidate) string {
+	var impls []string
+	for iface, i := range env.implementations {
+		names := make([]string, 0, len(i.candidates))
+		for _, impl := range i.candidates {
+			names = append(names, fullTypeName(impl.typ))
+		}
+
+		impls = append(impls, fullTypeName(iface)+"="+strings.Join(names, ","))
+	}
+
+	slices.Sort(impls)
+	return fmt.Sprintf("%s|%s|%t|%+v|%s|%s|%s|%d", env.format.name, strings.Join(env.format.tagKeys, ","),
+		env.settings.Get(enableLenient), env.coerce, env.naming, c.decode.timeLayout, strings.Join(impls, ";"),
+		leafGeneration())
+}
+
+// adopt takes paths built by another pathBuilder as its own. Enum and alias sets are switched for the ones of the
+// builder, since path types are compared by their pointers
+func (b *pathBuilder) adopt(paths jsonPaths) jsonPaths {
+	adopted := maps.Clone(paths)
+	for path, typ := range adopted {
+		if typ.oneOf != nil {
+			enum, ok := b.enums[typ.oneOf.key]
+			if !ok {
+				enum = typ.oneOf
+				b.enums[enum.key] = enum
+			}
+
+			typ.oneOf = enum
+		}
+
+		if typ.aliases != nil {
+			set, ok := b.aliasSets[typ.aliases.key]
+			if !ok {
+				set = typ.aliases
+				b.aliasSets[set.key] = set
+			}
+
+			typ.aliases = set
+		}
+
+		adopted[path] = typ
+	}
+
+	return adopted
+}
@@ -0,0 +1,209 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+type mergeInvoice struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+type mergeShipment struct {
+	ID      string `json:"id"`
+	Carrier string `json:"carrier"`
+}
+
+func TestMerge(t *testing.T) {
+	billing, err := New(Candidate(mergeInvoice{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shipping, err := New(Candidate(mergeShipment{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both were resolved by the id alone, which the merged Unmarshaler can't do
+	for _, u := range []*Unmarshaler{billing, shipping} {
+		if _, err := u.UnmarshalJSON([]byte(`{"id":"a"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := Merge(billing, shipping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		want    any
+		wantErr error
+	}{
+		{"first", `{"id":"a","total":1}`, &mergeInvoice{ID: "a", Total: 1}, nil},
+		{"second", `{"id":"a","carrier":"x"}`, &mergeShipment{ID: "a", Carrier: "x"}, nil},
+		{"shared fields alone", `{"id":"a"}`, nil, ErrNoMatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := all.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeSharedParameters(t *testing.T) {
+	shared := FuzzyMatch(50)
+	tests := []struct {
+		name     string
+		billing  []Parameter
+		shipping []Parameter
+		payload  string
+		want     any
+	}{
+		{
+			name:     "same tag key",
+			billing:  []Parameter{Candidate(mergeInvoice{}), TagKey("json")},
+			shipping: []Parameter{Candidate(mergeShipment{}), TagKey("json")},
+			payload:  `{"id":"a","carrier":"x"}`,
+			want:     &mergeShipment{ID: "a", Carrier: "x"},
+		},
+		{
+			name:     "same naming",
+			billing:  []Parameter{Candidate(mergeInvoice{}), SnakeCase},
+			shipping: []Parameter{Candidate(mergeShipment{}), SnakeCase},
+			payload:  `{"id":"a","total":1}`,
+			want:     &mergeInvoice{ID: "a", Total: 1},
+		},
+		{
+			name:     "same parameter",
+			billing:  []Parameter{Candidate(mergeInvoice{}), shared},
+			shipping: []Parameter{Candidate(mergeShipment{}), shared},
+			payload:  `{"id":"a","total":1}`,
+			want:     &mergeInvoice{ID: "a", Total: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billing, err := New(tt.billing...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			shipping, err := New(tt.shipping...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			all, err := Merge(billing, shipping)
+			if err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+
+			got, err := all.UnmarshalJSON([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+type mergeAmount struct {
+	Cents int `json:"cents"`
+}
+
+type mergeCharge struct {
+	ID     string      `json:"id"`
+	Amount mergeAmount `json:"amount"`
+}
+
+func TestMergeLeafTypes(t *testing.T) {
+	charges, err := New(Candidate(mergeCharge{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := charges.UnmarshalJSON([]byte(`{"id":"a","amount":{"cents":1}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The paths built by charges traverse the amount, which the merged Unmarshaler mustn't reuse
+	RegisterLeafType(reflect.TypeOf(mergeAmount{}), gjson.String)
+	all, err := Merge(charges)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := all.load().init(); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, paths := range all.load().resolver.(*traverseResolver).built {
+		if key.typ != reflect.TypeOf(mergeCharge{}) {
+			continue
+		}
+
+		if _, ok := paths["amount.cents"]; ok {
+			t.Errorf("paths = %v, want amount as a leaf", paths)
+		}
+
+		if got := paths["amount"].json; got != gjson.String {
+			t.Errorf("amount = %v, want %v", got, gjson.String)
+		}
+	}
+}
+
+func TestMergeInvalid(t *testing.T) {
+	invoices, err := New(Candidate(mergeInvoice{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	billing, err := New(Candidate(mergeInvoice{}), TagKey("json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	yamlShipping, err := New(Candidate(mergeShipment{}), TagKey("yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		us      []*Unmarshaler
+		wantErr error
+	}{
+		{"nil", []*Unmarshaler{invoices, nil}, ErrInvalidParameter},
+		{"same candidates", []*Unmarshaler{invoices, billing}, ErrInvalidCandidate},
+		{"different tag keys", []*Unmarshaler{billing, yamlShipping}, ErrDuplicateParameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Merge(tt.us...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Merge() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
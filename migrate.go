@@ -0,0 +1,82 @@
+package turnip
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Migrate turns values decoded into From into To with fn, so payloads of an old generation of a type can still be
+// resolved by their own shape while callers only ever get the latest one:
+//
+//	turnip.Migrate(UserV1{}, UserV2{}, func(v1 *UserV1) *UserV2 { ... })
+//
+// Migrations are chained, a UserV1 going through UserV2 on to UserV3 if that's migrated too, and apply to every value
+// the Unmarshaler decodes, whether it was resolved by a candidate, a selector or a default. The values given only tell
+// the types apart. fn returning nil fails the decoding
+func Migrate[From, To any](from From, to To, fn func(*From) *To) Parameter {
+	return &migration{
+		from: reflect.TypeOf((*From)(nil)).Elem(),
+		to:   reflect.TypeOf((*To)(nil)).Elem(),
+		apply: func(v any) any {
+			// A nil *To would make a non-nil any
+			if migrated := fn(v.(*From)); migrated != nil {
+				return migrated
+			}
+
+			return nil
+		},
+		valid: fn != nil,
+	}
+}
+
+type migration struct {
+	from, to reflect.Type
+	apply    func(v any) any
+	// valid is false for nil functions, which can't be told apart once wrapped by apply
+	valid bool
+}
+
+func (m *migration) Name() string {
+	return "Migrate"
+}
+
+// migrations are the migrations given, by the type they migrate from
+type migrations map[reflect.Type]*migration
+
+// check fails if following the migrations ever leads back to a type already migrated from
+func (m migrations) check() error {
+	for from, first := range m {
+		seen := map[reflect.Type]bool{from: true}
+		for next := m[first.to]; next != nil; next = m[next.to] {
+			if seen[next.from] {
+				return invalidParameter(first, "migrations from %s loop back to %s", from, next.from)
+			}
+
+			seen[next.from] = true
+		}
+	}
+
+	return nil
+}
+
+// migrate applies the migrations from the type of the decoded value v, one after the other, returning the last value
+func (m migrations) migrate(v any) (any, error) {
+	for {
+		ptr := reflect.TypeOf(v)
+		if ptr == nil || ptr.Kind() != reflect.Pointer {
+			return v, nil
+		}
+
+		mig, ok := m[ptr.Elem()]
+		if !ok {
+			return v, nil
+		}
+
+		migrated := mig.apply(v)
+		if migrated == nil {
+			return nil, fmt.Errorf("%w: migrating %s to %s returned nil", ErrDecode, mig.from, mig.to)
+		}
+
+		v = migrated
+	}
+}
@@ -0,0 +1,118 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type userV1 struct {
+	Name string `json:"name"`
+}
+
+type userV2 struct {
+	First string `json:"first"`
+	Last  string `json:"last"`
+}
+
+type userV3 struct {
+	FullName string `json:"full_name"`
+	Version  int    `json:"version"`
+}
+
+func migrateV1(v1 *userV1) *userV2 {
+	return &userV2{First: v1.Name}
+}
+
+func migrateV2(v2 *userV2) *userV3 {
+	return &userV3{FullName: v2.First + " " + v2.Last, Version: 3}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "migrated",
+			params:  []Parameter{Migrate(userV1{}, userV2{}, migrateV1)},
+			payload: `{"name":"ada"}`,
+			want:    &userV2{First: "ada"},
+		},
+		{
+			name:    "chained",
+			params:  []Parameter{Migrate(userV1{}, userV2{}, migrateV1), Migrate(userV2{}, userV3{}, migrateV2)},
+			payload: `{"name":"ada"}`,
+			want:    &userV3{FullName: "ada ", Version: 3},
+		},
+		{
+			name:    "chained from the middle",
+			params:  []Parameter{Migrate(userV1{}, userV2{}, migrateV1), Migrate(userV2{}, userV3{}, migrateV2)},
+			payload: `{"first":"ada","last":"lovelace"}`,
+			want:    &userV3{FullName: "ada lovelace", Version: 3},
+		},
+		{
+			name:    "latest",
+			params:  []Parameter{Migrate(userV1{}, userV2{}, migrateV1), Migrate(userV2{}, userV3{}, migrateV2)},
+			payload: `{"full_name":"ada lovelace","version":3}`,
+			want:    &userV3{FullName: "ada lovelace", Version: 3},
+		},
+		{
+			name:    "selected",
+			params:  []Parameter{SelectOn("legacy", true, userV1{}), Migrate(userV1{}, userV2{}, migrateV1)},
+			payload: `{"legacy":true,"name":"ada"}`,
+			want:    &userV2{First: "ada"},
+		},
+		{
+			name:    "nil result",
+			params:  []Parameter{Migrate(userV1{}, userV2{}, func(*userV1) *userV2 { return nil })},
+			payload: `{"name":"ada"}`,
+			wantErr: ErrDecode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := append([]Parameter{Candidate(userV1{}), Candidate(userV2{}), Candidate(userV3{})}, tt.params...)
+			u, err := New(params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []Parameter
+	}{
+		{"nil function", []Parameter{Migrate[userV1, userV2](userV1{}, userV2{}, nil)}},
+		{"migrated twice", []Parameter{Migrate(userV1{}, userV2{}, migrateV1), Migrate(userV1{}, userV2{}, migrateV1)}},
+		{"loop", []Parameter{
+			Migrate(userV1{}, userV2{}, migrateV1),
+			Migrate(userV2{}, userV1{}, func(*userV2) *userV1 { return &userV1{} }),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(append([]Parameter{Candidate(userV1{}), Candidate(userV2{})}, tt.params...)...)
+			if !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("New() error = %v, want %v", err, ErrInvalidParameter)
+			}
+		})
+	}
+}
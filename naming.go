@@ -0,0 +1,197 @@
+package turnip
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/tidwall/gjson"
+)
+
+// Naming decides when the name of a field matches a key of the payload. Both are put in the same form, and must then
+// be equal. It's given to New like any other parameter, and defaults to FoldCase
+type Naming uint8
+
+const (
+	// FoldCase matches names regardless of case, like encoding/json does: userId matches UserID but not user_id
+	FoldCase Naming = iota + 1
+	// ExactNames matches names only if they are written the same
+	ExactNames
+	// CamelCase matches keys written in camelCase, by their words, whatever the case of their acronyms: the field
+	// UserID, or tagged user_id, takes userId, userID and UserId, but not user_id nor user-id
+	CamelCase
+	// SnakeCase matches keys written in lowercase snake_case: the field UserID, or tagged userId, takes user_id, but not
+	// userId, User_ID nor user-id
+	SnakeCase
+	// KebabCase matches keys written in lowercase kebab-case: the field UserID, or tagged userId, takes user-id, but not
+	// userId nor user_id
+	KebabCase
+)
+
+func (n Naming) Name() string {
+	return "Naming"
+}
+
+func (n Naming) String() string {
+	switch n {
+	case FoldCase:
+		return "FoldCase"
+	case ExactNames:
+		return "ExactNames"
+	case CamelCase:
+		return "CamelCase"
+	case SnakeCase:
+		return "SnakeCase"
+	case KebabCase:
+		return "KebabCase"
+	default:
+		return fmt.Sprintf("Naming(%d)", n)
+	}
+}
+
+func (n Naming) valid() bool {
+	return n >= FoldCase && n <= KebabCase
+}
+
+// splitsWords reports whether names are matched by their words, which encoding/json can't do by itself, so payloads
+// need their keys renamed before decoding
+func (n Naming) splitsWords() bool {
+	return n == CamelCase || n == SnakeCase || n == KebabCase
+}
+
+// wireKey puts a key of the payload in the form names are compared in. It returns false for keys that are not written
+// in the convention of n, which match no name
+func (n Naming) wireKey(key string) (string, bool) {
+	var follows bool
+	switch n {
+	case CamelCase:
+		follows = !strings.ContainsAny(key, "_- ")
+	case SnakeCase:
+		follows = !strings.ContainsAny(key, "- ") && strings.ToLower(key) == key
+	case KebabCase:
+		follows = !strings.ContainsAny(key, "_ ") && strings.ToLower(key) == key
+	default:
+		follows = true
+	}
+
+	if !follows {
+		return "", false
+	}
+
+	return n.key(key), true
+}
+
+// key puts name, of a field or already in the form of n, in the form names are compared in
+func (n Naming) key(name string) string {
+	switch n {
+	case ExactNames:
+		return name
+	case CamelCase:
+		words := splitWords(name)
+		for i, w := range words {
+			r := []rune(strings.ToLower(w))
+			if i > 0 {
+				r[0] = unicode.ToUpper(r[0])
+			}
+
+			words[i] = string(r)
+		}
+
+		return strings.Join(words, "")
+	case SnakeCase:
+		return strings.ToLower(strings.Join(splitWords(name), "_"))
+	case KebabCase:
+		return strings.ToLower(strings.Join(splitWords(name), "-"))
+	default:
+		return strings.ToLower(name)
+	}
+}
+
+// get returns the value at path, made of keys in the form of n. Keys written as in path are taken first, like
+// encoding/json does, and otherwise every key of the objects in between is looked at
+func (n Naming) get(res gjson.Result, path string) gjson.Result {
+	if n == ExactNames {
+		return res.Get(path)
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		if !res.IsObject() {
+			return gjson.Result{}
+		}
+
+		// Keys are often already written in the form of n, and then there's no need to look at every other one
+		if n.key(key) == key {
+			if v := res.Get(escapeKey(key)); v.Exists() {
+				res = v
+				continue
+			}
+		}
+
+		var found gjson.Result
+		res.ForEach(func(k, v gjson.Result) bool {
+			if wire, ok := n.wireKey(k.String()); ok && wire == key {
+				found = v
+				return false
+			}
+
+			return true
+		})
+
+		if !found.Exists() {
+			return found
+		}
+
+		res = found
+	}
+
+	return res
+}
+
+// findKey returns the key of the object obj that matches name, preferring one written the same if it follows n
+func (n Naming) findKey(obj map[string]any, name string) (string, bool) {
+	if _, ok := obj[name]; ok {
+		if _, follows := n.wireKey(name); follows {
+			return name, true
+		}
+	}
+
+	want := n.key(name)
+	for key := range obj {
+		if wire, ok := n.wireKey(key); ok && wire == want {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+// splitWords splits name at separators and where the case changes. Runs of capitals are a single word, except for the
+// last one if a lowercase letter follows, so HTTPServer is HTTP and Server
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		if i == len(runes) || runes[i] == '_' || runes[i] == '-' || unicode.IsSpace(runes[i]) {
+			if start < i {
+				words = append(words, string(runes[start:i]))
+			}
+
+			start = i + 1
+			continue
+		}
+
+		if i == start || !unicode.IsUpper(runes[i]) {
+			continue
+		}
+
+		prev := runes[i-1]
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if !unicode.IsUpper(prev) || nextLower {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	return words
+}
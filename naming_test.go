@@ -0,0 +1,111 @@
+package turnip
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestNamingGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		naming  Naming
+		payload string
+		path    string
+		want    string
+	}{
+		{"exact key", FoldCase, `{"userid":1}`, "userid", "1"},
+		{"folded key", FoldCase, `{"UserID":1}`, "userid", "1"},
+		{"exact key first", FoldCase, `{"UserID":1,"userid":2}`, "userid", "2"},
+		{"nested", FoldCase, `{"User":{"ID":1}}`, "user.id", "1"},
+		{"missing", FoldCase, `{"name":1}`, "userid", ""},
+		{"not an object", FoldCase, `{"user":1}`, "user.id", ""},
+		{"snake case", SnakeCase, `{"userId":1}`, "user_id", ""},
+		{"snake case exact", SnakeCase, `{"user_id":1}`, "user_id", "1"},
+		{"exact names", ExactNames, `{"UserID":1}`, "userid", ""},
+		{"special characters", FoldCase, `{"a*b":1}`, "a*b", "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.naming.get(gjson.Parse(tt.payload), tt.path)
+			if got.Raw != tt.want {
+				t.Errorf("get(%s, %q) = %q, want %q", tt.payload, tt.path, got.Raw, tt.want)
+			}
+		})
+	}
+}
+
+type namedAccount struct {
+	UserID string
+	Plan   string `json:"plan"`
+}
+
+func TestNamings(t *testing.T) {
+	tests := []struct {
+		name    string
+		naming  Naming
+		payload string
+		want    string
+	}{
+		{"camel case", CamelCase, `{"user_id":"a","userId":"b","user-id":"c","plan":"p"}`, "b"},
+		{"camel case acronym", CamelCase, `{"user_id":"a","userID":"b","plan":"p"}`, "b"},
+		{"camel case pascal", CamelCase, `{"UserId":"b","plan":"p"}`, "b"},
+		{"camel case only snake", CamelCase, `{"user_id":"a","plan":"p"}`, ""},
+		{"snake case", SnakeCase, `{"userId":"a","user_id":"b","user-id":"c","plan":"p"}`, "b"},
+		{"snake case uppercase", SnakeCase, `{"User_ID":"a","plan":"p"}`, ""},
+		{"snake case only camel", SnakeCase, `{"userId":"a","plan":"p"}`, ""},
+		{"kebab case", KebabCase, `{"userId":"a","user_id":"b","user-id":"c","plan":"p"}`, "c"},
+		{"kebab case only snake", KebabCase, `{"user_id":"a","plan":"p"}`, ""},
+		{"fold case", FoldCase, `{"user_id":"a","userid":"b","plan":"p"}`, "b"},
+		{"exact names", ExactNames, `{"userid":"a","UserID":"b","plan":"p"}`, "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(Candidate(namedAccount{}), tt.naming)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+
+			if want := (&namedAccount{UserID: tt.want, Plan: "p"}); !reflect.DeepEqual(got, want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestWireKey(t *testing.T) {
+	tests := []struct {
+		naming Naming
+		key    string
+		want   string
+		wantOk bool
+	}{
+		{CamelCase, "userId", "userId", true},
+		{CamelCase, "UserID", "userId", true},
+		{CamelCase, "user_id", "", false},
+		{CamelCase, "user-id", "", false},
+		{SnakeCase, "user_id", "user_id", true},
+		{SnakeCase, "userId", "", false},
+		{SnakeCase, "user-id", "", false},
+		{KebabCase, "user-id", "user-id", true},
+		{KebabCase, "user_id", "", false},
+		{KebabCase, "userId", "", false},
+		{FoldCase, "User_ID", "user_id", true},
+		{ExactNames, "User_ID", "User_ID", true},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.naming.wireKey(tt.key)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("%s.wireKey(%q) = %q, %v, want %q, %v", tt.naming, tt.key, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
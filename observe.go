@@ -0,0 +1,115 @@
+package turnip
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Observation is the outcome of unmarshaling a payload, as given to the functions of Observe
+type Observation struct {
+	// Type is the type the payload was unmarshaled into, nil if it failed
+	Type reflect.Type
+	// Group is the name of the group of Type, empty if it's not in one
+	Group string
+	// Size is the length of the payload in bytes
+	Size int
+	// SizeBucket is the range Size falls in, one of "1KiB", "16KiB", "256KiB" and "4MiB" for payloads up to that size,
+	// or "+Inf" for larger ones. It keeps the cardinality of metrics labeled by size low
+	SizeBucket string
+	Duration   time.Duration
+	Err        error
+}
+
+// sizeBuckets are the upper bounds of the payload sizes of SizeBucket
+var sizeBuckets = []struct {
+	name string
+	size int
+}{
+	{"1KiB", 1 << 10},
+	{"16KiB", 16 << 10},
+	{"256KiB", 256 << 10},
+	{"4MiB", 4 << 20},
+}
+
+// Observe calls observe after every payload is unmarshaled, for timing metrics. It's called from the goroutine doing
+// the unmarshaling, so it must be quick and safe for concurrent use. With Prometheus, for instance:
+//
+//	opts := prometheus.HistogramOpts{Name: "turnip_unmarshal_seconds"}
+//	hist := prometheus.NewHistogramVec(opts, []string{"type", "size"})
+//	turnip.Observe(func(o turnip.Observation) {
+//		hist.WithLabelValues(fmt.Sprint(o.Type), o.SizeBucket).Observe(o.Duration.Seconds())
+//	})
+func Observe(observe func(o Observation)) Parameter {
+	return observer(observe)
+}
+
+type observer func(o Observation)
+
+func (o observer) Name() string {
+	return "Observe"
+}
+
+func sizeBucket(size int) string {
+	for _, b := range sizeBuckets {
+		if size <= b.size {
+			return b.name
+		}
+	}
+
+	return "+Inf"
+}
+
+// record tells the observers, the resolution log and the debug log about unmarshaling the payload b, of size bytes,
+// started at start
+func (u *unmarshaler) record(logger *zap.SugaredLogger, start time.Time, b []byte, size int, v any, err error) {
+	debug := logger.Level().Enabled(zapcore.DebugLevel)
+	if u.recent == nil && len(u.env.observers) == 0 && !debug {
+		return
+	}
+
+	duration := time.Since(start)
+
+	var typ reflect.Type
+	if err == nil && v != nil {
+		typ = reflect.TypeOf(v)
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+	}
+
+	if debug {
+		logger.Debugw("unmarshaled", zap.String("type", fmt.Sprint(typ)), zap.Int("size", size),
+			zap.Duration("duration", duration), zap.Error(err))
+	}
+
+	for _, observe := range u.env.observers {
+		observe(Observation{
+			Type:       typ,
+			Group:      u.env.groups[typ],
+			Size:       size,
+			SizeBucket: sizeBucket(size),
+			Duration:   duration,
+			Err:        err,
+		})
+	}
+
+	if u.recent == nil {
+		return
+	}
+
+	h := fnv.New64a()
+	h.Write(b)
+
+	u.recent.add(Resolution{
+		Time:        start,
+		PayloadHash: h.Sum64(),
+		Type:        typ,
+		Duration:    duration,
+		Err:         err,
+	})
+}
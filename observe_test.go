@@ -0,0 +1,76 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type observedOrder struct {
+	ID string `json:"id"`
+}
+
+func TestObserve(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		wantType   reflect.Type
+		wantBucket string
+		wantErr    error
+	}{
+		{"unmarshaled", `{"id":"a"}`, reflect.TypeOf(observedOrder{}), "1KiB", nil},
+		{"no match", `{"other":1}`, nil, "1KiB", ErrNoMatch},
+		{"large", `{"id":"` + string(make([]byte, 2<<10)) + `"}`, nil, "16KiB", ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Observation
+			u, err := New(Candidate(observedOrder{}), Observe(func(o Observation) {
+				got = append(got, o)
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, _ = u.UnmarshalJSON([]byte(tt.payload))
+			if len(got) != 1 {
+				t.Fatalf("Observe() called %d times, want 1", len(got))
+			}
+
+			o := got[0]
+			if o.Type != tt.wantType {
+				t.Errorf("Observation.Type = %v, want %v", o.Type, tt.wantType)
+			}
+
+			if o.Size != len(tt.payload) || o.SizeBucket != tt.wantBucket {
+				t.Errorf("Observation size = %d (%s), want %d (%s)", o.Size, o.SizeBucket, len(tt.payload),
+					tt.wantBucket)
+			}
+
+			if !errors.Is(o.Err, tt.wantErr) {
+				t.Errorf("Observation.Err = %v, want %v", o.Err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSizeBucket(t *testing.T) {
+	tests := []struct {
+		size int
+		want string
+	}{
+		{0, "1KiB"},
+		{1 << 10, "1KiB"},
+		{1<<10 + 1, "16KiB"},
+		{256 << 10, "256KiB"},
+		{4 << 20, "4MiB"},
+		{4<<20 + 1, "+Inf"},
+	}
+
+	for _, tt := range tests {
+		if got := sizeBucket(tt.size); got != tt.want {
+			t.Errorf("sizeBucket(%d) = %s, want %s", tt.size, got, tt.want)
+		}
+	}
+}
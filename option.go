@@ -0,0 +1,86 @@
+package turnip
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+// Option is a Parameter that configures the Unmarshaler directly, and the way new configuration is added. Options mix
+// freely with the other parameters given to New:
+//
+//	turnip.New(turnip.WithCandidates(User{}, Order{}), turnip.WithLogger(logger), turnip.EnableLenient())
+type Option func(env *environment) error
+
+func (o Option) Name() string {
+	return "Option"
+}
+
+// WithParameters adds the parameters, as if they had been given to New
+func WithParameters(params ...Parameter) Option {
+	return func(env *environment) error {
+		for _, p := range params {
+			err := env.add(p)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// WithCandidates adds a candidate for each of the values
+func WithCandidates(vs ...any) Option {
+	return func(env *environment) error {
+		for _, v := range vs {
+			err := env.add(Candidate(v))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// WithStrict adds a strict candidate for each of the values, see Strict
+func WithStrict(vs ...any) Option {
+	return func(env *environment) error {
+		for _, v := range vs {
+			err := env.add(Strict(v))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// WithDefaults adds the values to the chain of defaults, in order, see Default
+func WithDefaults(vs ...any) Option {
+	return func(env *environment) error {
+		for _, v := range vs {
+			err := env.add(Default(v))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// WithLogger makes the Unmarshaler log through logger, instead of the development logger of EnableDebug or no logger at
+// all
+func WithLogger(logger *zap.Logger) Option {
+	return func(env *environment) error {
+		if logger == nil {
+			return errors.New("nil logger")
+		}
+
+		env.logger = logger.Sugar().Named("turnip")
+		return nil
+	}
+}
@@ -3,8 +3,12 @@ package turnip
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"math"
 	"reflect"
+	"slices"
 
+	"github.com/tidwall/gjson"
 	"go.uber.org/zap"
 )
 
@@ -16,51 +20,528 @@ type environment struct {
 	selectors  []*selector
 	candidates []*candidate
 	settings   settings
-	fallback   *fallback
+	fallbacks  []*fallback
 	logger     *zap.SugaredLogger
+	// format decides which struct tags are used to build the paths
+	format     *format
+	formatName formatName
+	tagKey     tagKey
+	// versionField is the path of the version for candidates declared with Version
+	versionField versionField
+	// coerce are the conversions between scalars enabled by the settings
+	coerce coercions
+	// naming is how field names are matched with the keys of payloads
+	naming Naming
+	// fuzzyThreshold is the percentage of paths needed to match a candidate when nothing matches exactly, 0 if disabled
+	fuzzyThreshold fuzzyThreshold
+
+	implementations map[reflect.Type]*implementations
+
+	// mapstructure decodes instead of encoding/json, when set by UseMapstructure
+	mapstructure *mapstructureBackend
+	// validation checks decoded values, when set by UseValidator
+	validation *validation
+	// observers are called after every payload is unmarshaled
+	observers []observer
+	// warners are called with the non-fatal issues of unmarshaling
+	warners []warner
+	// recentSize is how many resolutions are recorded, 0 if disabled
+	recentSize recentSize
+	// sampling limits the debug logging, when set by SampleLogs, as applied by sampler
+	sampling *logSampling
+	sampler  *logSampler
+	// contextLogger gives the logger of each call, when set by ContextLogger
+	contextLogger contextLogger
+	// interner holds the paths of other Unmarshalers to share, when given by a Registry
+	interner *interner
+	// cacheSize is how many resolutions are cached, 0 if disabled
+	cacheSize cacheSize
+	// cache is the one given with UseCache
+	cache ResolutionCache
+	// builtPaths are the paths built by the Unmarshalers given to Merge
+	builtPaths builtPaths
+	// groups holds the name of the group of each grouped type
+	groups map[reflect.Type]string
+	// scanThreshold is the size above which payloads are resolved from their scanned keys, 0 if disabled
+	scanThreshold scanThreshold
+	// decompressionLimit is how large payloads can get once decompressed
+	decompressionLimit decompressionLimit
+	// rewriteHooks rewrite the payloads before they are decoded, by type
+	rewriteHooks rewriteHooks
+	// normalizeHooks change the values once decoded, by type
+	normalizeHooks normalizeHooks
+	// tuples is set if there are candidates declared with Tuple
+	tuples bool
+	// migrations turn decoded values into later generations of their types
+	migrations migrations
+	// redactHooks decide which paths of the payloads are redacted, on top of the fields tagged with redact
+	redactHooks []redactHook
+
+	// resolverFactory builds the resolver instead of fingerprinting, when set by UseResolver
+	resolverFactory ResolverFactory
+	resolverName    resolverName
 }
 
 func newEnv(params []Parameter) (environment, error) {
 	env := environment{
-		settings: make(settings),
+		settings:        make(settings),
+		format:          jsonFormat,
+		implementations: make(map[reflect.Type]*implementations),
+		migrations:      make(migrations),
+		rewriteHooks:    make(rewriteHooks),
+		normalizeHooks:  make(normalizeHooks),
+		groups:          make(map[reflect.Type]string),
 	}
 
 	for _, p := range params {
-		if p == nil {
-			return environment{}, errors.New("only one default type can be used at a time")
-		}
-
-		switch param := p.(type) {
-		case *selector:
-			env.selectors = append(env.selectors, param)
-		case *candidate:
-			env.candidates = append(env.candidates, param)
-		case setting:
-			env.settings[param] = true
-		case *fallback:
-			if env.fallback != nil {
-				return environment{}, errors.New("only one default type can be used at a time")
+		err := env.add(p)
+		if err != nil {
+			return environment{}, err
+		}
+	}
+
+	if env.tagKey != "" {
+		env.format = env.format.withTagKey(string(env.tagKey))
+	}
+
+	if env.versionField == "" {
+		env.versionField = defaultVersionField
+	}
+
+	if env.naming == 0 {
+		env.naming = FoldCase
+	}
+
+	if env.decompressionLimit == 0 {
+		env.decompressionLimit = defaultDecompressionLimit
+	}
+
+	env.coerce = coercionsOf(env.settings)
+
+	if env.settings.Get(strictMatching) {
+		for _, c := range env.candidates {
+			err := c.makeStrict()
+			if err != nil {
+				return environment{}, err
 			}
+		}
+	}
 
-			env.fallback = param
-		default:
-			return environment{}, fmt.Errorf("invalid parameter type '%s'", reflect.TypeOf(param).Name())
+	for _, c := range env.candidates {
+		if c.version != nil {
+			c.version.path = string(env.versionField)
 		}
 	}
 
 	if len(env.candidates) == 0 {
-		return environment{}, errors.New("at least one candidate must be defined")
+		return environment{}, ErrNoCandidates
+	}
+
+	err := env.migrations.check()
+	if err != nil {
+		return environment{}, err
+	}
+
+	err = validateCandidates(env.candidates, env)
+	if err != nil {
+		return environment{}, err
 	}
 
-	if env.settings.Get(enableVerbose) {
+	for _, c := range env.candidates {
+		sel, err := c.whenSelector()
+		if err != nil {
+			return environment{}, fmt.Errorf("%w %s: %w", ErrInvalidCandidate, c.typ, err)
+		}
+
+		if sel != nil {
+			env.selectors = append(env.selectors, sel)
+			c.routed = true
+		}
+
+		if c.tuple {
+			c.mapped = c.tupleFields(env.format)
+			env.tuples = true
+		}
+
+		if len(c.mappings) == 0 {
+			continue
+		}
+
+		c.mapped, err = c.mapFields(env.format)
+		if err != nil {
+			return environment{}, fmt.Errorf("%w %s: %w", ErrInvalidCandidate, c.typ, err)
+		}
+	}
+
+	for iface, impls := range env.implementations {
+		err = validateCandidates(impls.candidates, env)
+		if err != nil {
+			return environment{}, fmt.Errorf("implementations of %s: %w", iface, err)
+		}
+	}
+
+	switch {
+	case env.logger != nil:
+		// Given with WithLogger
+	case env.settings.Get(enableVerbose):
 		env.logger = zap.Must(zap.NewDevelopment()).Sugar().Named("turnip")
-		return env, nil
+	default:
+		env.logger = zap.NewNop().Sugar()
+	}
+
+	if env.sampling != nil {
+		env.sampler = newLogSampler(env.sampling)
+		env.logger = env.logger.WithOptions(zap.WrapCore(env.sampler.wrap))
 	}
 
-	env.logger = zap.NewNop().Sugar()
 	return env, nil
 }
 
+// add takes a single parameter into the environment
+func (env *environment) add(p Parameter) error {
+	if p == nil {
+		return &ParameterError{Err: ErrInvalidParameter, Reason: "nil parameter"}
+	}
+
+	switch param := p.(type) {
+	case *selector:
+		if param.cond == nil {
+			return invalidParameter(param, "nil condition")
+		}
+
+		if param.typ == nil {
+			return invalidParameter(param, "nil type")
+		}
+
+		err := param.cond.compile()
+		if err != nil {
+			return invalidParameter(param, "%s", err)
+		}
+
+		env.selectors = append(env.selectors, param)
+	case *candidate:
+		// Candidates are completed with the rest of the environment later on. Working on a copy keeps the parameter
+		// free to be given to other Unmarshalers, even concurrently
+		c := *param
+		if (param.strict || param.tuple) && len(param.mappings) > 0 {
+			return invalidParameter(param, "fields of strict or tuple candidates can't be mapped")
+		}
+
+		if param.dynamic != nil {
+			err := param.dynamic.validate()
+			if err != nil {
+				return invalidParameter(param, "%s", err)
+			}
+		}
+
+		if slices.ContainsFunc(param.matchers, func(m func(gjson.Result) bool) bool { return m == nil }) {
+			return invalidParameter(param, "nil matcher for %s", param.typ)
+		}
+
+		c.queries = slices.Clone(param.queries)
+		for i, q := range param.queries {
+			if q == "" {
+				return invalidParameter(param, "empty query for %s", param.typ)
+			}
+
+			if param.engine != "" {
+				query, err := compileQuery(param.engine, q)
+				if err != nil {
+					return invalidParameter(param, "query for %s: %s", param.typ, err)
+				}
+
+				c.lookups = append(c.lookups, query)
+				continue
+			}
+
+			var err error
+			c.queries[i], err = gjsonPath(q)
+			if err != nil {
+				return invalidParameter(param, "query for %s: %s", param.typ, err)
+			}
+		}
+
+		if param.version != nil {
+			version := *param.version
+			err := version.compile()
+			if err != nil {
+				return invalidParameter(param, "version of %s: %s", param.typ, err)
+			}
+
+			c.version = &version
+		}
+
+		env.candidates = append(env.candidates, &c)
+	case *candidateMatcher:
+		return invalidParameter(param, "matchers can only be given to candidates, not %T", param.of)
+	case *fieldMapping:
+		return invalidParameter(param, "fields can only be mapped for candidates, not %T", param.of)
+	case versionField:
+		if env.versionField != "" {
+			return duplicateParameter(param)
+		}
+
+		path, err := gjsonPath(string(param))
+		if err != nil {
+			return invalidParameter(param, "%s", err)
+		}
+
+		env.versionField = versionField(path)
+	case fuzzyThreshold:
+		if env.fuzzyThreshold != 0 {
+			return duplicateParameter(param)
+		}
+
+		err := param.validate()
+		if err != nil {
+			return invalidParameter(param, "%s", err)
+		}
+
+		env.fuzzyThreshold = param
+	case Naming:
+		if env.naming != 0 {
+			return duplicateParameter(param)
+		}
+
+		if !param.valid() {
+			return invalidParameter(param, "unknown naming %s", param)
+		}
+
+		env.naming = param
+	case setting:
+		env.settings[param] = true
+	case *fallback:
+		if param.typ == nil {
+			return invalidParameter(param, "nil type")
+		}
+
+		env.fallbacks = append(env.fallbacks, param)
+	case tagKey:
+		if env.tagKey != "" {
+			return duplicateParameter(param)
+		}
+
+		if param == "" {
+			return invalidParameter(param, "key can't be empty")
+		}
+
+		env.tagKey = param
+	case formatName:
+		if env.formatName != "" {
+			return duplicateParameter(param)
+		}
+
+		f, err := lookupFormat(param)
+		if err != nil {
+			return invalidParameter(param, "%s", err)
+		}
+
+		env.format = f
+		env.formatName = param
+	case resolverName:
+		if env.resolverName != "" {
+			return duplicateParameter(param)
+		}
+
+		factory, err := lookupResolver(param)
+		if err != nil {
+			return invalidParameter(param, "%s", err)
+		}
+
+		env.resolverFactory = factory
+		env.resolverName = param
+	case *givenResolver:
+		if env.resolverName != "" {
+			return duplicateParameter(param)
+		}
+
+		if param.r == nil {
+			return invalidParameter(param, "nil resolver")
+		}
+
+		env.resolverFactory = func([]reflect.Type) (Resolver, error) {
+			return param.r, nil
+		}
+		env.resolverName = resolverName(param.Name())
+	case *mapstructureBackend:
+		if env.mapstructure != nil {
+			return duplicateParameter(param)
+		}
+
+		env.mapstructure = param
+	case observer:
+		if param == nil {
+			return invalidParameter(param, "nil function")
+		}
+
+		env.observers = append(env.observers, param)
+	case warner:
+		if param == nil {
+			return invalidParameter(param, "nil function")
+		}
+
+		env.warners = append(env.warners, param)
+	case *deprecation:
+		return invalidParameter(param, "only candidates can be deprecated, not %T", param.of)
+	case recentSize:
+		if env.recentSize != 0 {
+			return duplicateParameter(param)
+		}
+
+		if param <= 0 {
+			return invalidParameter(param, "size must be positive, not %d", int(param))
+		}
+
+		env.recentSize = param
+	case *logSampling:
+		if env.sampling != nil {
+			return duplicateParameter(param)
+		}
+
+		if param.every < 0 || param.perSecond < 0 {
+			return invalidParameter(param, "limits can't be negative")
+		}
+
+		env.sampling = param
+	case contextLogger:
+		if param == nil {
+			return invalidParameter(param, "nil function")
+		}
+
+		if env.contextLogger != nil {
+			return duplicateParameter(param)
+		}
+
+		env.contextLogger = param
+	case sharedInterner:
+		env.interner = param.in
+	case *group:
+		return env.addGroup(param, "")
+	case builtPaths:
+		if env.builtPaths == nil {
+			env.builtPaths = make(builtPaths, len(param))
+		}
+
+		maps.Copy(env.builtPaths, param)
+	case cacheSize:
+		if env.cacheSize != 0 || env.cache != nil {
+			return duplicateParameter(param)
+		}
+
+		if param <= 0 {
+			return invalidParameter(param, "size must be positive, not %d", int(param))
+		}
+
+		env.cacheSize = param
+	case *cacheParameter:
+		if env.cacheSize != 0 || env.cache != nil {
+			return duplicateParameter(param)
+		}
+
+		if param.cache == nil {
+			return invalidParameter(param, "nil cache")
+		}
+
+		if lru, ok := param.cache.(*lruCache); ok && lru.size <= 0 {
+			return invalidParameter(param, "size must be positive, not %d", lru.size)
+		}
+
+		env.cache = param.cache
+	case scanThreshold:
+		if env.scanThreshold != 0 {
+			return duplicateParameter(param)
+		}
+
+		if param <= 0 {
+			return invalidParameter(param, "size must be positive, not %d", int(param))
+		}
+
+		env.scanThreshold = param
+	case decompressionLimit:
+		if env.decompressionLimit != 0 {
+			return duplicateParameter(param)
+		}
+
+		if param <= 0 {
+			return invalidParameter(param, "size must be positive, not %d", int64(param))
+		}
+
+		env.decompressionLimit = param
+	case *rewriteHook:
+		if param.typ == nil {
+			return invalidParameter(param, "nil type")
+		}
+
+		if param.rewrite == nil {
+			return invalidParameter(param, "nil function")
+		}
+
+		env.rewriteHooks[param.typ] = append(env.rewriteHooks[param.typ], param)
+	case *normalizeHook:
+		if param.typ == nil {
+			return invalidParameter(param, "nil type")
+		}
+
+		if param.normalize == nil {
+			return invalidParameter(param, "nil function")
+		}
+
+		env.normalizeHooks[param.typ] = append(env.normalizeHooks[param.typ], param)
+	case *migration:
+		if !param.valid {
+			return invalidParameter(param, "nil function")
+		}
+
+		if existing, ok := env.migrations[param.from]; ok {
+			return invalidParameter(param, "%s is already migrated to %s", param.from, existing.to)
+		}
+
+		env.migrations[param.from] = param
+	case redactHook:
+		if param == nil {
+			return invalidParameter(param, "nil function")
+		}
+
+		env.redactHooks = append(env.redactHooks, param)
+	case *validation:
+		if env.validation != nil {
+			return duplicateParameter(param)
+		}
+
+		env.validation = param
+	case Option:
+		err := param(env)
+		var paramErr *ParameterError
+		if errors.As(err, &paramErr) {
+			// From a parameter added by the option, which is more specific
+			return err
+		}
+
+		if err != nil {
+			return invalidParameter(param, "%s", err)
+		}
+	case *implementations:
+		err := param.validate()
+		if err != nil {
+			return invalidParameter(param, "%s", err)
+		}
+
+		if existing, ok := env.implementations[param.iface]; ok {
+			existing.candidates = append(existing.candidates, param.candidates...)
+			return nil
+		}
+
+		// A copy, since more implementations may be appended to it
+		impls := *param
+		impls.candidates = slices.Clone(param.candidates)
+		env.implementations[param.iface] = &impls
+	default:
+		return invalidParameter(param, "unknown parameter type %T", param)
+	}
+
+	return nil
+}
+
 type settings map[setting]bool
 
 func (s settings) Get(setting setting) bool {
@@ -68,52 +549,340 @@ func (s settings) Get(setting setting) bool {
 	return ok && v
 }
 
-func Candidate(v any) Parameter {
+// names returns the names of the settings enabled, sorted
+func (s settings) names() []string {
+	names := make([]string, 0, len(s))
+	for setting, enabled := range s {
+		if enabled {
+			names = append(names, setting.String())
+		}
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// Candidate declares a type payloads can be decoded into, told apart from the other candidates by its fields. Routing
+// rules can also live on the struct, with the when option of the turnip tag on any of its fields, blank ones included:
+//
+//	type User struct {
+//		_    struct{} `turnip:"when=type==user"`
+//		Name string   `json:"name"`
+//	}
+//
+// Each rule is a path, a comparison and a literal, like "type==user" or "/meta/version>=2", or only a path to check the
+// value exists. Literals are read as JSON when they can be, and as strings otherwise, with single quotes forcing a
+// string as in "version=='2'". Rules can't hold commas, and when several fields have one all of them must match. They
+// route payloads like SelectWhen does, after the selectors given to New, so the candidate is reachable even if its
+// fields can't tell it apart
+func Candidate(v any, opts ...DecodeOption) Parameter {
 	return &candidate{
-		typ: reflect.TypeOf(v),
+		typ:    reflect.TypeOf(v),
+		decode: newDecodeOptions(opts),
+	}
+}
+
+// CandidateOf is Candidate taking the type as a type parameter, which reads better for instantiated generic types:
+// CandidateOf[Envelope[UserEvent]]()
+func CandidateOf[T any](opts ...DecodeOption) Parameter {
+	return &candidate{
+		typ:    reflect.TypeOf((*T)(nil)).Elem(),
+		decode: newDecodeOptions(opts),
+	}
+}
+
+// Strict declares a candidate that only matches payloads having every one of its paths, with the right types, and no
+// fields it doesn't know about. Payloads resolved to it are also decoded rejecting unknown fields. Other candidates
+// keep matching as usual, so strict and loose candidates can be mixed
+func Strict(v any, opts ...DecodeOption) Parameter {
+	c := &candidate{
+		typ:    reflect.TypeOf(v),
+		strict: true,
+		decode: newDecodeOptions(opts),
+	}
+
+	c.decode.disallowUnknownFields = true
+	return c
+}
+
+// Fingerprint declares a candidate told apart by gjson queries instead of by its fields. It matches payloads where
+// every query finds something, and is checked before the candidates fingerprinted by their fields:
+//
+//	turnip.Fingerprint(Order{}, `items.#(sku%"AB-*")`)
+//
+// Queries starting with a slash are JSON Pointers instead, like "/items/0/sku"
+func Fingerprint(v any, queries ...string) Parameter {
+	return &candidate{
+		typ:       reflect.TypeOf(v),
+		queries:   queries,
+		byQueries: true,
+	}
+}
+
+// FingerprintWith is Fingerprint with expressions in the syntax of the path engine registered as engine, like
+// JSONPath:
+//
+//	turnip.FingerprintWith("jsonpath", Order{}, "$.items[?(@.sku == 'AB-1')]")
+func FingerprintWith(engine string, v any, exprs ...string) Parameter {
+	return &candidate{
+		typ:       reflect.TypeOf(v),
+		queries:   exprs,
+		engine:    engine,
+		byQueries: true,
 	}
 }
 
 type candidate struct {
 	typ reflect.Type
+	// version is set for candidates declared with Version
+	version *versionConstraint
+	// strict is set for candidates declared with Strict
+	strict bool
+	decode decodeOptions
+	// dynamic is set for candidates declared with Dynamic, whose type is made up
+	dynamic *dynamicCandidate
+	// queries are set for candidates declared with Fingerprint, and replace the paths. With FingerprintWith, they are
+	// expressions of the path engine, compiled into lookups
+	queries []string
+	engine  string
+	lookups []PathQuery
+	// byQueries is set for candidates declared with Fingerprint, which can also be told apart by matchers alone
+	byQueries bool
+	// routed is set for candidates with when options in their tags, which are reachable through them even if they
+	// can't be told apart by their fields
+	routed bool
+	// deprecated is the reason given to Deprecated, empty if the candidate isn't
+	deprecated string
+	// matchers are given by Match, and must all hold on top of the rest
+	matchers []func(res gjson.Result) bool
+	// mappings are set by MapField, and resolved into mapped once the format is known
+	mappings []*fieldMapping
+	mapped   []mappedField
+	// tuple is set for candidates declared with Tuple, whose fields are mapped to positions
+	tuple bool
+}
+
+func (c *candidate) String() string {
+	if c.dynamic != nil {
+		return c.dynamic.name
+	}
+
+	return c.typ.String()
 }
 
 func (c *candidate) Name() string {
 	return "Candidate"
 }
 
+// custom reports whether the candidate is told apart by queries or matchers instead of by its paths
+func (c *candidate) custom() bool {
+	return c.byQueries && (len(c.queries) > 0 || len(c.matchers) > 0)
+}
+
+// matched reports whether every matcher of the candidate holds for res
+func (c *candidate) matched(res gjson.Result) bool {
+	for _, match := range c.matchers {
+		if !match(res) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// queried reports whether every query of the candidate finds something in res
+func (c *candidate) queried(res gjson.Result) bool {
+	if c.engine != "" {
+		for _, query := range c.lookups {
+			if len(query(res)) == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	for _, q := range c.queries {
+		if !res.Get(q).Exists() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SelectOn routes payloads where the value at field equals equal to the type of then, regardless of fingerprints.
+// equal can also be a Predicate, like GTE(2), in which case the value must satisfy it instead
 func SelectOn(field string, equal any, then any) Parameter {
-	return &selector{}
+	if pred, ok := equal.(Predicate); ok {
+		return SelectWhen(Where(field, pred), then)
+	}
+
+	return SelectWhen(Eq(field, equal), then)
+}
+
+// SelectWhen routes payloads matching the condition to the type of then, regardless of fingerprints. Selectors are
+// checked in the order they were given, and before any candidate
+func SelectWhen(cond Condition, then any) Parameter {
+	return &selector{
+		cond: cond,
+		typ:  reflect.TypeOf(then),
+	}
 }
 
 type selector struct {
+	cond Condition
+	typ  reflect.Type
 }
 
 func (c *selector) Name() string {
 	return "Selector"
 }
 
+// Default decodes payloads that match no selector nor candidate into the type of v. Defaults can be given more than
+// once to build a chain, in which case each one is tried in order until the payload decodes without errors
 func Default(v any) Parameter {
-	return &fallback{}
+	return &fallback{
+		typ: reflect.TypeOf(v),
+	}
 }
 
 type fallback struct {
+	typ reflect.Type
 }
 
 func (c *fallback) Name() string {
 	return "Fallback"
 }
 
+// TagKey makes the names used for fingerprinting come from the given struct tag. Fields without it fall back to the
+// usual tags, json for JSON
+func TagKey(key string) Parameter {
+	return tagKey(key)
+}
+
+type tagKey string
+
+func (t tagKey) Name() string {
+	return "TagKey"
+}
+
+// FuzzyMatch lets payloads that match no candidate exactly still resolve to the candidate with the highest share of
+// its paths present with the right type, as long as that share is at least percent. Ties go to the candidate declared
+// first. Strict candidates and those that can't be told apart never match this way
+func FuzzyMatch(percent float64) Parameter {
+	return fuzzyThreshold(percent)
+}
+
+type fuzzyThreshold float64
+
+func (f fuzzyThreshold) Name() string {
+	return "FuzzyMatch"
+}
+
+// validate checks that the threshold is a percentage in (0, 100], which NaN fails every comparison with
+func (f fuzzyThreshold) validate() error {
+	if math.IsNaN(float64(f)) || f <= 0 || f > 100 {
+		return fmt.Errorf("threshold must be in (0, 100], not %v", float64(f))
+	}
+
+	return nil
+}
+
 func EnableDebug() Parameter {
 	return enableVerbose
 }
 
+// EnableLazyInit defers building the fingerprints until the first call to UnmarshalJSON. Resolver errors that would
+// be returned by New are then returned by that first call instead
+func EnableLazyInit() Parameter {
+	return enableLazyInit
+}
+
+// EnableLenient skips fields that can't be fingerprinted, like channels, functions and interfaces, instead of failing.
+// Those fields are still decoded as usual. Candidates that can't be told apart from others are also tolerated, and
+// logged as a warning instead
+func EnableLenient() Parameter {
+	return enableLenient
+}
+
+// FallbackToMap returns payloads that match nothing, not even the defaults, decoded into a map[string]any instead of
+// failing with ErrNoMatch
+func FallbackToMap() Parameter {
+	return fallbackToMap
+}
+
+// EnableJSONC accepts comments and trailing commas in payloads, as found in configuration files written by hand. They
+// are stripped before resolving and decoding
+func EnableJSONC() Parameter {
+	return enableJSONC
+}
+
+// CoerceQuotedNumbers accepts numbers written as strings, like "42", for numeric fields. Those fields match such values
+// when fingerprinting, and they are decoded as the number they hold
+func CoerceQuotedNumbers() Parameter {
+	return coerceQuotedNumbers
+}
+
+// CoerceNumbersToStrings accepts numbers for string fields, which match them when fingerprinting and get their text
+// when decoding, 42 becoming "42".
+//
+// Coercions make types less telling, so candidates only told apart by the type of a coerced field may both match a
+// payload, in which case the first one declared wins
+func CoerceNumbersToStrings() Parameter {
+	return coerceNumbersToStrings
+}
+
+// CoerceBooleans accepts the booleans of weakly typed APIs for boolean fields: the strings "true" and "false", and the
+// numbers 1 and 0. Those fields match such values when fingerprinting, and they are decoded as the boolean they stand
+// for
+func CoerceBooleans() Parameter {
+	return coerceBooleans
+}
+
 type setting uint
 
 const (
 	enableVerbose setting = iota
+	enableLazyInit
+	enableLenient
+	fallbackToMap
+	enableJSONC
+	coerceQuotedNumbers
+	coerceNumbersToStrings
+	coerceBooleans
+	enableDecompression
+	strictMatching
 )
 
 func (s setting) Name() string {
 	return "Setting"
 }
+
+// String is the name of the function enabling the setting
+func (s setting) String() string {
+	switch s {
+	case enableVerbose:
+		return "EnableDebug"
+	case enableLazyInit:
+		return "EnableLazyInit"
+	case enableLenient:
+		return "EnableLenient"
+	case fallbackToMap:
+		return "FallbackToMap"
+	case enableJSONC:
+		return "EnableJSONC"
+	case coerceQuotedNumbers:
+		return "CoerceQuotedNumbers"
+	case coerceNumbersToStrings:
+		return "CoerceNumbersToStrings"
+	case coerceBooleans:
+		return "CoerceBooleans"
+	case enableDecompression:
+		return "EnableDecompression"
+	case strictMatching:
+		return "StrictMatching"
+	default:
+		return fmt.Sprintf("Setting(%d)", uint(s))
+	}
+}
@@ -0,0 +1,127 @@
+package turnip
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type fallbackOrder struct {
+	ID string `json:"id"`
+}
+
+type fallbackCounter struct {
+	Count int `json:"count"`
+}
+
+type fallbackNote struct {
+	Count string `json:"count"`
+}
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "candidate first",
+			params:  []Parameter{Default(fallbackCounter{})},
+			payload: `{"id":"a"}`,
+			want:    &fallbackOrder{ID: "a"},
+		},
+		{
+			name:    "default",
+			params:  []Parameter{Default(fallbackCounter{})},
+			payload: `{"count":1}`,
+			want:    &fallbackCounter{Count: 1},
+		},
+		{
+			name:    "next default",
+			params:  []Parameter{Default(fallbackCounter{}), Default(fallbackNote{})},
+			payload: `{"count":"one"}`,
+			want:    &fallbackNote{Count: "one"},
+		},
+		{
+			name:    "defaults in order",
+			params:  []Parameter{Default(fallbackNote{}), Default(fallbackCounter{})},
+			payload: `{"other":1}`,
+			want:    &fallbackNote{},
+		},
+		{
+			name:    "no default decoding",
+			params:  []Parameter{Default(fallbackCounter{})},
+			payload: `{"count":"one"}`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name:    "map",
+			params:  []Parameter{FallbackToMap()},
+			payload: `{"count":1,"tags":["a"]}`,
+			want:    map[string]any{"count": float64(1), "tags": []any{"a"}},
+		},
+		{
+			name:    "defaults before the map",
+			params:  []Parameter{FallbackToMap(), Default(fallbackCounter{})},
+			payload: `{"count":1}`,
+			want:    &fallbackCounter{Count: 1},
+		},
+		{
+			name:    "map after the defaults",
+			params:  []Parameter{Default(fallbackCounter{}), FallbackToMap()},
+			payload: `{"count":"one"}`,
+			want:    map[string]any{"count": "one"},
+		},
+		{
+			name:    "no defaults",
+			payload: `{"count":1}`,
+			wantErr: ErrNoMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(append(tt.params, Candidate(fallbackOrder{}))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		percent float64
+		wantErr error
+	}{
+		{"lowest", 0.5, nil},
+		{"highest", 100, nil},
+		{"zero", 0, ErrInvalidParameter},
+		{"negative", -10, ErrInvalidParameter},
+		{"over a hundred", 100.5, ErrInvalidParameter},
+		{"infinite", math.Inf(1), ErrInvalidParameter},
+		{"not a number", math.NaN(), ErrInvalidParameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Candidate(fallbackOrder{}), FuzzyMatch(tt.percent))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package turnip
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Peek resolves the payload like UnmarshalJSON, but only fills the fields at the fingerprint paths of the candidate,
+// straight from the payload, leaving the rest zero. It's a cheap look at the few fields that told the candidate apart,
+// for routers that forward the payload as is. Types resolved by a selector are returned zero, and payloads that need a
+// default fail with ErrNoMatch, since there are no fingerprints to fill
+func (u *Unmarshaler) Peek(b []byte) (any, error) {
+	return u.load().peek(b)
+}
+
+func (u *unmarshaler) peek(b []byte) (any, error) {
+	_, res, err := u.parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	typ, err := u.resolve(&query{res: res})
+	if err != nil {
+		return nil, fmt.Errorf("%w: resolve: %w", ErrInternal, err)
+	}
+
+	if typ == nil {
+		return nil, ErrNoMatch
+	}
+
+	v := reflect.New(typ)
+
+	r, ok := u.resolver.(*traverseResolver)
+	if !ok {
+		return v.Interface(), nil
+	}
+
+	paths := r.fingerprintPaths(typ)
+	if len(paths) == 0 {
+		return v.Interface(), nil
+	}
+
+	err = project(v.Elem(), "", paths, res, u.env.format, u.env.naming)
+	if err != nil {
+		return nil, fmt.Errorf("peek: %w", err)
+	}
+
+	return v.Interface(), nil
+}
+
+// fingerprintPaths returns the fingerprint paths of the candidate of type typ, if there's one
+func (r *traverseResolver) fingerprintPaths(typ reflect.Type) jsonPaths {
+	for _, fp := range r.fingerprints {
+		if fp.candidate.typ == typ {
+			return fp.paths
+		}
+	}
+
+	return nil
+}
+
+// project decodes the values at paths into the matching fields of the struct v, and nothing else
+func project(v reflect.Value, curr string, paths jsonPaths, res gjson.Result, format *format, naming Naming) error {
+	for _, f := range wireFields(v.Type(), format) {
+		path := appendToPath(curr, f.name, naming)
+
+		typ, wanted := paths[path]
+		if !wanted && !hasPathUnder(paths, path) {
+			continue
+		}
+
+		fv := fieldByIndex(v, f.index)
+		if !fv.IsValid() {
+			continue
+		}
+
+		if wanted {
+			// Any-of and fuzzy fingerprints match without every path, and like a null, a missing value is left zero
+			val := typ.get(res, path)
+			if !val.Exists() {
+				continue
+			}
+
+			raw := val.Raw
+			if f.quoted && val.Type == gjson.String {
+				raw = val.Str
+			}
+
+			err := json.Unmarshal([]byte(raw), fv.Addr().Interface())
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+
+			continue
+		}
+
+		for fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+
+			fv = fv.Elem()
+		}
+
+		if fv.Kind() != reflect.Struct {
+			continue
+		}
+
+		err := project(fv, path, paths, res, format, naming)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+func hasPathUnder(paths jsonPaths, prefix string) bool {
+	prefix += "."
+	for path := range paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,141 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type peekedOrder struct {
+	ID    string  `json:"id"`
+	Total float64 `json:"total"`
+	Note  string  `json:"note"`
+}
+
+type peekedRefund struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+	Note   string `json:"note"`
+}
+
+type peekedQuoted struct {
+	Count int    `json:"count,string"`
+	Label string `json:"label"`
+}
+
+type peekedMeta struct {
+	Source string `json:"source"`
+}
+
+type peekedEmbedded struct {
+	peekedMeta
+	Kind string `json:"kind"`
+}
+
+type peekedCustomer struct {
+	Tier string `json:"tier"`
+}
+
+type peekedNested struct {
+	Customer *peekedCustomer `json:"customer"`
+	Name     string          `json:"name"`
+}
+
+type peekedSingle struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type peekedKind struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type peekedFuzzy struct {
+	A int `json:"a"`
+	B int `json:"b"`
+	C int `json:"c"`
+	D int `json:"d"`
+}
+
+func TestPeek(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "fingerprint only",
+			params:  []Parameter{Candidate(peekedOrder{}), Candidate(peekedRefund{})},
+			payload: `{"id":"a","total":9.5,"note":"x"}`,
+			want:    &peekedOrder{Total: 9.5},
+		},
+		{
+			name:    "any of",
+			params:  []Parameter{Candidate(peekedSingle{})},
+			payload: `{"name":"ada"}`,
+			want:    &peekedSingle{Name: "ada"},
+		},
+		{
+			name:    "fuzzy",
+			params:  []Parameter{Candidate(peekedFuzzy{}), Candidate(peekedOrder{}), FuzzyMatch(50)},
+			payload: `{"b":2,"c":3,"d":4}`,
+			want:    &peekedFuzzy{},
+		},
+		{
+			name:    "null",
+			params:  []Parameter{Candidate(peekedSingle{})},
+			payload: `{"id":null,"name":"ada"}`,
+			want:    &peekedSingle{Name: "ada"},
+		},
+		{
+			name:    "quoted",
+			params:  []Parameter{Candidate(peekedQuoted{}), Candidate(peekedOrder{})},
+			payload: `{"count":"3","label":"x"}`,
+			want:    &peekedQuoted{Count: 3},
+		},
+		{
+			name:    "embedded",
+			params:  []Parameter{Candidate(peekedEmbedded{}), Candidate(peekedKind{})},
+			payload: `{"source":"web","kind":"x"}`,
+			want:    &peekedEmbedded{peekedMeta: peekedMeta{Source: "web"}},
+		},
+		{
+			name:    "nested",
+			params:  []Parameter{Candidate(peekedNested{}), Candidate(peekedKind{})},
+			payload: `{"customer":{"tier":"gold"},"name":"ada"}`,
+			want:    &peekedNested{Customer: &peekedCustomer{Tier: "gold"}},
+		},
+		{
+			name:    "no match",
+			params:  []Parameter{Candidate(peekedOrder{}), Candidate(peekedRefund{})},
+			payload: `{"other":1}`,
+			wantErr: ErrNoMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got, err := u.Peek([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Peek() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Peek() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
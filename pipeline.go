@@ -0,0 +1,181 @@
+package turnip
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Result is the outcome of unmarshaling one of the payloads of a pipeline
+type Result struct {
+	// Seq is the position of the payload in the input, starting at 0
+	Seq   int
+	Value any
+	Err   error
+}
+
+// PipelineOption changes how a pipeline runs
+type PipelineOption func(o *pipelineOptions)
+
+type pipelineOptions struct {
+	ordered bool
+}
+
+// Ordered makes the pipeline return results in the same order the payloads came in. A slow payload then holds back the
+// ones after it, up to the number of workers
+func Ordered() PipelineOption {
+	return func(o *pipelineOptions) {
+		o.ordered = true
+	}
+}
+
+type pipelineJob struct {
+	seq  int
+	b    []byte
+	done chan Result
+}
+
+// Pipeline unmarshals the payloads coming from in with as many workers as given, which is the building block for queue
+// consumers. Payloads failing to unmarshal are reported in their Result, and don't stop the pipeline.
+//
+// Results are closed once in is closed and every payload is done, or the context is canceled. In the latter case, the
+// cause is sent on the error channel before closing it. The context is also the one given to ContextLogger
+func (u *Unmarshaler) Pipeline(ctx context.Context, in <-chan []byte, workers int,
+	opts ...PipelineOption) (<-chan Result, <-chan error) {
+	var o pipelineOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	out := make(chan Result)
+	errs := make(chan error, 1)
+	jobs := make(chan pipelineJob)
+
+	// Jobs waiting for their turn to be sent out, when ordered
+	var pending chan chan Result
+	if o.ordered {
+		pending = make(chan chan Result, workers)
+	}
+
+	go func() {
+		defer close(jobs)
+		if pending != nil {
+			defer close(pending)
+		}
+
+		for seq := 0; ; seq++ {
+			var b []byte
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case b, ok = <-in:
+				if !ok {
+					return
+				}
+			}
+
+			job := pipelineJob{seq: seq, b: b}
+			if pending != nil {
+				job.done = make(chan Result, 1)
+				select {
+				case <-ctx.Done():
+					return
+				case pending <- job.done:
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- job:
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				v, err := u.UnmarshalJSONContext(job.b, ResolveContext{Context: ctx})
+				r := Result{Seq: job.seq, Value: v, Err: err}
+				if job.done != nil {
+					job.done <- r
+					continue
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case out <- r:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(errs)
+		defer close(out)
+
+		if pending != nil {
+			for done := range pending {
+				var r Result
+				select {
+				case <-ctx.Done():
+				case r = <-done:
+				}
+
+				if ctx.Err() != nil {
+					break
+				}
+
+				select {
+				case <-ctx.Done():
+				case out <- r:
+				}
+			}
+		}
+
+		wg.Wait()
+
+		if err := ctx.Err(); err != nil {
+			errs <- err
+		}
+	}()
+
+	return out, errs
+}
+
+// UnmarshalBatch unmarshals the payloads concurrently, using as many workers as GOMAXPROCS. Values and errors are
+// aligned with the payloads, so the error of payloads[i] is errs[i], and nil if it succeeded
+func (u *Unmarshaler) UnmarshalBatch(payloads [][]byte) ([]any, []error) {
+	values := make([]any, len(payloads))
+	errs := make([]error, len(payloads))
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < min(runtime.GOMAXPROCS(0), len(payloads)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(payloads) {
+					return
+				}
+
+				values[i], errs[i] = u.UnmarshalJSON(payloads[i])
+			}
+		}()
+	}
+
+	wg.Wait()
+	return values, errs
+}
@@ -0,0 +1,146 @@
+package turnip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type pipelineItem struct {
+	N int `json:"n"`
+}
+
+// pipelinePayloads returns n payloads, every third of which fails
+func pipelinePayloads(n int) [][]byte {
+	payloads := make([][]byte, n)
+	for i := range payloads {
+		payloads[i] = []byte(fmt.Sprintf(`{"n":%d}`, i))
+		if i%3 == 2 {
+			payloads[i] = []byte(`"n"`)
+		}
+	}
+
+	return payloads
+}
+
+func TestPipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		opts    []PipelineOption
+	}{
+		{"one worker", 1, nil},
+		{"no workers", 0, nil},
+		{"several workers", 4, nil},
+		{"ordered", 4, []PipelineOption{Ordered()}},
+	}
+
+	u, err := New(Candidate(pipelineItem{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payloads := pipelinePayloads(50)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan []byte)
+			go func() {
+				defer close(in)
+				for _, b := range payloads {
+					in <- b
+				}
+			}()
+
+			out, errs := u.Pipeline(context.Background(), in, tt.workers, tt.opts...)
+			seen := make(map[int]bool)
+			for r := range out {
+				if len(tt.opts) > 0 && r.Seq != len(seen) {
+					t.Errorf("result %d came after %d results", r.Seq, len(seen))
+				}
+
+				seen[r.Seq] = true
+				if r.Seq%3 == 2 {
+					if !errors.Is(r.Err, ErrMalformed) {
+						t.Errorf("result %d error = %v, want %v", r.Seq, r.Err, ErrMalformed)
+					}
+
+					continue
+				}
+
+				if item, ok := r.Value.(*pipelineItem); !ok || item.N != r.Seq || r.Err != nil {
+					t.Errorf("result %d = %#v, %v", r.Seq, r.Value, r.Err)
+				}
+			}
+
+			if len(seen) != len(payloads) {
+				t.Errorf("got %d results, want %d", len(seen), len(payloads))
+			}
+
+			if err := <-errs; err != nil {
+				t.Errorf("Pipeline() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestPipelineCanceled(t *testing.T) {
+	u, err := New(Candidate(pipelineItem{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range [][]PipelineOption{nil, {Ordered()}} {
+		ctx, cancel := context.WithCancel(context.Background())
+		in := make(chan []byte)
+		out, errs := u.Pipeline(ctx, in, 2, opts...)
+
+		in <- []byte(`{"n":1}`)
+		cancel()
+		for range out {
+		}
+
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("Pipeline() error = %v, want %v", err, context.Canceled)
+		}
+	}
+}
+
+func TestUnmarshalBatch(t *testing.T) {
+	u, err := New(Candidate(pipelineItem{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		payloads [][]byte
+	}{
+		{"none", nil},
+		{"one", pipelinePayloads(1)},
+		{"many", pipelinePayloads(100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, errs := u.UnmarshalBatch(tt.payloads)
+			if len(values) != len(tt.payloads) || len(errs) != len(tt.payloads) {
+				t.Fatalf("UnmarshalBatch() = %d values, %d errors, want %d", len(values), len(errs), len(tt.payloads))
+			}
+
+			for i := range tt.payloads {
+				if i%3 == 2 {
+					if !errors.Is(errs[i], ErrMalformed) {
+						t.Errorf("error %d = %v, want %v", i, errs[i], ErrMalformed)
+					}
+
+					continue
+				}
+
+				if item, ok := values[i].(*pipelineItem); !ok || item.N != i || errs[i] != nil {
+					t.Errorf("value %d = %#v, %v", i, values[i], errs[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package turnip
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/tidwall/gjson"
+)
+
+// FormatSpec describes an input format contributed by another module, to be registered with RegisterFormat
+type FormatSpec struct {
+	Name string
+	// TagKeys are the struct tags naming the fields in this format, looked up in order
+	TagKeys []string
+	// PromoteEmbedded is set when the fields of untagged embedded structs are promoted to the parent, as encoding/json
+	// does
+	PromoteEmbedded bool
+	// Inline is set when the ",inline" tag option promotes the fields of a struct, embedded or not
+	Inline bool
+	// ToJSON converts a payload to JSON, which is what is resolved. If nil, payloads are expected to be JSON already
+	ToJSON func(b []byte) ([]byte, error)
+	// Unmarshal decodes the original payload into the resolved type. If nil, the JSON is decoded with encoding/json.
+	// Values it decodes get their defaults, validation, AfterDecode functions and migrations as any other, but
+	// BeforeDecode, MapField, aliases, coercions and word-splitting Namings rewrite the JSON, and so don't apply to them
+	Unmarshal func(b []byte, v any) error
+}
+
+// ResolverFactory builds a Resolver choosing between the given candidate types
+type ResolverFactory func(candidates []reflect.Type) (Resolver, error)
+
+// PathEngine compiles expressions of a path syntax other than gjson, like JSONPath, to be registered with
+// RegisterPathEngine. It's called once per expression, when the Unmarshaler is created
+type PathEngine func(expr string) (PathQuery, error)
+
+// PathQuery returns the values a compiled expression finds in a payload, none if it finds nothing
+type PathQuery func(res gjson.Result) []gjson.Result
+
+var plugins = struct {
+	sync.RWMutex
+	formats   map[string]*format
+	resolvers map[string]ResolverFactory
+	engines   map[string]PathEngine
+}{
+	formats: map[string]*format{
+		jsonFormat.name:    jsonFormat,
+		yamlFormat.name:    yamlFormat,
+		tomlFormat.name:    tomlFormat,
+		msgpackFormat.name: msgpackFormat,
+	},
+	resolvers: make(map[string]ResolverFactory),
+	engines: map[string]PathEngine{
+		"jsonpath": jsonPathEngine,
+	},
+}
+
+// RegisterFormat makes a format available to UseFormat by its name. Registering a name again replaces the previous
+// format, and only affects Unmarshalers built afterwards
+func RegisterFormat(spec FormatSpec) {
+	if spec.Name == "" {
+		panic("turnip: RegisterFormat with empty name")
+	}
+
+	plugins.Lock()
+	defer plugins.Unlock()
+
+	plugins.formats[spec.Name] = &format{
+		name:            spec.Name,
+		tagKeys:         append([]string(nil), spec.TagKeys...),
+		promoteEmbedded: spec.PromoteEmbedded,
+		inline:          spec.Inline,
+		toJSON:          spec.ToJSON,
+		unmarshal:       spec.Unmarshal,
+	}
+}
+
+// RegisterResolverFactory makes a resolution strategy available to UseResolver by its name. Registering a name again
+// replaces the previous factory, and only affects Unmarshalers built afterwards
+func RegisterResolverFactory(name string, factory ResolverFactory) {
+	if name == "" || factory == nil {
+		panic("turnip: RegisterResolverFactory with empty name or nil factory")
+	}
+
+	plugins.Lock()
+	defer plugins.Unlock()
+
+	plugins.resolvers[name] = factory
+}
+
+// RegisterPathEngine makes a path syntax available to Query and FingerprintWith by its name. "jsonpath" is registered
+// from the start, with the usual subset of JSONPath. Registering a name again replaces the previous engine, and only
+// affects Unmarshalers built afterwards
+func RegisterPathEngine(name string, engine PathEngine) {
+	if name == "" || engine == nil {
+		panic("turnip: RegisterPathEngine with empty name or nil engine")
+	}
+
+	plugins.Lock()
+	defer plugins.Unlock()
+
+	plugins.engines[name] = engine
+}
+
+// UseFormat makes the Unmarshaler work with payloads of a registered format, which are given to Unmarshal. Fields are
+// named by the tags of the format, falling back to json
+func UseFormat(name string) Parameter {
+	return formatName(name)
+}
+
+type formatName string
+
+func (f formatName) Name() string {
+	return "UseFormat"
+}
+
+// UseResolver resolves the candidates with a registered resolver instead of by their fingerprints. Selectors and
+// defaults still work as usual, but the rest is up to the resolver
+func UseResolver(name string) Parameter {
+	return resolverName(name)
+}
+
+type resolverName string
+
+func (r resolverName) Name() string {
+	return "UseResolver"
+}
+
+// ResolveWith is UseResolver for a resolver at hand instead of a registered one, like those built by the application
+// itself or the fakes of tests. It can't be used along with UseResolver
+func ResolveWith(r Resolver) Parameter {
+	return &givenResolver{r: r}
+}
+
+type givenResolver struct {
+	r Resolver
+}
+
+func (g *givenResolver) Name() string {
+	return "ResolveWith"
+}
+
+func lookupFormat(name formatName) (*format, error) {
+	plugins.RLock()
+	defer plugins.RUnlock()
+
+	f, ok := plugins.formats[string(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown format '%s'", string(name))
+	}
+
+	return f, nil
+}
+
+func lookupResolver(name resolverName) (ResolverFactory, error) {
+	plugins.RLock()
+	defer plugins.RUnlock()
+
+	factory, ok := plugins.resolvers[string(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown resolver '%s'", string(name))
+	}
+
+	return factory, nil
+}
+
+// compileQuery compiles expr with the path engine registered as engine
+func compileQuery(engine, expr string) (PathQuery, error) {
+	plugins.RLock()
+	compile, ok := plugins.engines[engine]
+	plugins.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown path engine '%s'", engine)
+	}
+
+	return compile(expr)
+}
+
+// pluginResolver checks the selectors before handing the payload to a registered resolver
+type pluginResolver struct {
+	env  environment
+	next Resolver
+}
+
+func newPluginResolver(env environment) (*pluginResolver, error) {
+	types := make([]reflect.Type, 0, len(env.candidates))
+	for _, c := range env.candidates {
+		types = append(types, c.typ)
+	}
+
+	next, err := env.resolverFactory(types)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", string(env.resolverName), err)
+	}
+
+	if next == nil {
+		return nil, fmt.Errorf("%s: nil resolver", string(env.resolverName))
+	}
+
+	return &pluginResolver{env: env, next: next}, nil
+}
+
+func (r *pluginResolver) ResolveJSON(res gjson.Result) (reflect.Type, error) {
+	return r.ResolveJSONContext(res, ResolveContext{})
+}
+
+func (r *pluginResolver) ResolveJSONContext(res gjson.Result, rc ResolveContext) (reflect.Type, error) {
+	q := &query{res: res, ctx: rc}
+	for _, s := range r.env.selectors {
+		if s.cond.matches(q) {
+			return s.typ, nil
+		}
+	}
+
+	if next, ok := r.next.(ContextResolver); ok {
+		return next.ResolveJSONContext(res, rc)
+	}
+
+	return r.next.ResolveJSON(res)
+}
@@ -0,0 +1,111 @@
+package turnip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type pluginAccount struct {
+	ID       string `json:"id" validate:"required"`
+	Role     string `json:"role" default:"member"`
+	Password string `json:"password" turnip:"redact"`
+}
+
+type pluginAccountV2 struct {
+	ID   string
+	Role string
+}
+
+func init() {
+	// Decodes JSON, but through the format's own Unmarshal, failing with the payload in the error when asked to
+	RegisterFormat(FormatSpec{
+		Name:    "plugintest",
+		TagKeys: []string{"json"},
+		ToJSON: func(b []byte) ([]byte, error) {
+			return b, nil
+		},
+		Unmarshal: func(b []byte, v any) error {
+			if strings.Contains(string(b), `"fail"`) {
+				return fmt.Errorf("can't decode %s", b)
+			}
+
+			return json.Unmarshal(b, v)
+		},
+	})
+}
+
+func TestFormatUnmarshalPipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			payload: `{"id":"a","password":"hunter2"}`,
+			want:    &pluginAccount{ID: "a", Role: "member", Password: "hunter2"},
+		},
+		{
+			name: "after decode",
+			params: []Parameter{AfterDecode(pluginAccount{}, func(v any) (any, error) {
+				v.(*pluginAccount).Role = strings.ToUpper(v.(*pluginAccount).Role)
+				return v, nil
+			})},
+			payload: `{"id":"a","role":"admin","password":"hunter2"}`,
+			want:    &pluginAccount{ID: "a", Role: "ADMIN", Password: "hunter2"},
+		},
+		{
+			name: "migrations",
+			params: []Parameter{Migrate(pluginAccount{}, pluginAccountV2{}, func(v *pluginAccount) *pluginAccountV2 {
+				return &pluginAccountV2{ID: v.ID, Role: v.Role}
+			})},
+			payload: `{"id":"a","password":"hunter2"}`,
+			want:    &pluginAccountV2{ID: "a", Role: "member"},
+		},
+		{
+			name:    "validation",
+			params:  []Parameter{UseValidator(nil)},
+			payload: `{"id":"","password":"hunter2"}`,
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "redaction",
+			payload: `{"id":"a","password":"hunter2","fail":true}`,
+			wantErr: ErrDecode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := append([]Parameter{Candidate(pluginAccount{}), UseFormat("plugintest")}, tt.params...)
+			u, err := New(params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.Unmarshal([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unmarshal() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				if strings.Contains(err.Error(), "hunter2") {
+					t.Errorf("Unmarshal() error = %v, want the password redacted", err)
+				}
+
+				return
+			}
+
+			want, _ := json.Marshal(tt.want)
+			have, _ := json.Marshal(got)
+			if fmt.Sprintf("%T", got) != fmt.Sprintf("%T", tt.want) || string(want) != string(have) {
+				t.Errorf("Unmarshal() = %T %s, want %T %s", got, have, tt.want, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package turnip
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isPointer reports whether path is a JSON Pointer (RFC 6901), like "/data/attributes/type", rather than a gjson path.
+// The empty pointer, for the whole payload, is never a path worth giving
+func isPointer(path string) bool {
+	return strings.HasPrefix(path, "/")
+}
+
+// pointerKeys returns the keys of the JSON Pointer p, unescaped
+func pointerKeys(p string) ([]string, error) {
+	keys := strings.Split(p[1:], "/")
+	for i, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("pointer %s: empty keys are not supported", p)
+		}
+
+		for j := 0; j < len(key); j++ {
+			if key[j] == '~' && (j+1 == len(key) || key[j+1] != '0' && key[j+1] != '1') {
+				return nil, fmt.Errorf("pointer %s: ~ must be followed by 0 or 1", p)
+			}
+		}
+
+		// ~1 first, so ~01 is ~1 and not /
+		keys[i] = strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")
+	}
+
+	return keys, nil
+}
+
+// gjsonPath returns path as a gjson path, converting it if it's a JSON Pointer. Paths that are not pointers are
+// returned as they are
+func gjsonPath(path string) (string, error) {
+	if !isPointer(path) {
+		return path, nil
+	}
+
+	keys, err := pointerKeys(path)
+	if err != nil {
+		return "", err
+	}
+
+	for i, key := range keys {
+		keys[i] = escapeKey(key)
+	}
+
+	return strings.Join(keys, "."), nil
+}
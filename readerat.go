@@ -0,0 +1,63 @@
+package turnip
+
+import (
+	"fmt"
+	"io"
+)
+
+// UnmarshalReaderAt is UnmarshalReader for documents that can be read at any offset, like files, which is where the
+// document is: the first size bytes of r. If the first prefix bytes are not enough to resolve it, as UnmarshalReader
+// tells, larger prefixes are read, doubling each time, instead of the whole document. Once resolved, the rest is
+// streamed into the decoder, so files of any size are decoded without holding them in memory.
+//
+// Documents that can't be resolved from any prefix, and those UnmarshalReader would read whole, are read whole too.
+// Compressed documents, with EnableDecompression, can only be read from the start, and so are left to UnmarshalReader
+func (u *Unmarshaler) UnmarshalReaderAt(r io.ReaderAt, size int64, prefix int) (any, error) {
+	return u.load().unmarshalReaderAt(r, size, prefix)
+}
+
+func (u *unmarshaler) unmarshalReaderAt(r io.ReaderAt, size int64, prefix int) (any, error) {
+	err := u.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	if u.settings.Get(enableDecompression) {
+		return u.unmarshalReader(io.NewSectionReader(r, 0, size), prefix)
+	}
+
+	for n := max(int64(prefix), 1); n < size; n *= 2 {
+		head := make([]byte, n)
+		_, err = r.ReadAt(head, 0)
+		if err != nil {
+			return nil, fmt.Errorf("read: %w", err)
+		}
+
+		if !u.canStream(head) {
+			break
+		}
+
+		typ, err := u.resolveHead(head)
+		if err != nil {
+			return nil, err
+		}
+
+		if typ == nil {
+			continue
+		}
+
+		if !u.streamsInto(typ) {
+			break
+		}
+
+		return u.streamRest(head, io.NewSectionReader(r, n, size-n), typ)
+	}
+
+	b := make([]byte, size)
+	n, err := r.ReadAt(b, 0)
+	if err != nil && (err != io.EOF || int64(n) < size) {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	return u.unmarshal(b, &query{})
+}
@@ -0,0 +1,43 @@
+package turnip
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestUnmarshalReaderAtPrefixes(t *testing.T) {
+	for _, tt := range streamCases {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, payload := range tt.payloads {
+				want, wantErr := u.UnmarshalJSON([]byte(payload))
+				r := bytes.NewReader([]byte(payload))
+				for prefix := 0; prefix <= len(payload)+1; prefix++ {
+					got, gotErr := u.UnmarshalReaderAt(r, int64(len(payload)), prefix)
+					sameOutcome(t, fmt.Sprintf("UnmarshalReaderAt(%s, %d)", payload, prefix), want, got, wantErr, gotErr)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalReaderAtCompressed(t *testing.T) {
+	u, err := New(Candidate(streamOrder{}), Candidate(streamRefund{}), EnableDecompression())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"id":1,"reason":"damaged","total":9.5}`)
+	want, wantErr := u.UnmarshalJSON(payload)
+
+	compressed := gzipBytes(t, payload)
+	for prefix := 0; prefix <= len(payload); prefix++ {
+		got, gotErr := u.UnmarshalReaderAt(bytes.NewReader(compressed), int64(len(compressed)), prefix)
+		sameOutcome(t, fmt.Sprintf("UnmarshalReaderAt(gzip, %d)", prefix), want, got, wantErr, gotErr)
+	}
+}
@@ -0,0 +1,80 @@
+package turnip
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// RecordResolutions keeps the last n resolutions in memory, for RecentResolutions. It's meant for debugging a running
+// service without turning on verbose logging, and costs a hash of every payload
+func RecordResolutions(n int) Parameter {
+	return recentSize(n)
+}
+
+type recentSize int
+
+func (n recentSize) Name() string {
+	return "RecordResolutions"
+}
+
+// Resolution is a payload unmarshaled by the Unmarshaler, as recorded by RecordResolutions
+type Resolution struct {
+	Time time.Time
+	// PayloadHash is the 64-bit FNV-1a hash of the payload, to tell payloads apart without keeping them around. For
+	// payloads streamed by UnmarshalReader, only the prefix is hashed
+	PayloadHash uint64
+	// Type is the type the payload was unmarshaled into, nil if it failed
+	Type     reflect.Type
+	Duration time.Duration
+	Err      error
+}
+
+// RecentResolutions returns the last resolutions, oldest first, or nil if they are not recorded. Reload starts
+// recording anew
+func (u *Unmarshaler) RecentResolutions() []Resolution {
+	return u.load().recent.list()
+}
+
+// resolutionLog is a ring buffer of resolutions
+type resolutionLog struct {
+	mu      sync.Mutex
+	entries []Resolution
+	// next is the index the next resolution is written at
+	next int
+	full bool
+}
+
+func newResolutionLog(size int) *resolutionLog {
+	return &resolutionLog{
+		entries: make([]Resolution, size),
+	}
+}
+
+func (l *resolutionLog) add(r Resolution) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = r
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+func (l *resolutionLog) list() []Resolution {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.full {
+		return append([]Resolution(nil), l.entries[:l.next]...)
+	}
+
+	list := make([]Resolution, 0, len(l.entries))
+	list = append(list, l.entries[l.next:]...)
+	return append(list, l.entries[:l.next]...)
+}
@@ -0,0 +1,180 @@
+package turnip
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/tidwall/gjson"
+)
+
+// Recommendation is what the samples of a candidate say about its fingerprint, as returned by Recommend. Paths are
+// written as in the fingerprints, following the Naming
+type Recommendation struct {
+	Type reflect.Type
+	// Accuracy is the share of the samples of the candidate currently resolved to it
+	Accuracy float64
+	// Required are paths present in every sample of the candidate that, together, are present in no sample of the
+	// others. Given to Fingerprint, they tell the candidate apart by how payloads really look instead of by its fields.
+	// Empty if the samples can't be told apart this way
+	Required []string
+	// Ignored are paths of the current fingerprint missing from some samples of the candidate, which then don't
+	// resolve to it. They are better left out, with Fingerprint or by not being fields of the candidate
+	Ignored []string
+	// Weights are, for every path of the candidate, the share of its samples having it minus the share of the samples
+	// of the others having it. Paths closer to 1 tell it apart best, and those around 0 or below not at all
+	Weights map[string]float64
+}
+
+// Recommend learns from sample payloads, labeled by the candidate they should resolve to, which paths tell the
+// candidates apart best. Every type must be a candidate, and candidates without samples are left out of the results,
+// although the samples of the rest are still checked against them. It needs the fingerprints, so it can't be used
+// along with UseResolver
+func (u *Unmarshaler) Recommend(samples map[reflect.Type][][]byte) ([]Recommendation, error) {
+	s := u.load()
+	err := s.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := s.resolver.(*traverseResolver)
+	if !ok {
+		return nil, fmt.Errorf("recommendations need the fingerprints, not a %T", s.resolver)
+	}
+
+	parsed := make(map[reflect.Type][]gjson.Result, len(samples))
+	for typ, payloads := range samples {
+		if _, ok := s.candidates[typ]; !ok {
+			return nil, fmt.Errorf("%s is not a candidate", typ)
+		}
+
+		for i, b := range payloads {
+			_, res, err := s.parse(b)
+			if err != nil {
+				return nil, fmt.Errorf("%s: sample %d: %w", typ, i, err)
+			}
+
+			parsed[typ] = append(parsed[typ], res)
+		}
+	}
+
+	var recs []Recommendation
+	for _, fp := range r.fingerprints {
+		own := parsed[fp.candidate.typ]
+		if len(own) == 0 {
+			continue
+		}
+
+		var others []gjson.Result
+		for typ, res := range parsed {
+			if typ != fp.candidate.typ {
+				others = append(others, res...)
+			}
+		}
+
+		rec, err := s.recommend(fp, own, others)
+		if err != nil {
+			return nil, err
+		}
+
+		recs = append(recs, rec)
+	}
+
+	return recs, nil
+}
+
+func (u *unmarshaler) recommend(fp fingerprint, own, others []gjson.Result) (Recommendation, error) {
+	rec := Recommendation{
+		Type:    fp.candidate.typ,
+		Weights: make(map[string]float64, len(fp.all)),
+	}
+
+	resolved := 0
+	for _, res := range own {
+		typ, err := u.resolve(&query{res: res})
+		if err != nil {
+			return Recommendation{}, fmt.Errorf("%w: resolve: %w", ErrInternal, err)
+		}
+
+		if typ == fp.candidate.typ {
+			resolved++
+		}
+	}
+
+	rec.Accuracy = float64(resolved) / float64(len(own))
+
+	// always holds the paths every sample of the candidate has, as candidates for Required
+	var always []string
+	for _, path := range sortPaths(fp.all) {
+		ownShare := presence(fp.all[path], path, own)
+		rec.Weights[path] = ownShare - presence(fp.all[path], path, others)
+		if ownShare == 1 {
+			always = append(always, path)
+		}
+
+		if _, ok := fp.paths[path]; ok && ownShare < 1 {
+			rec.Ignored = append(rec.Ignored, path)
+		}
+	}
+
+	rec.Required = coverSamples(fp.all, always, others)
+	return rec, nil
+}
+
+// presence is the share of the samples with a value of the right type at path
+func presence(typ pathType, path string, samples []gjson.Result) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	found := 0
+	for _, res := range samples {
+		if typ.matches(typ.get(res, path)) {
+			found++
+		}
+	}
+
+	return float64(found) / float64(len(samples))
+}
+
+// coverSamples picks paths greedily, each time the one missing from the most samples still left, until no sample has
+// them all. It's the same set cover as makeFingerprint, but over samples instead of candidates. If some samples have
+// every path, there's no such set and nil is returned
+func coverSamples(paths jsonPaths, candidates []string, samples []gjson.Result) []string {
+	left := make([]gjson.Result, len(samples))
+	copy(left, samples)
+
+	var picked []string
+	for len(left) > 0 {
+		best, bestCount := "", 0
+		for _, path := range candidates {
+			count := 0
+			for _, res := range left {
+				if !paths[path].matches(paths[path].get(res, path)) {
+					count++
+				}
+			}
+
+			if count > bestCount {
+				best, bestCount = path, count
+			}
+		}
+
+		if bestCount == 0 {
+			return nil
+		}
+
+		picked = append(picked, best)
+		remaining := left[:0]
+		for _, res := range left {
+			if paths[best].matches(paths[best].get(res, best)) {
+				remaining = append(remaining, res)
+			}
+		}
+
+		left = remaining
+	}
+
+	sort.Strings(picked)
+	return picked
+}
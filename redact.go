@@ -0,0 +1,267 @@
+package turnip
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tidwall/gjson"
+)
+
+// redactedValue replaces the values of redacted fields wherever they would be printed
+const redactedValue = "[REDACTED]"
+
+// Redact hides the values at the paths of the payload for which match returns true, on top of the fields tagged with
+// `turnip:"redact"`. Paths are the keys of the payload as they are, joined by dots, like "user.password".
+//
+// Redacted fields are still used for resolving and decoded as usual, but their values are taken out of the errors
+// returned, and so out of the logs. The wrapped errors are left untouched, so errors.Is and errors.As still work
+func Redact(match func(path string) bool) Parameter {
+	return redactHook(match)
+}
+
+type redactHook func(path string) bool
+
+func (h redactHook) Name() string {
+	return "Redact"
+}
+
+// redactedError is an error with the values of redacted fields taken out of its message
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redacts reports whether the payloads decoded into typ may have values to redact
+func (u *unmarshaler) redacts(typ reflect.Type) bool {
+	return len(u.env.redactHooks) > 0 || u.withRedacted[typ]
+}
+
+// redactError takes the values of the redacted fields of the payload res, decoded into typ, out of the message of err
+func (u *unmarshaler) redactError(err error, res gjson.Result, typ reflect.Type, wire *format) error {
+	if err == nil || !u.redacts(typ) {
+		return err
+	}
+
+	secrets := u.secrets(res, typ, wire)
+	if len(secrets) == 0 {
+		return err
+	}
+
+	msg := err.Error()
+	redacted := scrub(msg, secrets)
+	if redacted == msg {
+		return err
+	}
+
+	return &redactedError{
+		err: err,
+		msg: redacted,
+	}
+}
+
+// secrets returns the text of every scalar value of the payload res, with keys named as in the format wire, that must
+// not be printed
+func (u *unmarshaler) secrets(res gjson.Result, typ reflect.Type, wire *format) []string {
+	var secrets []string
+	if u.withRedacted[typ] {
+		secrets = taggedSecrets(secrets, res, typ, u.env.naming, wire)
+	}
+
+	if len(u.env.redactHooks) > 0 {
+		secrets = hookedSecrets(secrets, res, "", u.env.redactHooks)
+	}
+
+	return secrets
+}
+
+// taggedSecrets appends the values of the fields of t tagged with redact
+func taggedSecrets(secrets []string, res gjson.Result, t reflect.Type, naming Naming, wire *format) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if _, ok := getLeafType(t); ok {
+		return secrets
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if !res.IsObject() {
+			return secrets
+		}
+
+		for _, f := range wireFields(t, wire) {
+			sub := naming.get(res, naming.key(f.name))
+			for _, alias := range f.aliases() {
+				if sub.Exists() {
+					break
+				}
+
+				sub = naming.get(res, naming.key(alias))
+			}
+
+			if !sub.Exists() {
+				continue
+			}
+
+			if _, ok := f.options["redact"]; ok {
+				secrets = appendScalars(secrets, sub)
+				continue
+			}
+
+			secrets = taggedSecrets(secrets, sub, f.typ, naming, wire)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		res.ForEach(func(_, value gjson.Result) bool {
+			secrets = taggedSecrets(secrets, value, t.Elem(), naming, wire)
+			return true
+		})
+	}
+
+	return secrets
+}
+
+// hookedSecrets appends the values at the paths the hooks ask to redact
+func hookedSecrets(secrets []string, res gjson.Result, curr string, hooks []redactHook) []string {
+	res.ForEach(func(key, value gjson.Result) bool {
+		path := key.String()
+		if curr != "" {
+			path = curr + "." + path
+		}
+
+		for _, hook := range hooks {
+			if hook(path) {
+				secrets = appendScalars(secrets, value)
+				return true
+			}
+		}
+
+		if value.IsObject() {
+			secrets = hookedSecrets(secrets, value, path, hooks)
+		}
+
+		return true
+	})
+
+	return secrets
+}
+
+// appendScalars appends the text of v, or of every scalar in it if it's an object or an array
+func appendScalars(secrets []string, v gjson.Result) []string {
+	switch {
+	case v.IsObject() || v.IsArray():
+		v.ForEach(func(_, value gjson.Result) bool {
+			secrets = appendScalars(secrets, value)
+			return true
+		})
+	case v.Type == gjson.String:
+		secrets = append(secrets, v.Str)
+	case v.Type != gjson.Null:
+		secrets = append(secrets, v.Raw)
+	}
+
+	return secrets
+}
+
+// scrub replaces the secrets found in s. Only whole words are replaced, so short values like 1 don't take out every
+// digit of the message, and longer secrets go first in case they contain shorter ones. Messages that had a secret often
+// quote parts of it too, like time.ParseError does, so those quoted parts go as well
+func scrub(s string, secrets []string) string {
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+
+	original := s
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+
+		s = replaceWord(s, secret)
+	}
+
+	if s == original {
+		return s
+	}
+
+	return replaceQuoted(s, func(quoted string) bool {
+		for _, secret := range secrets {
+			if strings.Contains(secret, quoted) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// replaceQuoted replaces the non-empty texts between double or single quotes for which redact returns true
+func replaceQuoted(s string, redact func(quoted string) bool) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexAny(s, `"'`)
+		if start < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+
+		end := strings.IndexByte(s[start+1:], s[start])
+		if end < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+
+		end += start + 1
+		quoted := s[start+1 : end]
+		b.WriteString(s[:start+1])
+		if quoted != "" && quoted != redactedValue && redact(quoted) {
+			b.WriteString(redactedValue)
+		} else {
+			b.WriteString(quoted)
+		}
+
+		b.WriteByte(s[end])
+		s = s[end+1:]
+	}
+}
+
+func replaceWord(s, word string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, word)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+
+		end := i + len(word)
+		if isWordEdge(s, i-1) && isWordEdge(s, end) {
+			b.WriteString(s[:i])
+			b.WriteString(redactedValue)
+		} else {
+			b.WriteString(s[:end])
+		}
+
+		s = s[end:]
+	}
+}
+
+// isWordEdge reports whether the byte at i of s is not part of a word, which is the case out of bounds
+func isWordEdge(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return true
+	}
+
+	r := rune(s[i])
+	return r >= 0x80 || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+}
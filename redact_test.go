@@ -0,0 +1,124 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type redactLogin struct {
+	User     string    `json:"user"`
+	Password string    `json:"password" turnip:"redact"`
+	Expires  time.Time `json:"expires" turnip:"redact"`
+}
+
+var errRedactDenied = errors.New("denied")
+
+// redactDeny fails every login, quoting the whole of it
+func redactDeny(v any) (any, error) {
+	l := v.(*redactLogin)
+	return nil, fmt.Errorf("%w: %s with %s", errRedactDenied, l.User, l.Password)
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		hidden  []string
+		shown   []string
+		wantErr error
+	}{
+		{
+			name:    "tagged field in a decode error",
+			params:  []Parameter{Candidate(redactLogin{})},
+			payload: `{"user":"bob","password":"hunter2","expires":"tomorrow"}`,
+			hidden:  []string{"tomorrow"},
+			wantErr: ErrDecode,
+		},
+		{
+			name:    "tagged field in a hook error",
+			params:  []Parameter{Candidate(redactLogin{}), AfterDecode(redactLogin{}, redactDeny)},
+			payload: `{"user":"bob","password":"hunter2"}`,
+			hidden:  []string{"hunter2"},
+			shown:   []string{"bob"},
+			wantErr: errRedactDenied,
+		},
+		{
+			name: "redacted path",
+			params: []Parameter{
+				Candidate(redactLogin{}),
+				AfterDecode(redactLogin{}, redactDeny),
+				Redact(func(path string) bool { return path == "user" }),
+			},
+			payload: `{"user":"bob","password":"hunter2"}`,
+			hidden:  []string{"bob", "hunter2"},
+			wantErr: errRedactDenied,
+		},
+		{
+			name:    "secret within a word",
+			params:  []Parameter{Candidate(redactLogin{}), AfterDecode(redactLogin{}, redactDeny)},
+			payload: `{"user":"bob","password":"bo"}`,
+			shown:   []string{"bob"},
+			wantErr: errRedactDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			for _, s := range tt.hidden {
+				if strings.Contains(err.Error(), s) {
+					t.Errorf("UnmarshalJSON() error = %q, has %q", err, s)
+				}
+			}
+
+			for _, s := range tt.shown {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("UnmarshalJSON() error = %q, doesn't have %q", err, s)
+				}
+			}
+		})
+	}
+}
+
+func TestScrub(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		secrets []string
+		want    string
+	}{
+		{"word", "bad value 42 at id", []string{"42"}, "bad value [REDACTED] at id"},
+		{"within a word", "id 142 and 42", []string{"42"}, "id 142 and [REDACTED]"},
+		{"longest first", "key abc-def", []string{"abc", "abc-def"}, "key [REDACTED]"},
+		{
+			name:    "quoted part",
+			s:       `parsing "2020-13" failed: "13" out of range`,
+			secrets: []string{"2020-13"},
+			want:    `parsing "[REDACTED]" failed: "[REDACTED]" out of range`,
+		},
+		{"quotes without secrets", `field "id" is "x"`, []string{"y"}, `field "id" is "x"`},
+		{"empty secret", "nothing", []string{""}, "nothing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scrub(tt.s, tt.secrets)
+			if got != tt.want {
+				t.Errorf("scrub() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownTenant is returned by a Registry for tenants it has no Unmarshaler for
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// Registry holds an Unmarshaler for each tenant, or namespace, of a service ingesting payloads for many of them. Every
+// tenant has its own candidates and settings, but the paths of their fingerprints are interned together, so tenants
+// with the same or similar candidates don't each hold their own copy. It's safe for concurrent use
+type Registry struct {
+	mu      sync.RWMutex
+	tenants map[string]*tenantEntry
+	// shared are the parameters given to every tenant, before their own
+	shared []Parameter
+	in     *interner
+}
+
+// tenantEntry is the Unmarshaler of a tenant, and the interner holding the paths of its current state
+type tenantEntry struct {
+	u  *Unmarshaler
+	in *interner
+}
+
+// NewRegistry returns an empty Registry. The parameters are given to every tenant before their own, for settings like
+// WithLogger that all of them should follow
+func NewRegistry(shared ...Parameter) *Registry {
+	return &Registry{
+		tenants: make(map[string]*tenantEntry),
+		shared:  shared,
+		in:      newInterner(),
+	}
+}
+
+// Set creates the Unmarshaler of the tenant with the parameters, or reloads it if there's one already, so those
+// already taken with Get follow the new parameters too. If the parameters are invalid, the error is returned and the
+// tenant is left as it was. The Unmarshaler is built before taking the lock, so other tenants are never kept waiting
+// for it, and concurrent calls for the same tenant leave the last one to finish
+func (r *Registry) Set(tenant string, params ...Parameter) error {
+	in := r.in.child()
+	all := make([]Parameter, 0, len(r.shared)+len(params)+1)
+	all = append(all, r.shared...)
+	all = append(all, params...)
+	all = append(all, sharedInterner{in})
+
+	state, err := newUnmarshaler(all)
+	if err != nil {
+		in.release()
+		return fmt.Errorf("%s: %w", tenant, err)
+	}
+
+	r.mu.Lock()
+	entry, ok := r.tenants[tenant]
+	if !ok {
+		entry = &tenantEntry{u: &Unmarshaler{}}
+		r.tenants[tenant] = entry
+	}
+
+	previous := entry.in
+	entry.in = in
+	entry.u.swap(state)
+	r.mu.Unlock()
+
+	// The paths of the previous state are only kept while some other tenant holds them too
+	if previous != nil {
+		previous.release()
+	}
+
+	return nil
+}
+
+// Get returns the Unmarshaler of the tenant, if there's one
+func (r *Registry) Get(tenant string) (*Unmarshaler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.tenants[tenant]
+	if !ok {
+		return nil, false
+	}
+
+	return entry.u, true
+}
+
+// Remove forgets the tenant, along with the paths no other tenant holds. Its Unmarshaler keeps working for whoever
+// still holds it
+func (r *Registry) Remove(tenant string) {
+	r.mu.Lock()
+	entry, ok := r.tenants[tenant]
+	delete(r.tenants, tenant)
+	r.mu.Unlock()
+
+	if ok {
+		entry.in.release()
+	}
+}
+
+// Tenants returns the tenants with an Unmarshaler, sorted
+func (r *Registry) Tenants() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants := make([]string, 0, len(r.tenants))
+	for tenant := range r.tenants {
+		tenants = append(tenants, tenant)
+	}
+
+	sort.Strings(tenants)
+	return tenants
+}
+
+// Unmarshal unmarshals the JSON payload with the Unmarshaler of the tenant, failing with ErrUnknownTenant if there's
+// none
+func (r *Registry) Unmarshal(tenant string, b []byte) (any, error) {
+	u, ok := r.Get(tenant)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTenant, tenant)
+	}
+
+	return u.UnmarshalJSON(b)
+}
+
+// sharedInterner makes the Unmarshaler intern its paths with those of others, as the tenants of a Registry do
+type sharedInterner struct {
+	in *interner
+}
+
+func (s sharedInterner) Name() string {
+	return "sharedInterner"
+}
+
+var named = struct {
+	sync.RWMutex
+	unmarshalers map[string]*Unmarshaler
+}{
+	unmarshalers: make(map[string]*Unmarshaler),
+}
+
+// RegisterUnmarshaler makes u available to Get under name, so the code wiring up routers and consumers can refer to
+// Unmarshalers configured elsewhere without importing the package they are configured in. Registering a name again
+// replaces the previous registration
+func RegisterUnmarshaler(name string, u *Unmarshaler) {
+	if u == nil {
+		panic("turnip: RegisterUnmarshaler with nil Unmarshaler")
+	}
+
+	named.Lock()
+	defer named.Unlock()
+
+	named.unmarshalers[name] = u
+}
+
+// Get returns the Unmarshaler registered under name with RegisterUnmarshaler, if there's one
+func Get(name string) (*Unmarshaler, bool) {
+	named.RLock()
+	defer named.RUnlock()
+
+	u, ok := named.unmarshalers[name]
+	return u, ok
+}
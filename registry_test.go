@@ -0,0 +1,332 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type tenantOrder struct {
+	ID string `json:"id"`
+}
+
+type tenantInvoice struct {
+	Number string `json:"number"`
+}
+
+func TestRegistry(t *testing.T) {
+	tests := []struct {
+		name    string
+		shared  []Parameter
+		tenant  string
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "tenant candidates",
+			tenant:  "b",
+			payload: `{"number":"1"}`,
+			want:    &tenantInvoice{Number: "1"},
+		},
+		{
+			name:    "candidates of other tenants",
+			tenant:  "a",
+			payload: `{"number":"1"}`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name:    "unknown tenant",
+			tenant:  "c",
+			payload: `{"id":"1"}`,
+			wantErr: ErrUnknownTenant,
+		},
+		{
+			name:    "shared parameters",
+			shared:  []Parameter{FallbackToMap()},
+			tenant:  "a",
+			payload: `{"number":"1"}`,
+			want:    map[string]any{"number": "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(tt.shared...)
+			err := r.Set("a", Candidate(tenantOrder{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = r.Set("b", Candidate(tenantInvoice{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := r.Unmarshal(tt.tenant, []byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unmarshal() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistrySet(t *testing.T) {
+	r := NewRegistry()
+	for _, tenant := range []string{"b", "a"} {
+		err := r.Set(tenant, Candidate(tenantOrder{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := r.Tenants(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Tenants() = %v, want [a b]", got)
+	}
+
+	u, _ := r.Get("a")
+	payload := []byte(`{"number":"1"}`)
+
+	// Unmarshalers already taken follow the new parameters
+	err := r.Set("a", Candidate(tenantInvoice{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := u.UnmarshalJSON(payload); err != nil {
+		t.Errorf("UnmarshalJSON() after Set error = %v", err)
+	}
+
+	// Invalid parameters leave the tenant as it was
+	err = r.Set("a", CacheResolutions(0))
+	if !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Set() error = %v, want %v", err, ErrInvalidParameter)
+	}
+
+	if _, err := r.Unmarshal("a", payload); err != nil {
+		t.Errorf("Unmarshal() after a failed Set error = %v", err)
+	}
+
+	// Removed tenants are gone from the Registry, but not for whoever still holds them
+	r.Remove("a")
+	if _, err := r.Unmarshal("a", payload); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("Unmarshal() after Remove error = %v, want %v", err, ErrUnknownTenant)
+	}
+
+	if _, err := u.UnmarshalJSON(payload); err != nil {
+		t.Errorf("UnmarshalJSON() after Remove error = %v", err)
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	r := NewRegistry()
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := range 8 {
+		tenant := fmt.Sprintf("tenant-%d", i%2)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				err := r.Set(tenant, Candidate(tenantOrder{}), Candidate(tenantInvoice{}))
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				v, err := r.Unmarshal(tenant, []byte(`{"id":"1"}`))
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				if !reflect.DeepEqual(v, &tenantOrder{ID: "1"}) {
+					errs <- fmt.Errorf("%s: got %#v", tenant, v)
+					return
+				}
+
+				r.Tenants()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// blockedCondition holds the creation of the Unmarshaler using it until unblocked
+type blockedCondition struct {
+	started   chan struct{}
+	unblocked chan struct{}
+}
+
+func (c *blockedCondition) compile() error {
+	close(c.started)
+	<-c.unblocked
+	return nil
+}
+
+func (c *blockedCondition) matches(*query) bool {
+	return false
+}
+
+func (c *blockedCondition) paths() []string {
+	return nil
+}
+
+func TestRegistrySetUnlocked(t *testing.T) {
+	r := NewRegistry()
+	err := r.Set("a", Candidate(tenantOrder{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cond := &blockedCondition{started: make(chan struct{}), unblocked: make(chan struct{})}
+	set := make(chan error)
+	go func() {
+		set <- r.Set("b", Candidate(tenantOrder{}), SelectWhen(cond, tenantInvoice{}))
+	}()
+
+	<-cond.started
+	got := make(chan error)
+	go func() {
+		_, err := r.Unmarshal("a", []byte(`{"id":"1"}`))
+		r.Tenants()
+		got <- err
+	}()
+
+	select {
+	case err := <-got:
+		if err != nil {
+			t.Errorf("Unmarshal() while setting another tenant error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Unmarshal() blocked while setting another tenant")
+	}
+
+	close(cond.unblocked)
+	if err := <-set; err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(r.Tenants(), []string{"a", "b"}) {
+		t.Errorf("Tenants() = %v, want [a b]", r.Tenants())
+	}
+}
+
+func TestRegistryReleasesPaths(t *testing.T) {
+	r := NewRegistry()
+	err := r.Set("a", Candidate(tenantOrder{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orders := r.in.len()
+	if orders == 0 {
+		t.Fatal("Set() interned no paths")
+	}
+
+	tests := []struct {
+		name   string
+		change func() error
+		want   int
+	}{
+		{
+			name:   "tenant added",
+			change: func() error { return r.Set("b", Candidate(tenantOrder{}), Candidate(tenantInvoice{})) },
+			want:   orders + 1,
+		},
+		{
+			name:   "tenant reloaded",
+			change: func() error { return r.Set("b", Candidate(tenantOrder{})) },
+			want:   orders,
+		},
+		{
+			name:   "tenant failing to reload",
+			change: func() error { _ = r.Set("b", Candidate(tenantInvoice{}), CacheResolutions(0)); return nil },
+			want:   orders,
+		},
+		{
+			name:   "paths held by another tenant",
+			change: func() error { r.Remove("a"); return nil },
+			want:   orders,
+		},
+		{
+			name:   "last tenant removed",
+			change: func() error { r.Remove("b"); return nil },
+			want:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.change()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := r.in.len(); got != tt.want {
+				t.Errorf("interned paths = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterUnmarshaler(t *testing.T) {
+	orders, err := New(Candidate(tenantOrder{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invoices, err := New(Candidate(tenantInvoice{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		register map[string]*Unmarshaler
+		get      string
+		want     *Unmarshaler
+	}{
+		{"registered", map[string]*Unmarshaler{"test/orders": orders}, "test/orders", orders},
+		{"not registered", nil, "test/unknown", nil},
+		{"registered again", map[string]*Unmarshaler{"test/billing": invoices}, "test/billing", invoices},
+	}
+
+	// Replaced by the registered again case
+	RegisterUnmarshaler("test/billing", orders)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, u := range tt.register {
+				RegisterUnmarshaler(name, u)
+			}
+
+			got, ok := Get(tt.get)
+			if got != tt.want || ok != (tt.want != nil) {
+				t.Errorf("Get(%q) = %p, %v, want %p", tt.get, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterUnmarshalerNil(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterUnmarshaler() with nil didn't panic")
+		}
+	}()
+
+	RegisterUnmarshaler("test/nil", nil)
+}
@@ -0,0 +1,130 @@
+package turnip
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Reload replaces the parameters of the Unmarshaler, as if it had been created with New(params...). Calls already in
+// progress finish with the previous parameters, and the following ones use the new ones. If the new parameters are
+// invalid the error is returned, and the previous ones are kept
+func (u *Unmarshaler) Reload(params ...Parameter) error {
+	u.reloadMu.Lock()
+	defer u.reloadMu.Unlock()
+
+	state, err := newUnmarshaler(params)
+	if err != nil {
+		return err
+	}
+
+	u.replace(state)
+	return nil
+}
+
+// swap replaces the state with one already built, as Reload does
+func (u *Unmarshaler) swap(state *unmarshaler) {
+	u.reloadMu.Lock()
+	defer u.reloadMu.Unlock()
+
+	u.replace(state)
+}
+
+// replace swaps in the state, with reloadMu held
+func (u *Unmarshaler) replace(state *unmarshaler) {
+	previous := u.current.Swap(state)
+	if previous == nil {
+		return
+	}
+
+	state.env.logger.Infow("reloaded", zap.Int("previous_candidates", len(previous.env.candidates)),
+		zap.Int("candidates", len(state.env.candidates)))
+}
+
+// Add adds parameters, like more candidates, to those of the Unmarshaler, as Reload does with all of them. Calls never
+// wait for it: the new state is built on the side, from a copy of the parameters, and swapped in atomically once
+// ready, so calls already in progress finish with the previous one. Concurrent calls to Add and Reload are applied one
+// after the other, each one on top of the last
+func (u *Unmarshaler) Add(params ...Parameter) error {
+	u.reloadMu.Lock()
+	defer u.reloadMu.Unlock()
+
+	previous := u.load()
+	state, err := newUnmarshaler(append(slices.Clip(previous.params), params...))
+	if err != nil {
+		return err
+	}
+
+	u.current.Store(state)
+	state.env.logger.Infow("added parameters", zap.Int("previous_candidates", len(previous.env.candidates)),
+		zap.Int("candidates", len(state.env.candidates)))
+	return nil
+}
+
+// WatchFile reloads the Unmarshaler whenever the file at path changes, checking every interval until the context is
+// done. The contents of the file are turned into parameters by load, which is also where the file format is decided.
+//
+// Failures to read, load or reload keep the previous parameters, and are sent on the returned channel if there's room.
+// It's closed once the watch stops
+func (u *Unmarshaler) WatchFile(ctx context.Context, path string, interval time.Duration,
+	load func(b []byte) ([]Parameter, error)) <-chan error {
+	errs := make(chan error, 1)
+	report := func(err error) {
+		u.load().env.logger.Warnw("watch failed", zap.String("path", path), zap.Error(err))
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+
+	go func() {
+		defer close(errs)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last []byte
+		for {
+			b, err := os.ReadFile(path)
+			switch {
+			case err != nil:
+				report(fmt.Errorf("read: %w", err))
+			case last == nil || !bytes.Equal(b, last):
+				// Contents that fail are not retried until they change again
+				last = b
+
+				err = u.reloadFrom(b, load)
+				if err != nil {
+					report(err)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return errs
+}
+
+func (u *Unmarshaler) reloadFrom(b []byte, load func(b []byte) ([]Parameter, error)) error {
+	params, err := load(b)
+	if err != nil {
+		return fmt.Errorf("load: %w", err)
+	}
+
+	err = u.Reload(params...)
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+
+	return nil
+}
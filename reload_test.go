@@ -0,0 +1,156 @@
+package turnip
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type reloadOrder struct {
+	ID string `json:"id"`
+}
+
+type reloadRefund struct {
+	RefundOf string `json:"refund_of"`
+}
+
+// reloadTypes are the candidates a watched file can name, one per line
+var reloadTypes = map[string]any{
+	"order":  reloadOrder{},
+	"refund": reloadRefund{},
+}
+
+func loadTypes(b []byte) ([]Parameter, error) {
+	var params []Parameter
+	for _, name := range strings.Fields(string(b)) {
+		v, ok := reloadTypes[name]
+		if !ok {
+			return nil, errors.New("unknown type " + name)
+		}
+
+		params = append(params, Candidate(v))
+	}
+
+	return params, nil
+}
+
+func TestReload(t *testing.T) {
+	tests := []struct {
+		name      string
+		reload    func(u *Unmarshaler) error
+		reloadErr error
+		payload   string
+		wantErr   error
+	}{
+		{
+			name:    "reloaded",
+			reload:  func(u *Unmarshaler) error { return u.Reload(Candidate(reloadRefund{})) },
+			payload: `{"refund_of":"a"}`,
+		},
+		{
+			name:    "previous parameters gone",
+			reload:  func(u *Unmarshaler) error { return u.Reload(Candidate(reloadRefund{})) },
+			payload: `{"id":"a"}`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name:      "invalid parameters",
+			reload:    func(u *Unmarshaler) error { return u.Reload(CacheResolutions(0)) },
+			reloadErr: ErrInvalidParameter,
+			payload:   `{"id":"a"}`,
+		},
+		{
+			name:    "added",
+			reload:  func(u *Unmarshaler) error { return u.Add(Candidate(reloadRefund{})) },
+			payload: `{"refund_of":"a"}`,
+		},
+		{
+			name:    "previous parameters kept",
+			reload:  func(u *Unmarshaler) error { return u.Add(Candidate(reloadRefund{})) },
+			payload: `{"id":"a"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(Candidate(reloadOrder{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tt.reload(u)
+			if !errors.Is(err, tt.reloadErr) {
+				t.Fatalf("reload error = %v, want %v", err, tt.reloadErr)
+			}
+
+			_, err = u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "candidates")
+	err := os.WriteFile(path, []byte("order"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := New(Candidate(reloadRefund{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := u.WatchFile(ctx, path, time.Millisecond, loadTypes)
+
+	// eventually waits for the payload to unmarshal as want says
+	eventually := func(payload string, want bool) {
+		t.Helper()
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+			_, err := u.UnmarshalJSON([]byte(payload))
+			if (err == nil) == want {
+				return
+			}
+		}
+
+		t.Fatalf("UnmarshalJSON(%s) never succeeding = %v", payload, want)
+	}
+
+	eventually(`{"id":"a"}`, true)
+	eventually(`{"refund_of":"a"}`, false)
+
+	err = os.WriteFile(path, []byte("order\nrefund"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(`{"refund_of":"a"}`, true)
+
+	// Failures keep the previous parameters
+	err = os.WriteFile(path, []byte("invoice"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-errs:
+		if err == nil || !strings.Contains(err.Error(), "unknown type invoice") {
+			t.Errorf("WatchFile() error = %v, want the load error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WatchFile() didn't report the load error")
+	}
+
+	eventually(`{"refund_of":"a"}`, true)
+
+	cancel()
+	for range errs {
+	}
+}
@@ -3,17 +3,23 @@ package turnip
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/tidwall/gjson"
 	"go.uber.org/zap"
 )
 
-var ErrUnsupportedType = errors.New("unsupported type")
+var (
+	ErrUnsupportedType = errors.New("unsupported type")
+	ErrUnreachable     = errors.New("unreachable candidate")
+)
 
 const (
 	jsonIgnoreTag = "-"
+	turnipTag     = "turnip"
 )
 
 type Resolver interface {
@@ -21,25 +27,274 @@ type Resolver interface {
 }
 
 type traverseResolver struct {
-	env    environment
-	logger *zap.SugaredLogger
-	paths  map[*candidate]jsonPaths
+	env          environment
+	logger       *zap.SugaredLogger
+	fingerprints []fingerprint
+	// scan holds the keys kept for payloads above the ScanKeysAbove threshold, nil if they are not scanned
+	scan *keyTree
+	// built holds the paths of each candidate before any mapping, for Merge to reuse them
+	built builtPaths
+}
+
+// MultiResolver is a Resolver that can also return every type a payload matches, instead of only the first one
+type MultiResolver interface {
+	Resolver
+	ResolveAllJSON(res gjson.Result) ([]reflect.Type, error)
 }
 
-// TODO Return multiple posibilities
 func (r *traverseResolver) ResolveJSON(res gjson.Result) (reflect.Type, error) {
-	for c, paths := range r.paths {
-		for path, typ := range paths {
-			if res.Get(path).Type == typ {
-				return c.typ, nil
-			}
+	return r.resolve(&query{res: res})
+}
+
+func (r *traverseResolver) ResolveAllJSON(res gjson.Result) ([]reflect.Type, error) {
+	return r.resolveAll(&query{res: res}), nil
+}
+
+func (r *traverseResolver) ResolveJSONContext(res gjson.Result, rc ResolveContext) (reflect.Type, error) {
+	return r.resolve(&query{res: res, ctx: rc})
+}
+
+func (r *traverseResolver) resolve(q *query) (reflect.Type, error) {
+	q = r.scanned(q)
+	for _, s := range r.env.selectors {
+		if s.cond.matches(q) {
+			return s.typ, nil
+		}
+	}
+
+	for _, fp := range r.fingerprints {
+		if fp.matches(q.res) {
+			return fp.candidate.typ, nil
 		}
 	}
 
+	if r.env.fuzzyThreshold > 0 {
+		return r.resolveFuzzy(q), nil
+	}
+
 	return nil, nil
 }
 
-type jsonPaths map[string]gjson.Type
+// resolveFuzzy returns the candidate with the best score, if it's above the threshold
+func (r *traverseResolver) resolveFuzzy(q *query) reflect.Type {
+	var best *fingerprint
+	bestScore := float64(r.env.fuzzyThreshold) / 100
+	for i, fp := range r.fingerprints {
+		score := fp.score(q.res)
+		if score >= bestScore && (best == nil || score > bestScore) {
+			best, bestScore = &r.fingerprints[i], score
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+
+	r.logger.Debugw("fuzzy match", zap.Stringer("type", best.candidate.typ), zap.Float64("score", bestScore))
+	return best.candidate.typ
+}
+
+// resolveAll returns the types of every selector and candidate matching, in the order they would be checked by
+// resolve, without repeating any
+func (r *traverseResolver) resolveAll(q *query) []reflect.Type {
+	var types []reflect.Type
+	seen := make(map[reflect.Type]bool)
+	add := func(typ reflect.Type) {
+		if !seen[typ] {
+			seen[typ] = true
+			types = append(types, typ)
+		}
+	}
+
+	q = r.scanned(q)
+	for _, s := range r.env.selectors {
+		if s.cond.matches(q) {
+			add(s.typ)
+		}
+	}
+
+	for _, fp := range r.fingerprints {
+		if fp.matches(q.res) {
+			add(fp.candidate.typ)
+		}
+	}
+
+	return types
+}
+
+// scanned returns the query over the keys resolution looks at, if the payload is large enough to be scanned
+func (r *traverseResolver) scanned(q *query) *query {
+	if r.scan == nil || len(q.res.Raw) <= int(r.env.scanThreshold) {
+		return q
+	}
+
+	return &query{
+		res: r.scan.prune(q.res, r.env.naming),
+		ctx: q.ctx,
+	}
+}
+
+// ambiguousMatch returns the candidates that can't be told apart and have every one of their paths in the payload,
+// along with their rivals
+func (r *traverseResolver) ambiguousMatch(res gjson.Result) []*candidate {
+	for _, fp := range r.fingerprints {
+		if len(fp.ambiguous) == 0 {
+			continue
+		}
+
+		matches := true
+		for path, typ := range fp.all {
+			if !typ.matches(typ.get(res, path)) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return append([]*candidate{fp.candidate}, fp.ambiguous...)
+		}
+	}
+
+	return nil
+}
+
+type jsonPaths map[string]pathType
+
+// pathType is what is expected to be found at a path
+type pathType struct {
+	json gjson.Type
+	// format further restricts the values accepted, it's one of the valueFormats
+	format string
+	// oneOf restricts strings to a set of values. Equal sets share the same pointer, so path types can be compared
+	oneOf *enumSet
+	// layout restricts strings to times following it, for time fields of candidates with a TimeLayout
+	layout string
+	// coerce converts scalars of other types before checking them
+	coerce coercions
+	// aliases are the other paths the value can be found at
+	aliases *aliasSet
+	// naming is how the keys of the payload are matched with the path
+	naming Naming
+}
+
+func (p pathType) matches(v gjson.Result) bool {
+	v = p.coerce.apply(v, p.json)
+	if !matchesJSONType(v, p.json) {
+		return false
+	}
+
+	if p.oneOf != nil && !p.oneOf.values[v.Str] {
+		return false
+	}
+
+	if p.layout != "" && !layoutCheck(p.layout)(v) {
+		return false
+	}
+
+	return p.format == "" || valueFormats[p.format].check(v)
+}
+
+func (p pathType) String() string {
+	switch {
+	case p.format != "":
+		return fmt.Sprintf("%s(%s)", typeName(p.json), p.format)
+	case p.oneOf != nil:
+		return fmt.Sprintf("%s(oneof=%s)", typeName(p.json), p.oneOf.key)
+	case p.layout != "":
+		return fmt.Sprintf("%s(layout=%s)", typeName(p.json), p.layout)
+	default:
+		return typeName(p.json)
+	}
+}
+
+type enumSet struct {
+	// key is the sorted values joined by |
+	key    string
+	values map[string]bool
+}
+
+// anyJSONType is not a type gjson knows about. Paths of this type match any value, as long as it's present
+const anyJSONType gjson.Type = -2
+
+// fingerprint is the set of paths that, when all present, tell a candidate apart from every other one
+type fingerprint struct {
+	candidate *candidate
+	paths     jsonPaths
+	// ambiguous holds the rivals that have every path this candidate has. If not empty, the candidate can't be
+	// distinguished and the fingerprint never matches
+	ambiguous []*candidate
+	// anyOf is set when there are no rivals at all. Then, any one of paths being present is enough
+	anyOf bool
+	// strict is set for strict candidates, and must match on top of the paths
+	strict *strictShape
+	// all holds every path of the candidate, for fuzzy matching
+	all jsonPaths
+}
+
+func (f fingerprint) matches(res gjson.Result) bool {
+	if len(f.ambiguous) > 0 {
+		return false
+	}
+
+	if f.candidate.version != nil && !f.candidate.version.matches(res) {
+		return false
+	}
+
+	if f.strict != nil && !f.strict.matches(res) {
+		return false
+	}
+
+	switch {
+	case f.candidate.custom():
+		if !f.candidate.queried(res) {
+			return false
+		}
+	case f.anyOf:
+		if !f.matchesAny(res) {
+			return false
+		}
+	default:
+		for path, typ := range f.paths {
+			if !typ.matches(typ.get(res, path)) {
+				return false
+			}
+		}
+	}
+
+	return f.candidate.matched(res)
+}
+
+// matchesAny reports whether any one of the paths is present with the right type
+func (f fingerprint) matchesAny(res gjson.Result) bool {
+	for path, typ := range f.paths {
+		if typ.matches(typ.get(res, path)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// score is the share of the paths of the candidate that are present with the right type, or 0 if the candidate can't
+// be matched fuzzily
+func (f fingerprint) score(res gjson.Result) float64 {
+	if len(f.ambiguous) > 0 || f.strict != nil || len(f.all) == 0 {
+		return 0
+	}
+
+	if f.candidate.version != nil && !f.candidate.version.matches(res) || !f.candidate.matched(res) {
+		return 0
+	}
+
+	matched := 0
+	for path, typ := range f.all {
+		if typ.matches(typ.get(res, path)) {
+			matched++
+		}
+	}
+
+	return float64(matched) / float64(len(f.all))
+}
 
 func newTraverseResolver(env environment) (*traverseResolver, error) {
 	r := &traverseResolver{
@@ -49,136 +304,532 @@ func newTraverseResolver(env environment) (*traverseResolver, error) {
 
 	r.logger.Infow("building paths", zap.Int("candidates", len(env.candidates)))
 
+	// Paths are interned while building, so the many copies of the same path across candidates share their memory.
+	// The interner itself is only needed until the fingerprints are done, unless it's shared with other Unmarshalers
+	in := env.interner
+	if in == nil {
+		in = newInterner()
+	}
+
+	b := newPathBuilder(env, in, r.logger)
+
+	r.built = make(builtPaths, len(env.candidates))
 	candidatePaths := make(map[*candidate]jsonPaths, len(env.candidates))
 	for _, c := range env.candidates {
-		paths, err := buildPathsForRoot(c.typ)
-		if err != nil {
-			return nil, fmt.Errorf("%s: %w", c.typ, err)
+		key := pathsKey{typ: c.typ, config: pathsConfig(env, c)}
+		paths, ok := env.builtPaths[key]
+		if ok {
+			paths = b.adopt(paths)
+		} else {
+			b.timeLayout = c.decode.timeLayout
+			var err error
+			paths, err = b.build(c.typ)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", c.typ, err)
+			}
+		}
+
+		r.built[key] = paths
+
+		if len(c.mapped) > 0 {
+			paths = remapPaths(paths, c.mapped, env.naming, in)
 		}
 
-		r.logger.Infof("built %d paths for %s:", len(paths), c.typ)
-		for path, t := range paths {
-			r.logger.Infof("  %s -> %s", path, t.String())
+		r.logger.Infow("built paths", zap.Stringer("candidate", c), zap.Int("paths", len(paths)))
+		for _, path := range sortPaths(paths) {
+			r.logger.Infow("path", pathFields(c, path, paths[path])...)
 		}
 
 		candidatePaths[c] = paths
 	}
 
-	r.logger.Info("finding paths to use as fingerprints")
+	r.logger.Infow("finding paths to use as fingerprints", zap.Int("unique_paths", b.in.len()))
 
-	r.paths = makeUniquePaths(candidatePaths)
+	r.fingerprints = makeFingerprints(env.candidates, candidatePaths, env.naming)
 
-	for c, paths := range r.paths {
-		r.logger.Infof("%s:", c.typ)
-		for path, typ := range paths {
-			r.logger.Infof("  %s -> %s", path, typ.String())
+	if env.scanThreshold > 0 {
+		r.scan = newKeyTree(env, r.fingerprints)
+		if r.scan == nil {
+			r.logger.Warn("payloads will not be scanned, since the keys resolution looks at can't be known")
+		}
+	}
+
+	for _, fp := range r.fingerprints {
+		if len(fp.ambiguous) > 0 && fp.candidate.routed {
+			r.logger.Infow("candidate only matches by the when options of its tags",
+				zap.Stringer("candidate", fp.candidate), zap.Strings("ambiguous_with", candidateNames(fp.ambiguous)))
+			continue
+		}
+
+		if twin := r.foreignTwin(fp); twin != nil {
+			err := fmt.Errorf("%w: %s and %s have the same wire shape, but come from different packages", ErrUnreachable,
+				fullTypeName(fp.candidate.typ), fullTypeName(twin.typ))
+			if !env.settings.Get(enableLenient) {
+				return nil, err
+			}
+
+			r.logger.Warnw("candidate will never match, the same shape is declared in another package",
+				zap.String("candidate", fullTypeName(fp.candidate.typ)), zap.String("twin", fullTypeName(twin.typ)))
+			continue
+		}
+
+		if len(fp.ambiguous) > 0 {
+			err := fmt.Errorf("%w: %s can't be told apart from %s", ErrUnreachable, fp.candidate.typ, typeNames(fp.ambiguous))
+			if !env.settings.Get(enableLenient) {
+				return nil, err
+			}
+
+			r.logger.Warnw("candidate will never match", zap.Stringer("candidate", fp.candidate),
+				zap.Strings("ambiguous_with", candidateNames(fp.ambiguous)))
+			continue
+		}
+
+		r.logger.Infow("fingerprint", zap.Stringer("candidate", fp.candidate), zap.Int("paths", len(fp.paths)),
+			zap.Bool("any_of", fp.anyOf), zap.Bool("strict", fp.strict != nil))
+		for _, path := range sortPaths(fp.paths) {
+			r.logger.Infow("fingerprint path", pathFields(fp.candidate, path, fp.paths[path])...)
 		}
 	}
 
 	return r, nil
 }
 
-func buildPathsForRoot(t reflect.Type) (jsonPaths, error) {
+// newPathBuilder returns a pathBuilder for the candidates of env, interning their paths with in
+func newPathBuilder(env environment, in *interner, logger *zap.SugaredLogger) *pathBuilder {
+	return &pathBuilder{
+		in:              in,
+		format:          env.format,
+		lenient:         env.settings.Get(enableLenient),
+		coerce:          env.coerce,
+		naming:          env.naming,
+		implementations: env.implementations,
+		logger:          logger,
+		enums:           make(map[string]*enumSet),
+		aliasSets:       make(map[string]*aliasSet),
+	}
+}
+
+// pathFields describes a path of the candidate c for the logs
+func pathFields(c *candidate, path string, typ pathType) []any {
+	fields := []any{
+		zap.Stringer("candidate", c),
+		zap.String("path", path),
+		zap.String("json_type", typeName(typ.json)),
+	}
+
+	if typ.format != "" {
+		fields = append(fields, zap.String("format", typ.format))
+	}
+
+	if typ.layout != "" {
+		fields = append(fields, zap.String("layout", typ.layout))
+	}
+
+	if typ.oneOf != nil {
+		fields = append(fields, zap.String("oneof", typ.oneOf.key))
+	}
+
+	if typ.aliases != nil {
+		fields = append(fields, zap.Strings("aliases", typ.aliases.paths))
+	}
+
+	return fields
+}
+
+type pathBuilder struct {
+	in     *interner
+	format *format
+	// lenient skips fields of unsupported kinds instead of failing
+	lenient bool
+	// timeLayout is the TimeLayout of the candidate being built, if any
+	timeLayout string
+	// coerce is set on the paths of scalar fields
+	coerce coercions
+	// naming puts the names of the fields in the form of the paths, and is set on every path
+	naming Naming
+	// implementations of interface fields, which makes them fingerprintable
+	implementations map[reflect.Type]*implementations
+	logger          *zap.SugaredLogger
+
+	paths jsonPaths
+	// visiting holds the structs currently being traversed, so recursive types don't recurse forever
+	visiting map[reflect.Type]bool
+	// enums holds the oneof sets found so far, by key
+	enums map[string]*enumSet
+	// alternates holds the other paths of the fields with aliases, or nested in fields with aliases
+	alternates map[string][]string
+	// aliasSets holds the alias sets found so far, by key
+	aliasSets map[string]*aliasSet
+}
+
+func (b *pathBuilder) build(t reflect.Type) (jsonPaths, error) {
 	if t.Kind() != reflect.Struct {
 		return nil, errors.New("not a struct")
 	}
 
-	paths := make(jsonPaths, t.NumField())
+	b.paths = make(jsonPaths, t.NumField())
+	b.visiting = make(map[reflect.Type]bool)
+	b.alternates = make(map[string][]string)
+
+	err := b.buildStruct("", t)
+	if err != nil {
+		return nil, err
+	}
+
+	b.applyAliases()
+	for path, typ := range b.paths {
+		typ.naming = b.naming
+		b.paths[path] = typ
+	}
+
+	return b.paths, nil
+}
+
+func (b *pathBuilder) buildStruct(curr string, t reflect.Type) error {
+	b.visiting[t] = true
+	defer delete(b.visiting, t)
+
+	for _, f := range wireFields(t, b.format) {
+		path := b.in.intern(appendToPath(curr, f.name, b.naming))
+		if _, ok := f.options["aliases"]; ok && len(f.aliases()) == 0 {
+			return fmt.Errorf("%s: aliases needs at least one name", f.name)
+		}
+
+		if others := b.otherPaths(curr, f); len(others) > 0 {
+			b.alternates[path] = others
+		}
 
-	for _, f := range reflect.VisibleFields(t) {
-		if !f.IsExported() {
+		if f.quoted {
+			b.paths[path] = pathType{json: gjson.String}
 			continue
 		}
 
-		err := buildPathsForField(paths, appendToPath("", getJSONName(f)), f.Type)
+		err := b.buildField(path, f.typ)
 		if err != nil {
-			return nil, fmt.Errorf("%s :%w", f.Name, err)
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+
+		if format, ok := f.options["format"]; ok {
+			err = b.applyFormat(path, format)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+		}
+
+		if values, ok := f.options["oneof"]; ok {
+			err = b.applyOneOf(path, values)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
 		}
 	}
 
-	return paths, nil
+	return nil
+}
+
+func (b *pathBuilder) applyFormat(path, format string) error {
+	typ, ok := b.paths[path]
+	if !ok {
+		return fmt.Errorf("format '%s' can only be used on scalar fields", format)
+	}
+
+	vf, ok := valueFormats[format]
+	if !ok {
+		return fmt.Errorf("unknown format '%s'", format)
+	}
+
+	if vf.json != typ.json {
+		return fmt.Errorf("format '%s' is for %s values, not %s", format, typeName(vf.json), typeName(typ.json))
+	}
+
+	typ.format = format
+	b.paths[path] = typ
+	return nil
 }
 
-func buildPathsForField(paths jsonPaths, curr string, t reflect.Type) error {
+func (b *pathBuilder) applyOneOf(path, values string) error {
+	typ, ok := b.paths[path]
+	if !ok || typ.json != gjson.String {
+		return errors.New("oneof can only be used on string fields")
+	}
+
+	set := strings.Split(values, "|")
+	sort.Strings(set)
+
+	key := strings.Join(set, "|")
+	if key == "" {
+		return errors.New("oneof needs at least one value")
+	}
+
+	enum, ok := b.enums[key]
+	if !ok {
+		enum = &enumSet{
+			key:    key,
+			values: make(map[string]bool, len(set)),
+		}
+
+		for _, v := range set {
+			enum.values[v] = true
+		}
+
+		b.enums[key] = enum
+	}
+
+	typ.oneOf = enum
+	b.paths[path] = typ
+	return nil
+}
+
+func (b *pathBuilder) buildField(curr string, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		// encoding/json allocates and follows pointers, so on the wire they look just like the value they point to
+		t = t.Elem()
+	}
+
+	if leafType, ok := getLeafType(t); ok {
+		if t == timeType && b.timeLayout != "" {
+			leafType = pathType{json: gjson.String, layout: b.timeLayout}
+		}
+
+		b.paths[curr] = leafType
+		return nil
+	}
+
+	if _, ok := b.implementations[t]; ok {
+		// The concrete type is only resolved after the candidate, so for now anything goes
+		b.paths[curr] = pathType{json: anyJSONType}
+		return nil
+	}
+
 	jsonType, err := getJSONType(t)
+	if errors.Is(err, ErrUnsupportedType) && b.lenient {
+		b.logger.Warnw("skipping field", zap.String("path", curr), zap.Error(err))
+		return nil
+	}
+
 	if err != nil {
 		return err
 	}
 
 	if jsonType == gjson.True || jsonType == gjson.False {
 		// Booleans are constants in JSON, but a type in Go. We don't care about what value it has, just the type, so
-		// we'll accept either constant True or False
-		paths[curr] = gjson.True
-		paths[curr] = gjson.False
+		// we store True and accept either constant True or False when matching
+		b.paths[curr] = pathType{json: gjson.True, coerce: b.coerce}
 		return nil
 	}
 
 	if jsonType != gjson.JSON {
-		paths[curr] = jsonType
+		b.paths[curr] = pathType{json: jsonType, coerce: b.coerce}
 		return nil
 	}
 
 	if t.Kind() == reflect.Array || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
 		// We can't validate the type yet, since JSON does not distinction between all of this. We'll give the parser
 		// the final say
-		paths[curr] = gjson.JSON
+		b.paths[curr] = pathType{json: gjson.JSON}
+		return nil
+	}
+
+	if b.visiting[t] {
+		// A recursive type, we only know there is an object here
+		b.paths[curr] = pathType{json: gjson.JSON}
 		return nil
 	}
 
-	for _, f := range reflect.VisibleFields(t) {
-		if !f.IsExported() {
+	return b.buildStruct(curr, t)
+}
+
+// makeFingerprints picks, for each candidate, a minimal set of paths such that every other candidate lacks at least
+// one of them. This is a set cover problem, so it's solved greedily: the path that tells apart the most remaining
+// rivals goes in first. Paths shared between candidates are still usable as long as the combination is unique
+//
+// Candidates with queries given by Fingerprint are told apart by those instead. They are checked first, and are no
+// rivals to the rest
+func makeFingerprints(candidates []*candidate, candidatePaths map[*candidate]jsonPaths, naming Naming) []fingerprint {
+	fingerprints := make([]fingerprint, 0, len(candidates))
+	rivals := make([]*candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if c.custom() {
+			fingerprints = append(fingerprints, fingerprint{candidate: c, all: candidatePaths[c]})
+			continue
+		}
+
+		rivals = append(rivals, c)
+	}
+
+	for _, c := range rivals {
+		fingerprints = append(fingerprints, makeFingerprint(c, rivals, candidatePaths, naming))
+	}
+
+	return fingerprints
+}
+
+func makeFingerprint(c *candidate, candidates []*candidate, candidatePaths map[*candidate]jsonPaths,
+	naming Naming) fingerprint {
+	paths := candidatePaths[c]
+	fp := fingerprint{
+		candidate: c,
+		paths:     make(jsonPaths),
+		all:       paths,
+	}
+
+	if c.strict {
+		fp.strict = newStrictShape(paths, naming)
+	}
+
+	// For each path, the rivals that don't have it, and so can be told apart by it
+	covers := make(map[string][]*candidate, len(paths))
+	uncovered := make(map[*candidate]bool, len(candidates))
+	for _, rival := range candidates {
+		if rival.typ == c.typ {
+			// Same candidate
 			continue
 		}
 
-		name := getJSONName(f)
-		if name == jsonIgnoreTag {
+		if !canBeConfused(c, rival) {
+			// Their versions already tell them apart
 			continue
 		}
 
-		err = buildPathsForField(paths, appendToPath(curr, name), f.Type)
-		if err != nil {
-			return err
+		uncovered[rival] = true
+		for path, typ := range paths {
+			if rivalType, ok := candidatePaths[rival][path]; !ok || rivalType.withoutAliases() != typ.withoutAliases() {
+				covers[path] = append(covers[path], rival)
+			}
 		}
 	}
 
-	return nil
-}
+	if len(uncovered) == 0 {
+		// There's nothing to tell the candidate apart from, but the payload should still look like it
+		fp.paths = paths
+		fp.anyOf = true
+		return fp
+	}
 
-// TODO Use a cache of encountered paths instead
-func makeUniquePaths(candidatePaths map[*candidate]jsonPaths) map[*candidate]jsonPaths {
-	// This is a very expensive operation, but we only do it once at the creation of the resolver
-	for candidateA, pathsA := range candidatePaths {
-		for pathA, typeA := range pathsA {
-			// We go thorough all candidates again, searching for duplicates
-			for candidateB, pathsB := range candidatePaths {
-				if candidateA.typ == candidateB.typ {
-					// Same candidate
-					continue
+	sorted := sortPaths(paths)
+	for len(uncovered) > 0 {
+		best, bestCount := "", 0
+		for _, path := range sorted {
+			count := 0
+			for _, rival := range covers[path] {
+				if uncovered[rival] {
+					count++
 				}
+			}
+
+			if count > bestCount {
+				best, bestCount = path, count
+			}
+		}
 
-				for pathB, typeB := range pathsB {
-					if pathA == pathB && typeA == typeB {
-						// This can end up deleting pathA multiple times, which is no-op
-						// That's fine since we want to delete dupes from all maps, not just the first one
-						delete(pathsA, pathA)
-						delete(pathsB, pathB)
-						break
-					}
+		if bestCount == 0 {
+			// The remaining rivals have every path this candidate has, so no combination will work
+			for _, rival := range candidates {
+				if uncovered[rival] {
+					fp.ambiguous = append(fp.ambiguous, rival)
 				}
 			}
+
+			fp.paths = nil
+			return fp
+		}
+
+		fp.paths[best] = paths[best]
+		for _, rival := range covers[best] {
+			delete(uncovered, rival)
 		}
 	}
 
-	return candidatePaths
+	return fp
 }
 
-func getJSONName(f reflect.StructField) string {
-	tag := f.Tag.Get("json")
-	if tag == jsonIgnoreTag {
-		return jsonIgnoreTag
+// sortPaths returns the paths shallowest first, and alphabetically within the same depth. Shallow paths are preferred
+// as fingerprints since they are less likely to be missing from a payload
+func sortPaths(paths jsonPaths) []string {
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		di, dj := strings.Count(sorted[i], "."), strings.Count(sorted[j], ".")
+		if di != dj {
+			return di < dj
+		}
+
+		return sorted[i] < sorted[j]
+	})
+
+	return sorted
+}
+
+func matchesJSONType(v gjson.Result, typ gjson.Type) bool {
+	if typ == anyJSONType {
+		return v.Exists()
+	}
+
+	if typ == gjson.True || typ == gjson.False {
+		return v.Type == gjson.True || v.Type == gjson.False
 	}
 
-	return f.Name
+	return v.Type == typ
+}
+
+// foreignTwin returns the rival the ambiguous fingerprint fp can't be told apart from because it has the very same
+// paths, while being declared in another package, if there's one. Those are usually copies of the same type, like
+// one generated from a schema and one written by hand, with only one of them meant to be registered
+func (r *traverseResolver) foreignTwin(fp fingerprint) *candidate {
+	if len(fp.ambiguous) == 0 || fp.candidate.dynamic != nil {
+		return nil
+	}
+
+	for _, rival := range fp.ambiguous {
+		if rival.dynamic != nil || rival.typ.PkgPath() == fp.candidate.typ.PkgPath() {
+			continue
+		}
+
+		for _, other := range r.fingerprints {
+			if other.candidate == rival && maps.Equal(other.all, fp.all) {
+				return rival
+			}
+		}
+	}
+
+	return nil
+}
+
+// fullTypeName names types along with the path of their package, to tell apart types named the same in different
+// packages
+func fullTypeName(t reflect.Type) string {
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+
+	return t.PkgPath() + "." + t.Name()
+}
+
+func typeNames(candidates []*candidate) string {
+	return strings.Join(candidateNames(candidates), ", ")
+}
+
+func candidateNames(candidates []*candidate) []string {
+	names := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		names = append(names, c.String())
+	}
+
+	return names
+}
+
+func typeName(typ gjson.Type) string {
+	switch typ {
+	case anyJSONType:
+		return "Any"
+	case gjson.True, gjson.False:
+		return "Boolean"
+	default:
+		return typ.String()
+	}
 }
 
 func getJSONType(t reflect.Type) (gjson.Type, error) {
@@ -220,24 +871,11 @@ func getJSONType(t reflect.Type) (gjson.Type, error) {
 	}
 }
 
-func appendToPath(path, name string) string {
-	name = normalizeName(name)
+func appendToPath(path, name string, naming Naming) string {
+	name = naming.key(name)
 	if len(path) == 0 || strings.HasSuffix(path, ".") {
 		return path + name
 	}
 
 	return path + "." + name
 }
-
-func normalizeName(name string) string {
-	const cutset = " _-"
-	return cutsetString(strings.ToLower(name), cutset)
-}
-
-func cutsetString(s, cutset string) string {
-	for _, c := range strings.Split(cutset, "") {
-		s = strings.ReplaceAll(s, c, "")
-	}
-
-	return s
-}
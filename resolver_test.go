@@ -0,0 +1,138 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type fpOrder struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+type fpShipment struct {
+	ID      string `json:"id"`
+	Carrier string `json:"carrier"`
+}
+
+type fpLabel struct {
+	Carrier string `json:"carrier"`
+	Total   int    `json:"total"`
+}
+
+type fpCount struct {
+	ID    string `json:"id"`
+	Total string `json:"total"`
+}
+
+type fpOnlyID struct {
+	ID string `json:"id"`
+}
+
+// fingerprintPaths returns the paths of the fingerprint of each candidate of u, by the name of its type
+func fingerprintPaths(t *testing.T, u *Unmarshaler) map[string][]string {
+	t.Helper()
+
+	s := u.load()
+	err := s.ready()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fps := make(map[string][]string)
+	for _, fp := range s.resolver.(*traverseResolver).fingerprints {
+		fps[fp.candidate.typ.Name()] = sortPaths(fp.paths)
+	}
+
+	return fps
+}
+
+func TestFingerprints(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []any
+		want       map[string][]string
+	}{
+		{
+			name:       "unique paths",
+			candidates: []any{fpOrder{}, fpShipment{}},
+			want:       map[string][]string{"fpOrder": {"total"}, "fpShipment": {"carrier"}},
+		},
+		{
+			name:       "shared paths combined",
+			candidates: []any{fpOrder{}, fpShipment{}, fpLabel{}},
+			want: map[string][]string{
+				"fpOrder":    {"id", "total"},
+				"fpShipment": {"carrier", "id"},
+				"fpLabel":    {"carrier", "total"},
+			},
+		},
+		{
+			name:       "same path with another type",
+			candidates: []any{fpOrder{}, fpCount{}},
+			want:       map[string][]string{"fpOrder": {"total"}, "fpCount": {"total"}},
+		},
+		{
+			name:       "no rivals",
+			candidates: []any{fpOrder{}},
+			want:       map[string][]string{"fpOrder": {"id", "total"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params []Parameter
+			for _, c := range tt.candidates {
+				params = append(params, Candidate(c))
+			}
+
+			u, err := New(params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := fingerprintPaths(t, u); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fingerprints = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprintsUnreachable(t *testing.T) {
+	u, err := New(Candidate(fpOnlyID{}), Candidate(fpOrder{}))
+	if err == nil {
+		err = u.load().ready()
+	}
+
+	if !errors.Is(err, ErrUnreachable) {
+		t.Errorf("New() error = %v, want %v", err, ErrUnreachable)
+	}
+}
+
+func TestResolveWithoutRivals(t *testing.T) {
+	u, err := New(Candidate(fpOrder{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr error
+	}{
+		{"every path", `{"id":"a","total":1}`, nil},
+		{"one path", `{"total":1}`, nil},
+		{"no known path", `{"other":1}`, ErrNoMatch},
+		{"known path with another type", `{"total":"1"}`, ErrNoMatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
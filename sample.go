@@ -0,0 +1,159 @@
+package turnip
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// sampleTime is the time written in samples, whatever the layout or format
+var sampleTime = time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+// GenerateSample is Unmarshaler.GenerateSample for an Unmarshaler with v as its only candidate. The parameters are
+// given to New along with it, for the Naming, the format or anything else that changes the paths
+func GenerateSample(v any, params ...Parameter) ([]byte, error) {
+	u, err := New(append([]Parameter{Candidate(v)}, params...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.GenerateSample(v)
+}
+
+// GenerateSample returns a JSON document for the candidate of the type of v, built from its paths: every field the
+// candidate has is there, keyed as the Naming expects, with a value of the type, format, layout or oneof set it's
+// fingerprinted with. Nested structs are filled in, while slices and maps are left empty. It's meant as a starting
+// point for tests, docs and the corpora given to Simulate, so it's not checked against selectors nor against the
+// queries given by Fingerprint, and types that decode themselves only get a value of the right JSON type
+func (u *Unmarshaler) GenerateSample(v any) ([]byte, error) {
+	s := u.load()
+	err := s.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := s.resolver.(*traverseResolver)
+	if !ok {
+		return nil, fmt.Errorf("samples need the paths of the candidates, not a %T", s.resolver)
+	}
+
+	typ := reflect.TypeOf(v)
+	var paths jsonPaths
+	for _, fp := range r.fingerprints {
+		if fp.candidate.typ == typ {
+			paths = fp.all
+			break
+		}
+	}
+
+	if paths == nil {
+		return nil, fmt.Errorf("%s is not a candidate", typ)
+	}
+
+	sample := sampleObject(typ, "", paths, s.env.format, s.env.naming)
+	b, err := json.MarshalIndent(sample, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%w: marshal sample: %w", ErrInternal, err)
+	}
+
+	return b, nil
+}
+
+// sampleObject returns the sample of the struct t, keyed by the names of the fields as written in the struct, or in the
+// convention of the Naming if it splits words, since only keys following it match
+func sampleObject(t reflect.Type, curr string, paths jsonPaths, format *format, naming Naming) map[string]any {
+	obj := make(map[string]any)
+	for _, f := range wireFields(t, format) {
+		path := appendToPath(curr, f.name, naming)
+		key := f.name
+		if naming.splitsWords() {
+			key = naming.key(f.name)
+		}
+
+		ft := f.typ
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if typ, ok := paths[path]; ok {
+			obj[key] = sampleValue(typ, ft, f.quoted)
+			continue
+		}
+
+		if ft.Kind() == reflect.Struct && hasPathUnder(paths, path) {
+			obj[key] = sampleObject(ft, path, paths, format, naming)
+		}
+	}
+
+	return obj
+}
+
+// sampleValue returns a value matching typ for a field of type t
+func sampleValue(typ pathType, t reflect.Type, quoted bool) any {
+	switch {
+	case typ.oneOf != nil:
+		return strings.Split(typ.oneOf.key, "|")[0]
+	case typ.layout != "":
+		return sampleTime.Format(typ.layout)
+	case typ.format != "":
+		return sampleFormatted(typ.format)
+	case quoted && t.Kind() == reflect.String:
+		// encoding/json quotes strings twice
+		return strconv.Quote("example")
+	case quoted:
+		return fmt.Sprint(sampleValue(pathType{json: getQuotedJSONType(t)}, t, false))
+	case t == timeType:
+		return sampleTime.Format(time.RFC3339)
+	}
+
+	switch typ.json {
+	case gjson.String:
+		return "example"
+	case gjson.Number:
+		return 1
+	case gjson.True, gjson.False:
+		return true
+	case gjson.JSON:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			return []any{}
+		}
+
+		return map[string]any{}
+	default:
+		return nil
+	}
+}
+
+func sampleFormatted(format string) any {
+	switch format {
+	case formatRFC3339:
+		return sampleTime.Format(time.RFC3339)
+	case formatDate:
+		return sampleTime.Format(time.DateOnly)
+	case formatUnix:
+		return sampleTime.Unix()
+	case formatUnixMilli:
+		return sampleTime.UnixMilli()
+	case formatUnixMicro:
+		return sampleTime.UnixMicro()
+	case formatUnixNano:
+		return sampleTime.UnixNano()
+	default:
+		return nil
+	}
+}
+
+// getQuotedJSONType is the type of the value quoted by a field with the string option
+func getQuotedJSONType(t reflect.Type) gjson.Type {
+	typ, err := getJSONType(t)
+	if err != nil {
+		return gjson.String
+	}
+
+	return typ
+}
@@ -0,0 +1,139 @@
+package turnip
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type sampleAddress struct {
+	Street string `json:"street"`
+	Zip    int    `json:"zip"`
+}
+
+type sampleManager struct {
+	Name string `json:"name"`
+}
+
+type sampleContact struct {
+	FullName    string
+	HomeAddress sampleAddress
+}
+
+type sampleCustomer struct {
+	ID      string        `json:"id"`
+	Address sampleAddress `json:"address"`
+}
+
+type sampleTeam struct {
+	ID      string   `json:"id"`
+	Members []string `json:"members"`
+}
+
+type sampleAccount struct {
+	ID      string         `json:"id"`
+	Manager *sampleManager `json:"manager"`
+}
+
+type sampleRival struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+}
+
+func TestGenerateSample(t *testing.T) {
+	tests := []struct {
+		name   string
+		v      any
+		params []Parameter
+		want   string
+	}{
+		{
+			name:   "nested",
+			v:      sampleCustomer{},
+			params: []Parameter{Candidate(sampleRival{})},
+			want:   `{"id":"example","address":{"street":"example","zip":1}}`,
+		},
+		{
+			name:   "slice",
+			v:      sampleTeam{},
+			params: []Parameter{Candidate(sampleRival{})},
+			want:   `{"id":"example","members":[]}`,
+		},
+		{
+			name:   "pointer",
+			v:      sampleAccount{},
+			params: []Parameter{Candidate(sampleRival{})},
+			want:   `{"id":"example","manager":{"name":"example"}}`,
+		},
+		{
+			name: "any of",
+			v:    sampleCustomer{},
+			want: `{"id":"example","address":{"street":"example","zip":1}}`,
+		},
+		{
+			name:   "snake case",
+			v:      sampleContact{},
+			params: []Parameter{SnakeCase},
+			want:   `{"full_name":"example","home_address":{"street":"example","zip":1}}`,
+		},
+		{
+			name:   "camel case",
+			v:      sampleContact{},
+			params: []Parameter{CamelCase},
+			want:   `{"fullName":"example","homeAddress":{"street":"example","zip":1}}`,
+		},
+		{
+			name: "names as written",
+			v:    sampleContact{},
+			want: `{"FullName":"example","HomeAddress":{"street":"example","zip":1}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := GenerateSample(tt.v, tt.params...)
+			if err != nil {
+				t.Fatalf("GenerateSample() error = %v", err)
+			}
+
+			var got, want any
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("GenerateSample() = %s, not JSON: %v", b, err)
+			}
+
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GenerateSample() = %s, want %s", b, tt.want)
+			}
+
+			// Samples resolve back to the candidate they were generated for
+			u, err := New(append([]Parameter{Candidate(tt.v)}, tt.params...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := u.UnmarshalJSON(b)
+			if err != nil {
+				t.Fatalf("UnmarshalJSON() of the sample error = %v", err)
+			}
+
+			if typ := reflect.TypeOf(v).Elem(); typ != reflect.TypeOf(tt.v) {
+				t.Errorf("UnmarshalJSON() of the sample = %s, want %s", typ, reflect.TypeOf(tt.v))
+			}
+		})
+	}
+}
+
+func TestGenerateSampleNotCandidate(t *testing.T) {
+	u, err := New(Candidate(sampleCustomer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := u.GenerateSample(sampleTeam{}); err == nil {
+		t.Error("GenerateSample() of a type that's not a candidate error = nil")
+	}
+}
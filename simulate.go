@@ -0,0 +1,94 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// SimulationReport is how a corpus of payloads resolves, as returned by Simulate
+type SimulationReport struct {
+	// Total is the number of payloads in the corpus
+	Total int
+	// Matches counts the payloads resolved to each type, either by a selector or a candidate
+	Matches map[reflect.Type]int
+	// NoMatch holds the indexes of the payloads that resolved to nothing. They would be left to the defaults
+	NoMatch []int
+	// Malformed holds the indexes of the payloads that are not JSON objects
+	Malformed []int
+	// Overlaps holds the payloads matching more than one selector or candidate. They resolve to the first one, but
+	// only because of the order of the parameters
+	Overlaps []Overlap
+}
+
+// Overlap is a payload that matches more than one selector or candidate
+type Overlap struct {
+	// Index is the index of the payload in the corpus
+	Index int
+	// Types are the types matched, the first one being the one resolved to
+	Types []reflect.Type
+}
+
+// MatchRate is the share of the corpus resolved to typ
+func (r *SimulationReport) MatchRate(typ reflect.Type) float64 {
+	if r.Total == 0 {
+		return 0
+	}
+
+	return float64(r.Matches[typ]) / float64(r.Total)
+}
+
+// Simulate resolves a corpus of payloads, usually taken from real traffic, and reports how they were resolved. Nothing
+// is decoded, so it's cheap enough to validate a set of candidates before deploying it. Resolver errors stop the
+// simulation, since they are a problem of the Unmarshaler rather than of the payloads
+func (u *Unmarshaler) Simulate(payloads [][]byte) (*SimulationReport, error) {
+	s := u.load()
+	err := s.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SimulationReport{
+		Total:   len(payloads),
+		Matches: make(map[reflect.Type]int),
+	}
+
+	for i, b := range payloads {
+		_, res, err := s.parse(b)
+		if errors.Is(err, ErrMalformed) {
+			report.Malformed = append(report.Malformed, i)
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("payload %d: %w", i, err)
+		}
+
+		q := &query{res: res}
+		typ, err := s.resolve(q)
+		if err != nil {
+			return nil, fmt.Errorf("%w: payload %d: resolve: %w", ErrInternal, i, err)
+		}
+
+		if typ == nil {
+			report.NoMatch = append(report.NoMatch, i)
+			continue
+		}
+
+		report.Matches[typ]++
+
+		all, err := s.resolveAll(q)
+		if err != nil {
+			return nil, fmt.Errorf("%w: payload %d: resolve: %w", ErrInternal, i, err)
+		}
+
+		if len(all) > 1 {
+			report.Overlaps = append(report.Overlaps, Overlap{
+				Index: i,
+				Types: all,
+			})
+		}
+	}
+
+	return report, nil
+}
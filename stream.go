@@ -0,0 +1,404 @@
+package turnip
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// Decoder reads JSON documents one after the other from a stream, like API logs or journald dumps, and resolves each
+// one on its own. Documents can be separated by whitespace or nothing at all
+type Decoder struct {
+	u   *Unmarshaler
+	dec *json.Decoder
+	// lazy decompresses the stream, with EnableDecompression
+	lazy *lazyDecompressor
+}
+
+// NewDecoder returns a Decoder reading from r. With EnableDecompression, the stream may be compressed as a whole
+func (u *Unmarshaler) NewDecoder(r io.Reader) *Decoder {
+	d := &Decoder{u: u}
+	if s := u.load(); s.settings.Get(enableDecompression) {
+		d.lazy = &lazyDecompressor{r: r, limit: s.env.decompressionLimit}
+		r = d.lazy
+	}
+
+	d.dec = json.NewDecoder(r)
+	return d
+}
+
+// Decode reads the next document and unmarshals it as UnmarshalJSON would. It returns io.EOF once there are no more
+// documents. Documents that fail to resolve or decode don't stop the stream, the next call moves on to the following
+// one, but malformed JSON does
+func (d *Decoder) Decode() (any, error) {
+	if d.lazy != nil {
+		d.lazy.resetLimit()
+	}
+
+	var raw json.RawMessage
+	err := d.dec.Decode(&raw)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	return d.u.UnmarshalJSON(raw)
+}
+
+// More reports whether there is another document in the stream
+func (d *Decoder) More() bool {
+	return d.dec.More()
+}
+
+// UnmarshalReader unmarshals a single document from r, resolving it from only its first prefix bytes. The rest of the
+// document is streamed into the decoder afterwards, so large bodies don't need to be buffered before knowing their
+// type. If the prefix is not enough to resolve the document, it's read whole and resolved as usual.
+//
+// The prefix is only enough when what follows it can't change the resolution: every key looked at by the selectors
+// checked must be in the prefix with its whole value, as must one making each fingerprint checked before the one
+// matching fail. Selectors looking for missing paths, with Not or Exists, and candidates checked by Strict, Match or
+// other path engines, always need the whole document.
+//
+// Documents that need more than encoding/json to be decoded, because of EnableJSONC, UTF-16, registered
+// Implementations, a TimeLayout, coercions, aliases, a Naming that splits words, defaults, redacted fields, dynamic
+// candidates, tuples or UseMapstructure, are always read whole
+func (u *Unmarshaler) UnmarshalReader(r io.Reader, prefix int) (any, error) {
+	return u.load().unmarshalReader(r, prefix)
+}
+
+func (u *unmarshaler) unmarshalReader(r io.Reader, prefix int) (any, error) {
+	err := u.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	if u.settings.Get(enableDecompression) {
+		r, err = decompressReader(r, u.env.decompressionLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	head := make([]byte, prefix)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	head = head[:n]
+	if n < prefix || !u.canStream(head) {
+		return u.unmarshalRest(head, r)
+	}
+
+	typ, err := u.resolveHead(head)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ == nil || !u.streamsInto(typ) {
+		return u.unmarshalRest(head, r)
+	}
+
+	return u.streamRest(head, r, typ)
+}
+
+// resolveHead resolves the document starting with head from it alone, returning nil if it's not enough. Only the
+// members of the object complete in head are looked at, and the type is only returned if nothing that may follow
+// them could change it
+func (u *unmarshaler) resolveHead(head []byte) (reflect.Type, error) {
+	head = bytes.TrimPrefix(head, bomUTF8)
+	if len(bytes.TrimSpace(head)) == 0 {
+		return nil, nil
+	}
+
+	res, err := closeHead(head)
+	if err != nil {
+		return nil, err
+	}
+
+	// Other resolvers can't be told what they would look at
+	r, ok := u.resolver.(*traverseResolver)
+	if !ok {
+		return nil, nil
+	}
+
+	q := &query{res: res}
+	typ, err := u.resolve(q)
+	if err != nil {
+		return nil, fmt.Errorf("%w: resolve: %w", ErrInternal, err)
+	}
+
+	if typ == nil || !r.settles(res, typ) {
+		return nil, nil
+	}
+
+	if u.streamsInto(typ) {
+		// The rest is resolved and warned about as usual otherwise
+		u.warnResolved(q, typ)
+	}
+
+	return typ, nil
+}
+
+// closeHead returns the object starting with head, the beginning of a document, with only the members whose values are
+// complete in head. A value is only complete once what follows it is in head too, so numbers cut short are not
+func closeHead(head []byte) (gjson.Result, error) {
+	res := gjson.ParseBytes(head)
+	if !res.IsObject() {
+		return gjson.Result{}, fmt.Errorf("%w: not an object", ErrMalformed)
+	}
+
+	closed := []byte{'{'}
+	res.ForEach(func(key, value gjson.Result) bool {
+		end := value.Index + len(value.Raw)
+		if value.Index == 0 || !completeAt(head, end) || !gjson.Valid(value.Raw) {
+			return false
+		}
+
+		if len(closed) > 1 {
+			closed = append(closed, ',')
+		}
+
+		closed = append(closed, key.Raw...)
+		closed = append(closed, ':')
+		closed = append(closed, value.Raw...)
+		return true
+	})
+
+	return gjson.ParseBytes(append(closed, '}')), nil
+}
+
+// completeAt reports whether the value of a member ending at end is followed by the next member or the end of the
+// object in head
+func completeAt(head []byte, end int) bool {
+	rest := bytes.TrimLeft(head[end:], " \t\r\n")
+	return len(rest) > 0 && (rest[0] == ',' || rest[0] == '}')
+}
+
+// settles reports whether the type typ, that the object res resolved to, can't change with members that may follow
+// those of res. Selectors may match on anything, Not and missing paths included, so every key looked at by those
+// checked must be in res. Fingerprints only match on what's there, but those checked before the one of typ must fail
+// on what's there too, which says nothing about the rest
+func (r *traverseResolver) settles(res gjson.Result, typ reflect.Type) bool {
+	keys := make(map[string]bool)
+	folded := make(map[string]bool)
+	res.ForEach(func(key, _ gjson.Result) bool {
+		keys[key.String()] = true
+		if wire, ok := r.env.naming.wireKey(key.String()); ok {
+			folded[wire] = true
+		}
+
+		return true
+	})
+
+	q := &query{res: res}
+	for _, s := range r.env.selectors {
+		for _, path := range s.cond.paths() {
+			key, ok := queryKey(path)
+			if !ok || !keys[key] {
+				return false
+			}
+		}
+
+		if s.cond.matches(q) {
+			return s.typ == typ
+		}
+	}
+
+	for _, fp := range r.fingerprints {
+		if fp.candidate.typ == typ {
+			return fp.matches(res) && fp.settled()
+		}
+
+		if !fp.failsOn(res, keys, folded) {
+			return false
+		}
+	}
+
+	// Fuzzy matches depend on every path
+	return false
+}
+
+// settled reports whether a fingerprint matching an object keeps matching whatever members follow. Strict candidates
+// don't take unknown members, and the functions of Match and queries of other path engines look at the whole object
+func (f fingerprint) settled() bool {
+	c := f.candidate
+	if f.strict != nil || len(c.matchers) > 0 || c.engine != "" {
+		return false
+	}
+
+	for _, q := range c.queries {
+		if _, ok := queryKey(q); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// failsOn reports whether the fingerprint doesn't match the object res, whatever members follow. That's so when
+// something it looks for is in res, with keys and folded having its keys as they are and as put by the Naming, and
+// isn't what it needs to be
+func (f fingerprint) failsOn(res gjson.Result, keys, folded map[string]bool) bool {
+	c := f.candidate
+	if len(f.ambiguous) > 0 {
+		return true
+	}
+
+	if c.version != nil {
+		key, ok := queryKey(c.version.path)
+		if ok && keys[key] && !c.version.matches(res) {
+			return true
+		}
+	}
+
+	if c.custom() {
+		if c.engine != "" {
+			return false
+		}
+
+		for _, q := range c.queries {
+			if key, ok := queryKey(q); ok && keys[key] && !res.Get(q).Exists() {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	failed := 0
+	for path, typ := range f.paths {
+		if !typ.settledIn(path, folded) {
+			continue
+		}
+
+		if !typ.matches(typ.get(res, path)) {
+			if !f.anyOf {
+				return true
+			}
+
+			failed++
+		}
+	}
+
+	return f.anyOf && failed == len(f.paths)
+}
+
+// settledIn reports whether the value at path can't change with members following those in folded, the keys of an
+// object put by the Naming. That's so when the first keys of the path and its aliases are all there
+func (p pathType) settledIn(path string, folded map[string]bool) bool {
+	key, _, _ := strings.Cut(path, ".")
+	if !folded[key] {
+		return false
+	}
+
+	if p.aliases != nil {
+		for _, alias := range p.aliases.paths {
+			key, _, _ := strings.Cut(alias, ".")
+			if !folded[key] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// streamsInto reports whether documents resolved to typ can be decoded as they are read
+func (u *unmarshaler) streamsInto(typ reflect.Type) bool {
+	c := u.candidates[typ]
+	return !(c != nil && (c.decode.timeLayout != "" || c.dynamic != nil || len(c.mapped) > 0) || u.withDefaults[typ] ||
+		u.withAliases[typ] || u.redacts(typ) || len(u.env.rewriteHooks[typ]) > 0)
+}
+
+// streamRest decodes the document starting with head, and following in r, into typ, and records it
+func (u *unmarshaler) streamRest(head []byte, r io.Reader, typ reflect.Type) (any, error) {
+	start := time.Now()
+	counter := &countingReader{r: r}
+	v, err := u.stream(head, counter, typ, u.candidates[typ])
+	if err == nil {
+		v, err = u.env.normalizeHooks.apply(v, typ)
+	}
+
+	if err == nil {
+		v, err = u.env.migrations.migrate(v)
+	}
+
+	u.record(u.env.logger, start, head, len(head)+counter.n, v, err)
+	return v, err
+}
+
+// stream decodes the document starting with head, and following in r, into typ as it's read
+func (u *unmarshaler) stream(head []byte, r io.Reader, typ reflect.Type, c *candidate) (any, error) {
+	head = bytes.TrimPrefix(head, bomUTF8)
+	dec := json.NewDecoder(io.MultiReader(bytes.NewReader(head), r))
+	if c != nil && c.decode.useNumber {
+		dec.UseNumber()
+	}
+
+	if c != nil && c.decode.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	v := reflect.New(typ)
+	err := dec.Decode(v.Interface())
+	if err != nil {
+		// Only the head is at hand, so errors past it go without a line
+		return nil, decodeError(err, head)
+	}
+
+	if u.env.validation != nil {
+		err = u.env.validation.check(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return v.Interface(), nil
+}
+
+// canStream reports whether the document starting with head can be decoded as it comes
+func (u *unmarshaler) canStream(head []byte) bool {
+	if u.settings.Get(enableJSONC) || u.env.tuples || len(u.interfaces) > 0 || !u.env.coerce.isZero() ||
+		u.env.naming.splitsWords() || u.env.mapstructure != nil {
+		return false
+	}
+
+	return len(toUTF8(head)) == len(bytes.TrimPrefix(head, bomUTF8))
+}
+
+func (u *unmarshaler) unmarshalRest(head []byte, r io.Reader) (any, error) {
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	return u.unmarshal(append(head, rest...), &query{})
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
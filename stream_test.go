@@ -0,0 +1,321 @@
+package turnip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type streamUser struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type streamDeleted struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	DeletedAt string `json:"deleted_at"`
+}
+
+type streamV1 struct {
+	Version int    `json:"version"`
+	Body    string `json:"body"`
+}
+
+type streamV2 struct {
+	Version int    `json:"version"`
+	Body    string `json:"body"`
+}
+
+type streamOrder struct {
+	ID    int     `json:"id"`
+	Total float64 `json:"total"`
+}
+
+type streamRefund struct {
+	ID     int    `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// streamCases are Unmarshalers and payloads whose resolution depends on what comes after any cut
+var streamCases = []struct {
+	name     string
+	params   []Parameter
+	payloads []string
+}{
+	{
+		name: "absent path",
+		params: []Parameter{
+			SelectWhen(And(Eq("type", "user"), Not(Exists("deleted_at"))), streamUser{}),
+			Candidate(streamDeleted{}),
+		},
+		payloads: []string{
+			`{"type":"user","name":"ann","deleted_at":"2024-01-01"}`,
+			`{"type":"user","name":"ann"}`,
+			`{"deleted_at":"2024-01-01","type":"user","name":"ann"}`,
+		},
+	},
+	{
+		name:   "number cut short",
+		params: []Parameter{Version(streamV1{}, "<100"), Version(streamV2{}, ">=100")},
+		payloads: []string{
+			`{"version":123,"body":"hello"}`,
+			`{"version":12,"body":"hello"}`,
+			`{"body":"hello","version":123}`,
+		},
+	},
+	{
+		name:   "fingerprints in order",
+		params: []Parameter{Candidate(streamOrder{}), Candidate(streamRefund{})},
+		payloads: []string{
+			`{"id":1,"total":9.5}`,
+			`{"id":1,"reason":"damaged"}`,
+			`{"id":1,"reason":"damaged","total":9.5}`,
+			`{"reason":"damaged","id":1}`,
+			`  {"id" : 1 , "total" : 9.5 }  `,
+		},
+	},
+	{
+		name:   "string cut short",
+		params: []Parameter{SelectWhen(Eq("type", "user"), streamUser{}), Candidate(streamDeleted{})},
+		payloads: []string{
+			`{"type":"user-admin","name":"ann","deleted_at":"x"}`,
+			`{"type":"user","name":"ann","deleted_at":"x"}`,
+		},
+	},
+	{
+		name:   "nested values",
+		params: []Parameter{SelectWhen(Eq("meta.kind", "user"), streamUser{}), Candidate(streamDeleted{})},
+		payloads: []string{
+			`{"meta":{"kind":"user","extra":[1,2,3]},"name":"ann"}`,
+			`{"meta":{"kind":"admin"},"type":"x","name":"ann","deleted_at":"y"}`,
+		},
+	},
+}
+
+// sameOutcome fails if the value or error of a call differ from those of UnmarshalJSON
+func sameOutcome(t *testing.T, call string, want, got any, wantErr, gotErr error) {
+	t.Helper()
+
+	if (wantErr == nil) != (gotErr == nil) {
+		t.Errorf("%s error = %v, UnmarshalJSON error = %v", call, gotErr, wantErr)
+		return
+	}
+
+	for _, category := range []error{ErrMalformed, ErrNoMatch, ErrDecode} {
+		if errors.Is(wantErr, category) != errors.Is(gotErr, category) {
+			t.Errorf("%s error = %v, UnmarshalJSON error = %v", call, gotErr, wantErr)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("%s = %#v, UnmarshalJSON = %#v", call, got, want)
+	}
+}
+
+func TestUnmarshalReaderPrefixes(t *testing.T) {
+	for _, tt := range streamCases {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, payload := range tt.payloads {
+				want, wantErr := u.UnmarshalJSON([]byte(payload))
+				for prefix := 0; prefix <= len(payload)+1; prefix++ {
+					got, gotErr := u.UnmarshalReader(bytes.NewReader([]byte(payload)), prefix)
+					sameOutcome(t, fmt.Sprintf("UnmarshalReader(%s, %d)", payload, prefix), want, got, wantErr, gotErr)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveHead(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []Parameter
+		head   string
+		want   reflect.Type
+	}{
+		{
+			name:   "selector on complete value",
+			params: []Parameter{SelectWhen(Eq("type", "user"), streamUser{}), Candidate(streamDeleted{})},
+			head:   `{"type":"user","name":"an`,
+			want:   reflect.TypeOf(streamUser{}),
+		},
+		{
+			name:   "selector on value cut short",
+			params: []Parameter{SelectWhen(Eq("type", "user"), streamUser{}), Candidate(streamDeleted{})},
+			head:   `{"type":"user"`,
+		},
+		{
+			name: "selector looking for a missing path",
+			params: []Parameter{
+				SelectWhen(And(Eq("type", "user"), Not(Exists("deleted_at"))), streamUser{}),
+				Candidate(streamDeleted{}),
+			},
+			head: `{"type":"user","name":"ann","del`,
+		},
+		{
+			name:   "version cut short",
+			params: []Parameter{Version(streamV1{}, "<100"), Version(streamV2{}, ">=100")},
+			head:   `{"version":12`,
+		},
+		{
+			name:   "version complete",
+			params: []Parameter{Version(streamV1{}, "<100"), Version(streamV2{}, ">=100")},
+			head:   `{"version":123,"bo`,
+			want:   reflect.TypeOf(streamV2{}),
+		},
+		{
+			name:   "first fingerprint",
+			params: []Parameter{Candidate(streamOrder{}), Candidate(streamRefund{})},
+			head:   `{"id":1,"total":9.5,"`,
+			want:   reflect.TypeOf(streamOrder{}),
+		},
+		{
+			name:   "fingerprint after one that may still match",
+			params: []Parameter{Candidate(streamOrder{}), Candidate(streamRefund{})},
+			head:   `{"id":1,"reason":"damaged",`,
+		},
+		{
+			name:   "fingerprint after one that can't match",
+			params: []Parameter{Candidate(streamOrder{}), Candidate(streamRefund{})},
+			head:   `{"id":1,"total":"none","reason":"damaged",`,
+			want:   reflect.TypeOf(streamRefund{}),
+		},
+		{
+			name:   "whitespace",
+			params: []Parameter{Candidate(streamOrder{}), Candidate(streamRefund{})},
+			head:   `  `,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := u.load()
+			err = s.ready()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.resolveHead([]byte(tt.head))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("resolveHead(%s) = %v, want %v", tt.head, got, tt.want)
+			}
+		})
+	}
+}
+
+// onlyReader hides every method of a reader but Read, so it can't be read past what's asked
+type onlyReader struct {
+	r io.Reader
+}
+
+func (r onlyReader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func TestUnmarshalReaderStreams(t *testing.T) {
+	u, err := New(SelectWhen(Eq("type", "user"), streamUser{}), Candidate(streamDeleted{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Resolved from the head alone, the rest is decoded as it's read
+	payload := []byte(`{"type":"user","name":"ann"}`)
+	v, err := u.UnmarshalReader(onlyReader{bytes.NewReader(payload)}, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (&streamUser{Type: "user", Name: "ann"}); !reflect.DeepEqual(v, want) {
+		t.Errorf("UnmarshalReader() = %#v, want %#v", v, want)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	type decoded struct {
+		v   any
+		err error
+	}
+
+	tests := []struct {
+		name   string
+		stream string
+		want   []decoded
+	}{
+		{
+			name:   "concatenated",
+			stream: `{"id":1,"total":9.5}{"id":2,"reason":"damaged"}`,
+			want:   []decoded{{v: &streamOrder{ID: 1, Total: 9.5}}, {v: &streamRefund{ID: 2, Reason: "damaged"}}},
+		},
+		{
+			name:   "one per line",
+			stream: "{\"id\":1,\"total\":9.5}\n{\"id\":2,\"reason\":\"damaged\"}\n",
+			want:   []decoded{{v: &streamOrder{ID: 1, Total: 9.5}}, {v: &streamRefund{ID: 2, Reason: "damaged"}}},
+		},
+		{
+			name:   "no match in between",
+			stream: `{"id":1,"total":9.5} {"other":1} {"id":2,"reason":"damaged"}`,
+			want: []decoded{
+				{v: &streamOrder{ID: 1, Total: 9.5}},
+				{err: ErrNoMatch},
+				{v: &streamRefund{ID: 2, Reason: "damaged"}},
+			},
+		},
+		{
+			name:   "malformed",
+			stream: `{"id":1,"total":9.5} {"id":}`,
+			want:   []decoded{{v: &streamOrder{ID: 1, Total: 9.5}}, {err: ErrMalformed}},
+		},
+		{
+			name:   "empty",
+			stream: "  \n",
+		},
+	}
+
+	u, err := New(Candidate(streamOrder{}), Candidate(streamRefund{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := u.NewDecoder(bytes.NewReader([]byte(tt.stream)))
+			for i, want := range tt.want {
+				got, err := d.Decode()
+				if !errors.Is(err, want.err) {
+					t.Fatalf("Decode() #%d error = %v, want %v", i, err, want.err)
+				}
+
+				if err == nil && !reflect.DeepEqual(got, want.v) {
+					t.Errorf("Decode() #%d = %#v, want %#v", i, got, want.v)
+				}
+			}
+
+			if n := len(tt.want); n > 0 && errors.Is(tt.want[n-1].err, ErrMalformed) {
+				return
+			}
+
+			if _, err := d.Decode(); err != io.EOF {
+				t.Errorf("Decode() at the end error = %v, want %v", err, io.EOF)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package turnip
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// StrictMatching makes every candidate strict, as if it had been declared with Strict
+func StrictMatching() Parameter {
+	return strictMatching
+}
+
+// makeStrict makes the candidate strict, as StrictMatching does
+func (c *candidate) makeStrict() error {
+	switch {
+	case c.tuple:
+		return invalidParameter(strictMatching, "%s is a tuple, and can't be strict", c.typ)
+	case len(c.mappings) > 0:
+		return invalidParameter(strictMatching, "%s has mapped fields, and can't be strict", c.typ)
+	}
+
+	c.strict = true
+	c.decode.disallowUnknownFields = true
+	return nil
+}
+
+// strictShape is the whole shape of a strict candidate, which payloads must follow exactly
+type strictShape struct {
+	paths jsonPaths
+	// known holds the paths, along with their aliases
+	known map[string]bool
+	// objects holds the paths of the nested structs, whose keys are checked too
+	objects map[string]bool
+	// naming puts the keys of the payload in the form of the paths
+	naming Naming
+}
+
+func newStrictShape(paths jsonPaths, naming Naming) *strictShape {
+	s := &strictShape{
+		paths:   paths,
+		naming:  naming,
+		known:   make(map[string]bool, len(paths)),
+		objects: make(map[string]bool),
+	}
+
+	for path, typ := range paths {
+		if typ.aliases != nil {
+			for _, alias := range typ.aliases.paths {
+				s.addKnown(alias)
+			}
+		}
+
+		s.addKnown(path)
+	}
+
+	return s
+}
+
+// addKnown adds a path the payload may have, and the objects holding it
+func (s *strictShape) addKnown(path string) {
+	s.known[path] = true
+	for i := strings.LastIndex(path, "."); i > 0; i = strings.LastIndex(path, ".") {
+		path = path[:i]
+		s.objects[path] = true
+	}
+}
+
+func (s *strictShape) matches(res gjson.Result) bool {
+	for path, typ := range s.paths {
+		if !typ.matches(typ.get(res, path)) {
+			return false
+		}
+	}
+
+	return s.knowsKeys("", res)
+}
+
+// knowsKeys checks that every key of the object at curr is a path of the shape. Values of leaf paths aren't looked
+// into, so maps and slices can hold anything
+func (s *strictShape) knowsKeys(curr string, res gjson.Result) bool {
+	known := true
+	res.ForEach(func(key, value gjson.Result) bool {
+		path := appendToPath(curr, key.String(), s.naming)
+		if s.known[path] {
+			return true
+		}
+
+		if s.objects[path] && value.IsObject() && s.knowsKeys(path, value) {
+			return true
+		}
+
+		known = false
+		return false
+	})
+
+	return known
+}
@@ -0,0 +1,67 @@
+package turnip
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// Tuple declares a candidate sent as a positional array instead of an object, like ["user", 42, {"name": "x"}] as some
+// RPC and market data feeds do. Each element is decoded into the field at the same position, in the order the fields
+// are declared with the fields of embedded structs in their place, and extra elements are ignored.
+//
+// Arrays are written as objects keyed by the positions of their elements before resolving, so tuples are fingerprinted
+// by the types at each position and told apart from the other candidates as usual
+func Tuple(v any, opts ...DecodeOption) Parameter {
+	return &candidate{
+		typ:    reflect.TypeOf(v),
+		decode: newDecodeOptions(opts),
+		tuple:  true,
+	}
+}
+
+// tupleFields maps each field of the tuple candidate to its position in the array, which is written as an object
+// keyed by the positions before resolving
+func (c *candidate) tupleFields(format *format) []mappedField {
+	fields := wireFields(c.typ, format)
+	mapped := make([]mappedField, len(fields))
+	for i, f := range fields {
+		mapped[i] = mappedField{
+			keys: []string{strconv.Itoa(i)},
+			wire: []string{f.name},
+		}
+	}
+
+	return mapped
+}
+
+// indexArray writes the array res as an object keyed by the positions of its elements, for tuples to be resolved like
+// any other candidate
+func indexArray(res gjson.Result) []byte {
+	buf := []byte{'{'}
+	i := 0
+	res.ForEach(func(_, value gjson.Result) bool {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+
+		buf = strconv.AppendQuote(buf, strconv.Itoa(i))
+		buf = append(buf, ':')
+		buf = append(buf, value.Raw...)
+		i++
+		return true
+	})
+
+	return append(buf, '}')
+}
+
+// indexTuple turns arrays into objects keyed by the positions, if there are tuple candidates to resolve them
+func (u *unmarshaler) indexTuple(b []byte, res gjson.Result) ([]byte, gjson.Result) {
+	if !u.env.tuples || !res.IsArray() {
+		return b, res
+	}
+
+	b = indexArray(res)
+	return b, gjson.ParseBytes(b)
+}
@@ -0,0 +1,64 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type tupleTrade struct {
+	Symbol string  `json:"symbol"`
+	Price  float64 `json:"price"`
+	Size   int     `json:"size"`
+}
+
+type tupleMeta struct {
+	Venue string `json:"venue"`
+}
+
+type tupleQuote struct {
+	Symbol string
+	Bid    bool
+	tupleMeta
+}
+
+type tupleHeartbeat struct {
+	Seq int `json:"seq"`
+}
+
+func TestTuple(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    any
+		wantErr error
+	}{
+		{"positions", `["AAPL",1.5,10]`, &tupleTrade{Symbol: "AAPL", Price: 1.5, Size: 10}, nil},
+		{"extra elements", `["AAPL",1.5,10,"x"]`, &tupleTrade{Symbol: "AAPL", Price: 1.5, Size: 10}, nil},
+		{
+			name:    "embedded fields",
+			payload: `["AAPL",true,"NYSE"]`,
+			want:    &tupleQuote{Symbol: "AAPL", Bid: true, tupleMeta: tupleMeta{Venue: "NYSE"}},
+		},
+		{"object", `{"seq":1}`, &tupleHeartbeat{Seq: 1}, nil},
+		{"types at positions", `[1,"AAPL"]`, nil, ErrNoMatch},
+	}
+
+	u, err := New(Tuple(tupleTrade{}), Tuple(tupleQuote{}), Candidate(tupleHeartbeat{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Package turniptest helps testing code that depends on a turnip.Unmarshaler, by deciding what payloads resolve to
+// instead of crafting payloads that match the right fingerprints
+package turniptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/gjson"
+
+	"turnip"
+)
+
+// StaticResolver resolves payloads to the type under their JSON. Payloads and keys are compared without their
+// whitespace, so keys can be written in any layout. Payloads not in the map resolve to nothing, and are then left to
+// the defaults
+type StaticResolver map[string]reflect.Type
+
+// ResolveJSON implements turnip.Resolver
+func (r StaticResolver) ResolveJSON(res gjson.Result) (reflect.Type, error) {
+	payload := compact(res.Raw)
+	if typ, ok := r[payload]; ok {
+		return typ, nil
+	}
+
+	for key, typ := range r {
+		if compact(key) == payload {
+			return typ, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func compact(s string) string {
+	var buf bytes.Buffer
+	err := json.Compact(&buf, []byte(s))
+	if err != nil {
+		return s
+	}
+
+	return buf.String()
+}
+
+// New returns an Unmarshaler resolving with r, with every type in r as a candidate. The parameters are given to
+// turnip.New along with them, for defaults and anything else the code under test relies on
+func New(r StaticResolver, params ...turnip.Parameter) (*turnip.Unmarshaler, error) {
+	all := make([]turnip.Parameter, 0, len(r)+len(params)+1)
+	seen := make(map[reflect.Type]bool, len(r))
+	for _, typ := range r {
+		if typ == nil || seen[typ] {
+			continue
+		}
+
+		seen[typ] = true
+		all = append(all, turnip.Candidate(reflect.New(typ).Elem().Interface()))
+	}
+
+	all = append(all, params...)
+	all = append(all, turnip.ResolveWith(r))
+	return turnip.New(all...)
+}
+
+// MustNew is New for tests, failing t if the Unmarshaler can't be created
+func MustNew(t testing.TB, r StaticResolver, params ...turnip.Parameter) *turnip.Unmarshaler {
+	t.Helper()
+
+	u, err := New(r, params...)
+	if err != nil {
+		t.Fatalf("turniptest.New() error = %v", err)
+	}
+
+	return u
+}
+
+// AssertUnmarshals reports a failure to t unless u unmarshals payload into a value equal to want, as compared by
+// reflect.DeepEqual. It returns whether it did, so tests can stop on what depends on it
+func AssertUnmarshals(t testing.TB, u *turnip.Unmarshaler, payload string, want any) bool {
+	t.Helper()
+
+	got, err := u.UnmarshalJSON([]byte(payload))
+	if err != nil {
+		t.Errorf("UnmarshalJSON(%s) error = %v, want %#v", payload, err, want)
+		return false
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalJSON(%s) = %#v, want %#v", payload, got, want)
+		return false
+	}
+
+	return true
+}
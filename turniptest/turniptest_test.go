@@ -0,0 +1,164 @@
+package turniptest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"turnip"
+)
+
+type order struct {
+	ID string `json:"id"`
+}
+
+type refund struct {
+	ID string `json:"id"`
+}
+
+// fakeTB records the failures reported to it instead of failing the test
+type fakeTB struct {
+	testing.TB
+	failures []string
+	fatal    bool
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	f.fatal = true
+	runtime.Goexit()
+}
+
+// run calls fn with f in a goroutine of its own, which Fatalf stops as it would stop a test
+func (f *fakeTB) run(fn func(tb testing.TB)) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(f)
+	}()
+
+	<-done
+}
+
+var resolver = StaticResolver{
+	`{"id": "a"}`: reflect.TypeOf(order{}),
+	`{"id":"b"}`:  reflect.TypeOf(refund{}),
+}
+
+func TestStaticResolver(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []turnip.Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "payload",
+			payload: `{"id":"b"}`,
+			want:    &refund{ID: "b"},
+		},
+		{
+			name:    "payload in another layout",
+			payload: `{ "id" : "a" }`,
+			want:    &order{ID: "a"},
+		},
+		{
+			name:    "other payload",
+			payload: `{"id":"c"}`,
+			wantErr: turnip.ErrNoMatch,
+		},
+		{
+			name:    "other payload with defaults",
+			params:  []turnip.Parameter{turnip.FallbackToMap()},
+			payload: `{"id":"c"}`,
+			want:    map[string]any{"id": "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := MustNew(t, resolver, tt.params...)
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMustNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		r         StaticResolver
+		params    []turnip.Parameter
+		wantFatal bool
+	}{
+		{"valid", resolver, nil, false},
+		{"invalid parameters", resolver, []turnip.Parameter{turnip.FuzzyMatch(0)}, true},
+		{"no types", StaticResolver{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			var u *turnip.Unmarshaler
+			tb.run(func(tb testing.TB) {
+				u = MustNew(tb, tt.r, tt.params...)
+			})
+
+			if tb.fatal != tt.wantFatal {
+				t.Fatalf("MustNew() failures = %v, want fatal %v", tb.failures, tt.wantFatal)
+			}
+
+			if !tt.wantFatal && u == nil {
+				t.Error("MustNew() = nil")
+			}
+		})
+	}
+}
+
+func TestAssertUnmarshals(t *testing.T) {
+	u := MustNew(t, resolver)
+	tests := []struct {
+		name    string
+		payload string
+		want    any
+		wantOK  bool
+	}{
+		{"equal", `{"id":"a"}`, &order{ID: "a"}, true},
+		{"other value", `{"id":"a"}`, &order{ID: "b"}, false},
+		{"other type", `{"id":"b"}`, &order{ID: "b"}, false},
+		{"error", `{"id":"c"}`, &order{ID: "c"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			var ok bool
+			tb.run(func(tb testing.TB) {
+				ok = AssertUnmarshals(tb, u, tt.payload, tt.want)
+			})
+
+			if ok != tt.wantOK || (len(tb.failures) == 0) != tt.wantOK {
+				t.Errorf("AssertUnmarshals() = %v with failures %v, want %v", ok, tb.failures, tt.wantOK)
+			}
+
+			if tb.fatal {
+				t.Error("AssertUnmarshals() stopped the test")
+			}
+		})
+	}
+}
@@ -1,61 +1,695 @@
 package turnip
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/tidwall/gjson"
 	"go.uber.org/zap"
 )
 
-var ErrNoMatch = errors.New("no match")
-
+// Unmarshaler resolves payloads into one of its candidates. It's safe for concurrent use, and meant to be shared: its
+// state is only ever replaced as a whole by Reload and Add, and the lazy initialization happens exactly once. Calls
+// never take a lock to read it. Parameters can also be shared between Unmarshalers, since they are copied or only
+// prepared once
 type Unmarshaler struct {
-	resolver Resolver
-	settings settings
+	current atomic.Pointer[unmarshaler]
+	// reloadMu keeps reloads and additions in order
+	reloadMu sync.Mutex
+	// formats holds the FormatUnmarshalers given by Format, by name
+	formats sync.Map
+}
+
+// JSONUnmarshaler is what most code needs from an Unmarshaler, for it to depend on an interface instead, which tests
+// can then replace with a fake. Unmarshal takes JSON unless the Unmarshaler was given another format
+type JSONUnmarshaler interface {
+	Unmarshal(b []byte) (any, error)
+	UnmarshalJSONContext(b []byte, rc ResolveContext) (any, error)
+	ResolveResult(res gjson.Result) (reflect.Type, error)
+}
+
+// unmarshaler is the state of an Unmarshaler built from a set of parameters. Calls take it once and work on it until
+// they are done, so a reload in the middle doesn't affect them
+type unmarshaler struct {
+	// params are the ones the state was built from, for Add to build the next one
+	params     []Parameter
+	env        environment
+	resolver   Resolver
+	interfaces interfaceResolvers
+	settings   settings
+	// candidates holds the declared candidates by type, for the decoding to follow their options
+	candidates map[reflect.Type]*candidate
+	// withDefaults holds the types that have fields with a default tag
+	withDefaults map[reflect.Type]bool
+	// withAliases holds the types that have fields with aliases
+	withAliases map[reflect.Type]bool
+	// withRedacted holds the types that have fields tagged with redact
+	withRedacted map[reflect.Type]bool
+	// recent holds the last resolutions, when recorded
+	recent *resolutionLog
+	// cache holds the types payloads resolved to, when cached
+	cache     ResolutionCache
+	cacheKeys *cacheKeys
+	// shared is the resolver of another state to use instead of building one, when its fingerprints hold for this one
+	shared Resolver
+
+	initOnce sync.Once
+	initErr  error
 }
 
+var _ JSONUnmarshaler = (*Unmarshaler)(nil)
+
 func New(params ...Parameter) (*Unmarshaler, error) {
+	state, err := newUnmarshaler(params)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &Unmarshaler{}
+	u.current.Store(state)
+	return u, nil
+}
+
+// load returns the current state, which must be used for the whole of a call
+func (u *Unmarshaler) load() *unmarshaler {
+	return u.current.Load()
+}
+
+func newUnmarshaler(params []Parameter) (*unmarshaler, error) {
+	u, err := newState(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.env.settings.Get(enableLazyInit) {
+		u.env.logger.Info("lazy init enabled, deferring resolver creation")
+		return u, nil
+	}
+
+	err = u.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// newState returns the state for the parameters, leaving the resolver to be built by init
+func newState(params []Parameter) (*unmarshaler, error) {
 	env, err := newEnv(params)
 	if err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
 
-	env.logger.Infow("creating new turnip unmarshaler",
-		zap.String("settings", fmt.Sprintf("%v", env.settings)))
+	env.logger.Infow("creating new turnip unmarshaler", zap.Strings("settings", env.settings.names()),
+		zap.Int("candidates", len(env.candidates)))
+
+	u := &unmarshaler{
+		params:     slices.Clone(params),
+		env:        env,
+		settings:   env.settings,
+		candidates: make(map[reflect.Type]*candidate, len(env.candidates)),
+	}
+
+	for _, c := range env.candidates {
+		u.candidates[c.typ] = c
+	}
+
+	if env.recentSize > 0 {
+		u.recent = newResolutionLog(int(env.recentSize))
+	}
+
+	u.cache = env.cache
+	if env.cacheSize > 0 {
+		u.cache = NewLRUCache(int(env.cacheSize), 0)
+	}
+
+	err = u.inspectTypes()
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	return u, nil
+}
+
+// inspectTypes looks for default tags, aliases and redacted fields in every type that can be decoded into
+func (u *unmarshaler) inspectTypes() error {
+	types := make([]reflect.Type, 0, len(u.env.candidates)+len(u.env.selectors)+len(u.env.fallbacks))
+	for _, c := range u.env.candidates {
+		types = append(types, c.typ)
+	}
+
+	for _, s := range u.env.selectors {
+		types = append(types, s.typ)
+	}
+
+	for _, f := range u.env.fallbacks {
+		types = append(types, f.typ)
+	}
+
+	u.withDefaults = make(map[reflect.Type]bool)
+	u.withAliases = make(map[reflect.Type]bool)
+	u.withRedacted = make(map[reflect.Type]bool)
+	for _, t := range types {
+		if t.Kind() != reflect.Struct {
+			continue
+		}
+
+		if hasTurnipOption(t, "aliases", make(map[reflect.Type]bool)) {
+			u.withAliases[t] = true
+		}
+
+		if hasTurnipOption(t, "redact", make(map[reflect.Type]bool)) {
+			u.withRedacted[t] = true
+		}
+
+		found, err := checkDefaults(t, make(map[reflect.Type]bool))
+		if err != nil {
+			return fmt.Errorf("%s: %w", t, err)
+		}
+
+		if found {
+			u.withDefaults[t] = true
+		}
+	}
+
+	return nil
+}
+
+// MustNew is New, but panics if the Unmarshaler can't be created. It's meant for package-level variables, where the
+// parameters are fixed and failing at startup is the way to go
+func MustNew(params ...Parameter) *Unmarshaler {
+	u, err := New(params...)
+	if err != nil {
+		panic("turnip: " + err.Error())
+	}
+
+	return u
+}
+
+// init builds the resolver exactly once, no matter how many goroutines are asking for it
+func (u *unmarshaler) init() error {
+	u.initOnce.Do(func() {
+		resolver := u.shared
+		var err error
+		switch {
+		case resolver != nil:
+			// Found by another state, see withFormat
+		case u.env.resolverFactory != nil:
+			resolver, err = newPluginResolver(u.env)
+		default:
+			resolver, err = newTraverseResolver(u.env)
+		}
+
+		if err != nil {
+			u.initErr = fmt.Errorf("resolver: %w", err)
+			return
+		}
+
+		interfaces, err := newInterfaceResolvers(u.env)
+		if err != nil {
+			u.initErr = fmt.Errorf("implementations: %w", err)
+			return
+		}
+
+		u.resolver = resolver
+		u.interfaces = interfaces
+		if u.cache != nil {
+			u.cacheKeys = newCacheKeys(u.env, resolver)
+		}
+	})
 
-	resolver, err := newTraverseResolver(env)
+	return u.initErr
+}
+
+// ready makes sure the resolver is built before using it. Failing to build it is an internal error at this point,
+// since the payload has nothing to do with it
+func (u *unmarshaler) ready() error {
+	err := u.init()
 	if err != nil {
-		return nil, fmt.Errorf("resolver: %w", err)
+		return fmt.Errorf("%w: %w", ErrInternal, err)
 	}
 
-	return &Unmarshaler{
-		resolver: resolver,
-	}, nil
+	return nil
 }
 
 func (u *Unmarshaler) UnmarshalJSON(b []byte) (any, error) {
-	res := gjson.ParseBytes(b)
+	return u.load().unmarshal(b, &query{})
+}
+
+// Unmarshal is UnmarshalJSON for payloads of the format given with UseFormat, which is JSON by default. The payload
+// is converted to JSON to resolve it, and then decoded as the format says
+func (u *Unmarshaler) Unmarshal(b []byte) (any, error) {
+	return u.load().unmarshalFormat(b)
+}
+
+func (u *unmarshaler) unmarshalFormat(b []byte) (any, error) {
+	f := u.env.format
+	if f.toJSON == nil {
+		return u.unmarshal(b, &query{})
+	}
+
+	j, err := f.toJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrMalformed, f.name, err)
+	}
+
+	if f.unmarshal == nil {
+		return u.unmarshal(j, &query{})
+	}
+
+	start := time.Now()
+	v, err := u.unmarshalWith(f, b, j)
+	u.record(u.env.logger, start, j, len(b), v, err)
+	return v, err
+}
+
+// unmarshalWith resolves the payload b, converted to the JSON j, and decodes b with the format f
+func (u *unmarshaler) unmarshalWith(f *format, b, j []byte) (any, error) {
+	j, res, err := u.parse(j)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{res: res}
+	typ, err := u.resolve(q)
+	if err != nil {
+		return nil, fmt.Errorf("%w: resolve: %w", ErrInternal, err)
+	}
+
+	if typ == nil {
+		return u.decodeFallback(j, res, u.env.logger)
+	}
+
+	u.warnResolved(q, typ)
+	v, err := u.decodeFormatValue(f, b, res, typ)
+	return u.normalize(v, err, res, typ, f)
+}
+
+// decodeFormatValue decodes the payload b, of the format f and parsed into res once converted to JSON, into typ
+func (u *unmarshaler) decodeFormatValue(f *format, b []byte, res gjson.Result, typ reflect.Type) (any, error) {
+	v := reflect.New(typ)
+	err := f.unmarshal(b, v.Interface())
+	if err != nil {
+		// Whatever the format reports, it's not about the JSON it was converted to
+		return nil, decodeError(err, nil)
+	}
+
+	return u.complete(v, res, typ, f)
+}
+
+// ResolveResult returns the type of the selector or candidate an already parsed payload resolves to, for applications
+// that route with gjson themselves. Payloads that resolve to nothing return a nil type, since the defaults can only be
+// told apart by decoding
+func (u *Unmarshaler) ResolveResult(res gjson.Result) (reflect.Type, error) {
+	s := u.load()
+	if res.Type != gjson.JSON {
+		return nil, fmt.Errorf("%w: not an object", ErrMalformed)
+	}
+
+	err := s.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	_, res = s.indexTuple(nil, res)
+	typ, err := s.resolve(&query{res: res})
+	if err != nil {
+		return nil, fmt.Errorf("%w: resolve: %w", ErrInternal, err)
+	}
+
+	return typ, nil
+}
+
+// UnmarshalResult is UnmarshalJSON for an already parsed payload. The payload is not parsed again, it's only decoded
+// from its raw JSON
+func (u *Unmarshaler) UnmarshalResult(res gjson.Result) (any, error) {
 	if res.Type != gjson.JSON {
-		return nil, errors.New("invalid json: not an object")
+		return nil, fmt.Errorf("%w: not an object", ErrMalformed)
+	}
+
+	s := u.load()
+	b, res := s.indexTuple([]byte(res.Raw), res)
+	return s.unmarshalParsed(b, res, &query{})
+}
+
+// UnmarshalMap is UnmarshalJSON for a payload that was already decoded into a map, like by a middleware upstream. The
+// map is encoded back to JSON, so it's decoded with the same rules as any other payload, json tags and custom
+// unmarshalers included
+func (u *Unmarshaler) UnmarshalMap(m map[string]any) (any, error) {
+	if m == nil {
+		return nil, fmt.Errorf("%w: nil map", ErrMalformed)
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	return u.UnmarshalJSON(b)
+}
+
+// UnmarshalJSONHint is UnmarshalJSON with a hint about what the payload is, like the topic it came from or a header.
+// Selectors using the Hint condition look at it before any fingerprint is checked
+func (u *Unmarshaler) UnmarshalJSONHint(b []byte, hint string) (any, error) {
+	return u.load().unmarshal(b, &query{ctx: ResolveContext{Hint: hint}})
+}
+
+// UnmarshalJSONContext is UnmarshalJSON with everything else known about the payload, for selectors using the Hint
+// and Meta conditions
+func (u *Unmarshaler) UnmarshalJSONContext(b []byte, rc ResolveContext) (any, error) {
+	return u.load().unmarshal(b, &query{ctx: rc})
+}
+
+// UnmarshalJSONAll decodes the payload into every selector and candidate it matches, in the order UnmarshalJSON checks
+// them. Payloads matching none go through the defaults as usual, resulting in at most one value. Types failing to
+// decode don't keep the rest from being returned: the values decoded are returned along with the errors of the others,
+// each telling its type, joined
+func (u *Unmarshaler) UnmarshalJSONAll(b []byte) ([]any, error) {
+	return u.load().unmarshalAll(b)
+}
+
+func (u *unmarshaler) unmarshalAll(b []byte) ([]any, error) {
+	b, res, err := u.parse(b)
+	if err != nil {
+		return nil, err
 	}
 
-	typ, err := u.resolver.ResolveJSON(res)
+	err = u.ready()
 	if err != nil {
-		return nil, fmt.Errorf("resolve: %w", err)
+		return nil, err
+	}
+
+	types, err := u.resolveAll(&query{res: res})
+	if err != nil {
+		return nil, fmt.Errorf("%w: resolve: %w", ErrInternal, err)
+	}
+
+	if len(types) == 0 {
+		v, err := u.decodeFallback(b, res, u.env.logger)
+		if err != nil {
+			return nil, err
+		}
+
+		return []any{v}, nil
+	}
+
+	values := make([]any, 0, len(types))
+	var errs []error
+	for _, typ := range types {
+		v, err := u.decode(b, res, typ)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", typ, err))
+			continue
+		}
+
+		values = append(values, v)
+	}
+
+	return values, errors.Join(errs...)
+}
+
+func (u *unmarshaler) unmarshal(b []byte, q *query) (any, error) {
+	start := time.Now()
+	parsed, res, err := u.parse(b)
+	if err != nil {
+		u.record(u.loggerFor(q), start, b, len(b), nil, err)
+		return nil, err
+	}
+
+	b = parsed
+
+	return u.unmarshalParsed(b, res, q)
+}
+
+// unmarshalParsed is unmarshal once the payload is parsed into res
+func (u *unmarshaler) unmarshalParsed(b []byte, res gjson.Result, q *query) (any, error) {
+	start := time.Now()
+	v, err := u.resolveAndDecode(b, res, q)
+	u.record(u.loggerFor(q), start, b, len(b), v, err)
+	return v, err
+}
+
+func (u *unmarshaler) resolveAndDecode(b []byte, res gjson.Result, q *query) (any, error) {
+	err := u.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	q.res = res
+	typ, err := u.resolveCached(b, q)
+	if err != nil {
+		return nil, fmt.Errorf("%w: resolve: %w", ErrInternal, err)
 	}
 
 	if typ == nil {
-		return nil, ErrNoMatch
+		return u.decodeFallback(b, res, u.loggerFor(q))
+	}
+
+	u.warnResolved(q, typ)
+	return u.decode(b, res, typ)
+}
+
+// parse cleans up the payload as the settings ask for, and checks that it's an object. Payloads are always turned into
+// UTF-8 without a byte order mark first, since that's all gjson and encoding/json understand
+func (u *unmarshaler) parse(b []byte) ([]byte, gjson.Result, error) {
+	if u.settings.Get(enableDecompression) {
+		var err error
+		b, err = decompress(b, u.env.decompressionLimit)
+		if err != nil {
+			return nil, gjson.Result{}, err
+		}
+	}
+
+	b = toUTF8(b)
+	if u.settings.Get(enableJSONC) {
+		b = stripJSONC(b)
+	}
+
+	b, res := u.indexTuple(b, gjson.ParseBytes(b))
+	if res.Type != gjson.JSON {
+		return nil, gjson.Result{}, fmt.Errorf("%w: not an object", ErrMalformed)
+	}
+
+	return b, res, nil
+}
+
+// decode decodes the payload b, already parsed into res, into typ, and then normalizes and migrates it. Errors have the
+// values of redacted fields taken out
+func (u *unmarshaler) decode(b []byte, res gjson.Result, typ reflect.Type) (any, error) {
+	v, err := u.decodeValue(b, res, typ)
+	return u.normalize(v, err, res, typ, jsonFormat)
+}
+
+// normalize is what follows decoding the payload res into typ, whatever the format: v is given to the functions of
+// AfterDecode and then migrated, or if decoding failed with err, the values of redacted fields are taken out of it.
+// Keys of res are named as in the format wire
+func (u *unmarshaler) normalize(v any, err error, res gjson.Result, typ reflect.Type, wire *format) (any, error) {
+	if err == nil {
+		v, err = u.env.normalizeHooks.apply(v, typ)
+	}
+
+	if err != nil {
+		return nil, u.redactError(err, res, typ, wire)
+	}
+
+	return u.env.migrations.migrate(v)
+}
+
+func (u *unmarshaler) decodeValue(b []byte, res gjson.Result, typ reflect.Type) (any, error) {
+	b, rewritten, err := u.env.rewriteHooks.apply(b, typ)
+	if err != nil {
+		return nil, err
+	}
+
+	if rewritten {
+		res = gjson.ParseBytes(b)
+	}
+
+	if c, ok := u.candidates[typ]; ok && len(c.mapped) > 0 {
+		// Tuples only have what's mapped from their positions
+		dst := b
+		if c.tuple {
+			dst = []byte("{}")
+		}
+
+		var mapped bool
+		b, mapped, err = applyMappings(dst, res, c.mapped, u.env.naming)
+		if err != nil {
+			return nil, err
+		}
+
+		if mapped {
+			rewritten = true
+			res = gjson.ParseBytes(b)
+		}
+	}
+
+	// given is the payload as given, for errors to point into, unless it's rewritten
+	given := b
+	if rewritten {
+		given = nil
+	}
+
+	if u.withAliases[typ] || u.env.naming.splitsWords() || !u.env.coerce.isZero() {
+		rewritten, err := u.rewrite(b, typ)
+		if err != nil {
+			return nil, decodeError(err, b)
+		}
+
+		// The keys may have changed, and the rest of the decoding looks them up
+		b, given = rewritten, nil
+		res = gjson.ParseBytes(b)
+	}
+
+	// Selectors and defaults may be given types that aren't candidates, which are decoded with no options
+	var opts decodeOptions
+	if c, ok := u.candidates[typ]; ok {
+		if c.dynamic != nil {
+			return c.dynamic.unmarshal(b, c.decode)
+		}
+
+		opts = c.decode
 	}
 
-	v := reflect.New(typ).Interface()
-	err = json.Unmarshal(b, v)
+	v := reflect.New(typ)
+	err = u.interfaces.populate(v.Elem(), res)
 	if err != nil {
-		return nil, fmt.Errorf("unmarshall: %w", err)
+		return nil, fmt.Errorf("implementations: %w", err)
+	}
+
+	switch {
+	case u.env.mapstructure != nil:
+		err = u.env.mapstructure.decode(b, v.Interface(), opts)
+	case !opts.isZero():
+		err = decodeWith(b, v.Interface(), opts)
+	default:
+		err = json.Unmarshal(b, v.Interface())
+	}
+
+	if err != nil {
+		return nil, decodeError(err, given)
+	}
+
+	return u.complete(v, res, typ, jsonFormat)
+}
+
+// complete fills in the defaults of the value v, just decoded from res into typ, and validates it. Keys of res are
+// named as in the format wire
+func (u *unmarshaler) complete(v reflect.Value, res gjson.Result, typ reflect.Type, wire *format) (any, error) {
+	if u.withDefaults[typ] {
+		err := applyDefaults(v.Elem(), res, wire)
+		if err != nil {
+			return nil, fmt.Errorf("%w: defaults: %w", ErrDecode, err)
+		}
+	}
+
+	if u.env.validation != nil {
+		err := u.env.validation.check(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return v.Interface(), nil
+}
+
+// rewrite renames the keys that only match a field by its aliases or by naming, and then applies the coercions, for
+// encoding/json to decode the payload b into typ
+func (u *unmarshaler) rewrite(b []byte, typ reflect.Type) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.withAliases[typ] || u.env.naming.splitsWords() {
+		v = renameKeys(v, typ, u.env.naming)
+	}
+
+	if !u.env.coerce.isZero() {
+		var coerced bool
+		v, coerced = u.env.coerce.coerce(v, typ)
+		if coerced {
+			u.warn(Warning{
+				Kind:    WarnCoerced,
+				Type:    typ,
+				Message: fmt.Sprintf("scalars coerced to decode into %s", typ),
+			})
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// decodeFallback goes through the defaults in order, returning the first one the payload decodes into
+func (u *unmarshaler) decodeFallback(b []byte, res gjson.Result, logger *zap.SugaredLogger) (any, error) {
+	for _, f := range u.env.fallbacks {
+		v, err := u.decode(b, res, f.typ)
+		if err == nil {
+			return v, nil
+		}
+
+		logger.Debugw("default did not decode", zap.Stringer("type", f.typ), zap.Error(err))
+	}
+
+	if u.settings.Get(fallbackToMap) {
+		var m map[string]any
+		err := json.Unmarshal(b, &m)
+		if err != nil {
+			return nil, decodeError(err, b)
+		}
+
+		return m, nil
+	}
+
+	if r, ok := u.resolver.(*traverseResolver); ok {
+		if confused := r.ambiguousMatch(res); len(confused) > 0 {
+			return nil, fmt.Errorf("%w: %w: could be any of %s", ErrNoMatch, ErrAmbiguous, typeNames(confused))
+		}
+
+		if hint := r.closestMatch(res); hint != "" {
+			return nil, fmt.Errorf("%w: %s", ErrNoMatch, hint)
+		}
+	}
+
+	return nil, ErrNoMatch
+}
+
+// resolve hands the context to resolvers that can use it, and just the payload to the rest
+func (u *unmarshaler) resolve(q *query) (reflect.Type, error) {
+	if r, ok := u.resolver.(ContextResolver); ok {
+		return r.ResolveJSONContext(q.res, q.ctx)
+	}
+
+	return u.resolver.ResolveJSON(q.res)
+}
+
+// resolveAll falls back to the single type for resolvers that can only return one
+func (u *unmarshaler) resolveAll(q *query) ([]reflect.Type, error) {
+	if r, ok := u.resolver.(MultiResolver); ok {
+		return r.ResolveAllJSON(q.res)
+	}
+
+	typ, err := u.resolve(q)
+	if err != nil || typ == nil {
+		return nil, err
 	}
 
-	return v, nil
+	return []reflect.Type{typ}, nil
 }
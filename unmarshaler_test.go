@@ -0,0 +1,255 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type raceCharge struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+type raceRefund struct {
+	RefundOf string `json:"refund_of"`
+}
+
+type racePing struct {
+	Ping bool `json:"ping"`
+}
+
+// racePayloads are unmarshaled by every goroutine of the concurrency tests, along with what they decode into
+var racePayloads = []struct {
+	payload string
+	want    any
+}{
+	{`{"id":"a","amount":1}`, &raceCharge{ID: "a", Amount: 1}},
+	{`{"refund_of":"a"}`, &raceRefund{RefundOf: "a"}},
+	{`{"id":"b","amount":20000}`, &raceCharge{ID: "b", Amount: 20000}},
+}
+
+// unmarshalConcurrently unmarshals racePayloads from several goroutines at once, failing on any unexpected result
+func unmarshalConcurrently(t *testing.T, unmarshal func(b []byte) (any, error)) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				p := racePayloads[i%len(racePayloads)]
+				got, err := unmarshal([]byte(p.payload))
+				if err != nil {
+					errs <- fmt.Errorf("%s: %w", p.payload, err)
+					return
+				}
+
+				if !reflect.DeepEqual(got, p.want) {
+					errs <- fmt.Errorf("%s: got %#v, want %#v", p.payload, got, p.want)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestConcurrentUnmarshal(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []Parameter
+	}{
+		{"candidates", []Parameter{Candidate(raceCharge{}), Candidate(raceRefund{})}},
+		{"lazy init", []Parameter{Candidate(raceCharge{}), Candidate(raceRefund{}), EnableLazyInit()}},
+		{"cache", []Parameter{Candidate(raceCharge{}), Candidate(raceRefund{}), CacheResolutions(2)}},
+		{"shared cache", []Parameter{Candidate(raceCharge{}), Candidate(raceRefund{}), UseCache(NewLRUCache(1, 0))}},
+		{"recorded resolutions", []Parameter{Candidate(raceCharge{}), Candidate(raceRefund{}), RecordResolutions(2)}},
+		{"selectors", []Parameter{
+			SelectWhen(Where("amount", GT(10000)), raceCharge{}),
+			SelectWhen(Where("id", Regex("^[a-z]$")), raceCharge{}),
+			Candidate(raceRefund{}),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			unmarshalConcurrently(t, u.UnmarshalJSON)
+		})
+	}
+}
+
+func TestConcurrentNewSharingParameters(t *testing.T) {
+	params := []Parameter{
+		Candidate(raceCharge{}),
+		Candidate(raceRefund{}),
+		SelectWhen(Where("id", Regex("^[a-z]$")), raceCharge{}),
+	}
+
+	var wg sync.WaitGroup
+	unmarshalers := make([]*Unmarshaler, 4)
+	errs := make([]error, len(unmarshalers))
+	for i := range unmarshalers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unmarshalers[i], errs[i] = New(params...)
+		}()
+	}
+
+	wg.Wait()
+	for i, u := range unmarshalers {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+
+		unmarshalConcurrently(t, u.UnmarshalJSON)
+	}
+}
+
+func TestConcurrentReload(t *testing.T) {
+	base := []Parameter{Candidate(raceCharge{}), Candidate(raceRefund{}), CacheResolutions(2)}
+	u, err := New(base...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	reloaded := make(chan error, 1)
+	go func() {
+		defer close(reloaded)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			err := u.Reload(base...)
+			if err == nil {
+				err = u.Add(Candidate(racePing{}))
+			}
+
+			if err != nil {
+				reloaded <- err
+				return
+			}
+		}
+	}()
+
+	t.Run("UnmarshalJSON", func(t *testing.T) {
+		unmarshalConcurrently(t, u.UnmarshalJSON)
+	})
+
+	t.Run("FormatUnmarshaler", func(t *testing.T) {
+		unmarshalConcurrently(t, u.Format("plugintest").Unmarshal)
+	})
+
+	t.Run("Document", func(t *testing.T) {
+		unmarshalConcurrently(t, func(b []byte) (any, error) {
+			_, err := u.Document()
+			if err != nil {
+				return nil, err
+			}
+
+			return u.UnmarshalJSON(b)
+		})
+	})
+
+	close(done)
+	for err := range reloaded {
+		t.Errorf("Reload() error = %v", err)
+	}
+
+	// The ping candidate may or may not be there when the payload is unmarshaled, but never half there
+	_, err = u.UnmarshalJSON([]byte(`{"ping":true}`))
+	if err != nil && !errors.Is(err, ErrNoMatch) {
+		t.Errorf("UnmarshalJSON() error = %v, want nil or %v", err, ErrNoMatch)
+	}
+}
+
+type allCharge struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+type allAmount struct {
+	Amount int `json:"amount"`
+}
+
+type allLegacy struct {
+	ID int `json:"id"`
+}
+
+func TestUnmarshalJSONAll(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		want       []any
+		wantErr    error
+		wantFailed string
+	}{
+		{
+			name:    "every type decoding",
+			payload: `{"amount":1}`,
+			want:    []any{&allAmount{Amount: 1}, &allCharge{Amount: 1}},
+		},
+		{
+			name:       "a type failing to decode",
+			payload:    `{"id":"a","amount":1}`,
+			want:       []any{&allAmount{Amount: 1}, &allCharge{ID: "a", Amount: 1}},
+			wantErr:    ErrDecode,
+			wantFailed: "allLegacy",
+		},
+		{
+			name:       "every type failing to decode",
+			payload:    `{"id":true}`,
+			want:       []any{},
+			wantErr:    ErrDecode,
+			wantFailed: "allLegacy",
+		},
+		{
+			name:    "no match",
+			payload: `{"other":1}`,
+			wantErr: ErrNoMatch,
+		},
+	}
+
+	u, err := New(SelectWhen(Exists("id"), allLegacy{}), SelectWhen(Exists("amount"), allAmount{}),
+		Candidate(allCharge{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.UnmarshalJSONAll([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSONAll() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), tt.wantFailed) {
+				t.Errorf("UnmarshalJSONAll() error = %v, want it to tell %s", err, tt.wantFailed)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSONAll() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
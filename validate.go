@@ -0,0 +1,63 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var ErrInvalidCandidate = errors.New("invalid candidate")
+
+// validateCandidates catches the candidates that would otherwise fail in confusing ways, either while building their
+// paths or by silently never matching
+func validateCandidates(candidates []*candidate, env environment) error {
+	seen := make(map[reflect.Type]bool, len(candidates))
+	for i, c := range candidates {
+		if c.typ == nil {
+			return fmt.Errorf("%w #%d: nil type", ErrInvalidCandidate, i)
+		}
+
+		if c.typ.Kind() == reflect.Pointer && c.typ.Elem().Kind() == reflect.Struct {
+			return fmt.Errorf("%w %s: pointers can't be candidates, use %s{} instead", ErrInvalidCandidate, c.typ, c.typ.Elem())
+		}
+
+		if c.typ.Kind() != reflect.Struct {
+			return fmt.Errorf("%w %s: must be a struct, not %s", ErrInvalidCandidate, c.typ, c.typ.Kind())
+		}
+
+		if seen[c.typ] {
+			return fmt.Errorf("%w %s: registered more than once", ErrInvalidCandidate, c.typ)
+		}
+
+		seen[c.typ] = true
+
+		if !hasUsableFields(c.typ, env) {
+			return fmt.Errorf("%w %s: no exported fields that can be fingerprinted", ErrInvalidCandidate, c.typ)
+		}
+	}
+
+	return nil
+}
+
+func hasUsableFields(t reflect.Type, env environment) bool {
+	for _, f := range wireFields(t, env.format) {
+		ft := f.typ
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if _, ok := env.implementations[ft]; ok {
+			return true
+		}
+
+		if _, ok := getLeafType(ft); ok {
+			return true
+		}
+
+		if _, err := getJSONType(ft); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
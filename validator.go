@@ -0,0 +1,84 @@
+package turnip
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// UseValidator checks the `validate` struct tags of every decoded value with the given validator, failing with a
+// *ValidationError for values that break them. A nil validator uses a new one that names fields after their json tags,
+// so the errors can be reported back in terms of the payload.
+//
+// Only structs are validated, dynamic candidates and FallbackToMap maps are returned as they are
+func UseValidator(v *validator.Validate) Parameter {
+	if v == nil {
+		v = validator.New(validator.WithRequiredStructEnabled())
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+
+	return &validation{validate: v}
+}
+
+type validation struct {
+	validate *validator.Validate
+}
+
+func (v *validation) Name() string {
+	return "UseValidator"
+}
+
+func (v *validation) check(value reflect.Value) error {
+	t := value.Type()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := v.validate.Struct(value.Interface())
+	var fields validator.ValidationErrors
+	if errors.As(err, &fields) {
+		return &ValidationError{
+			Type:   t,
+			Fields: fields,
+		}
+	}
+
+	return err
+}
+
+// jsonFieldName names a field as it's written in the payload
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	default:
+		return name
+	}
+}
+
+// ValidationError is returned when a payload decodes, but the value breaks its `validate` tags as checked by the
+// validator given to UseValidator. It's also an ErrValidation, which is enough for callers that don't need the details
+type ValidationError struct {
+	// Type is the type the payload was decoded into
+	Type reflect.Type
+	// Fields are the failed checks, one per field
+	Fields validator.ValidationErrors
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrValidation, e.Type, e.Fields)
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return []error{ErrValidation, e.Fields}
+}
@@ -0,0 +1,50 @@
+package turnip
+
+import (
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	formatRFC3339   = "rfc3339"
+	formatDate      = "date"
+	formatUnix      = "unix"
+	formatUnixMilli = "unixmilli"
+	formatUnixMicro = "unixmicro"
+	formatUnixNano  = "unixnano"
+)
+
+// valueFormat is a recognizable encoding of a value, given to fields with the format option of the turnip tag:
+//
+//	CreatedAt int64 `turnip:"format=unixmilli"`
+//
+// Fields that differ only in their format can then tell candidates apart
+type valueFormat struct {
+	json  gjson.Type
+	check func(v gjson.Result) bool
+}
+
+var valueFormats = map[string]valueFormat{
+	formatRFC3339: {json: gjson.String, check: layoutCheck(time.RFC3339Nano)},
+	formatDate:    {json: gjson.String, check: layoutCheck(time.DateOnly)},
+	// Epochs are told apart by their magnitude. Seconds stay under 1e11 until the year 5138, and the same goes for the
+	// finer units scaled accordingly
+	formatUnix:      {json: gjson.Number, check: epochCheck(0, 1e11)},
+	formatUnixMilli: {json: gjson.Number, check: epochCheck(1e11, 1e14)},
+	formatUnixMicro: {json: gjson.Number, check: epochCheck(1e14, 1e17)},
+	formatUnixNano:  {json: gjson.Number, check: epochCheck(1e17, 1e20)},
+}
+
+func layoutCheck(layout string) func(v gjson.Result) bool {
+	return func(v gjson.Result) bool {
+		_, err := time.Parse(layout, v.Str)
+		return err == nil
+	}
+}
+
+func epochCheck(min, max float64) func(v gjson.Result) bool {
+	return func(v gjson.Result) bool {
+		return v.Num >= min && v.Num < max
+	}
+}
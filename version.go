@@ -0,0 +1,263 @@
+package turnip
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const defaultVersionField = "version"
+
+// Version declares a candidate that only matches payloads whose version satisfies the constraint. Constraints are
+// made of comparisons (=, !=, >, >=, < and <=) that must all hold, like ">=2 <3", and alternatives can be given with
+// "||". Versions can be integers or semver-like strings, "v2.1.0" and 2.1 being the same version.
+//
+// Candidates with constraints that can't both hold don't need to be told apart by their fingerprints, so successive
+// versions of the same struct can share their shape
+func Version(v any, constraint string, opts ...DecodeOption) Parameter {
+	return &candidate{
+		typ:    reflect.TypeOf(v),
+		decode: newDecodeOptions(opts),
+		version: &versionConstraint{
+			raw: constraint,
+		},
+	}
+}
+
+// VersionField sets the gjson path or JSON Pointer of the version used by candidates declared with Version, "version"
+// by default
+func VersionField(path string) Parameter {
+	return versionField(path)
+}
+
+type versionField string
+
+func (v versionField) Name() string {
+	return "VersionField"
+}
+
+type version [3]uint64
+
+func (v version) compare(o version) int {
+	for i := range v {
+		if v[i] != o[i] {
+			if v[i] < o[i] {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// parseVersion reads integers, decimals and strings like "v1.2.3-beta". Pre-release and build suffixes are ignored
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > len(version{}) {
+		return version{}, false
+	}
+
+	var v version
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return version{}, false
+		}
+
+		v[i] = n
+	}
+
+	return v, true
+}
+
+func versionOf(res gjson.Result) (version, bool) {
+	switch res.Type {
+	case gjson.Number:
+		return parseVersion(res.Raw)
+	case gjson.String:
+		return parseVersion(res.Str)
+	default:
+		return version{}, false
+	}
+}
+
+type versionConstraint struct {
+	raw string
+	// path is where the version is found in the payload
+	path string
+	// alternatives are ORed, the comparisons within each one ANDed
+	alternatives [][]versionComparison
+}
+
+type versionComparison struct {
+	op string
+	v  version
+}
+
+func (c *versionConstraint) compile() error {
+	c.alternatives = nil
+	for _, alt := range strings.Split(c.raw, "||") {
+		var comparisons []versionComparison
+		for _, f := range strings.Fields(strings.ReplaceAll(alt, ",", " ")) {
+			op := "="
+			for _, candidate := range []string{">=", "<=", "!=", "==", ">", "<", "="} {
+				if strings.HasPrefix(f, candidate) {
+					op = candidate
+					f = f[len(candidate):]
+					break
+				}
+			}
+
+			if op == "==" {
+				op = "="
+			}
+
+			v, ok := parseVersion(f)
+			if !ok {
+				return fmt.Errorf("invalid version '%s' in constraint '%s'", f, c.raw)
+			}
+
+			comparisons = append(comparisons, versionComparison{op: op, v: v})
+		}
+
+		if len(comparisons) == 0 {
+			return fmt.Errorf("empty version constraint '%s'", c.raw)
+		}
+
+		c.alternatives = append(c.alternatives, comparisons)
+	}
+
+	return nil
+}
+
+func (c *versionConstraint) matches(res gjson.Result) bool {
+	v, ok := versionOf(res.Get(c.path))
+	if !ok {
+		return false
+	}
+
+	for _, alt := range c.alternatives {
+		if allHold(alt, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func allHold(comparisons []versionComparison, v version) bool {
+	for _, cmp := range comparisons {
+		c := v.compare(cmp.v)
+
+		var ok bool
+		switch cmp.op {
+		case "=":
+			ok = c == 0
+		case "!=":
+			ok = c != 0
+		case ">":
+			ok = c > 0
+		case ">=":
+			ok = c >= 0
+		case "<":
+			ok = c < 0
+		case "<=":
+			ok = c <= 0
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// overlaps reports whether some version could satisfy both constraints. It's conservative: != comparisons are ignored,
+// so constraints only excluded by them are still considered overlapping
+func (c *versionConstraint) overlaps(o *versionConstraint) bool {
+	for _, a := range c.alternatives {
+		for _, b := range o.alternatives {
+			i := rangeOf(a)
+			i.intersect(rangeOf(b))
+			if !i.empty() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type versionBound struct {
+	set       bool
+	v         version
+	inclusive bool
+}
+
+type versionRange struct {
+	low, high versionBound
+}
+
+func rangeOf(comparisons []versionComparison) versionRange {
+	var r versionRange
+	for _, cmp := range comparisons {
+		switch cmp.op {
+		case "=":
+			r.intersect(versionRange{
+				low:  versionBound{set: true, v: cmp.v, inclusive: true},
+				high: versionBound{set: true, v: cmp.v, inclusive: true},
+			})
+		case ">", ">=":
+			r.intersect(versionRange{low: versionBound{set: true, v: cmp.v, inclusive: cmp.op == ">="}})
+		case "<", "<=":
+			r.intersect(versionRange{high: versionBound{set: true, v: cmp.v, inclusive: cmp.op == "<="}})
+		}
+	}
+
+	return r
+}
+
+func (r *versionRange) intersect(o versionRange) {
+	if o.low.set {
+		c := o.low.v.compare(r.low.v)
+		if !r.low.set || c > 0 || (c == 0 && !o.low.inclusive) {
+			r.low = o.low
+		}
+	}
+
+	if o.high.set {
+		c := o.high.v.compare(r.high.v)
+		if !r.high.set || c < 0 || (c == 0 && !o.high.inclusive) {
+			r.high = o.high
+		}
+	}
+}
+
+func (r versionRange) empty() bool {
+	if !r.low.set || !r.high.set {
+		return false
+	}
+
+	c := r.low.v.compare(r.high.v)
+	return c > 0 || (c == 0 && !(r.low.inclusive && r.high.inclusive))
+}
+
+// canBeConfused reports whether a payload could match both candidates as far as their versions are concerned
+func canBeConfused(a, b *candidate) bool {
+	if a.version == nil || b.version == nil {
+		return true
+	}
+
+	return a.version.overlaps(b.version)
+}
@@ -0,0 +1,157 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"2", `2`, true},
+		{"2", `"v2.0.0"`, true},
+		{"2", `2.1`, false},
+		{"=2.1", `"2.1.0-beta"`, true},
+		{">=2 <3", `"2.9.9"`, true},
+		{">=2 <3", `3`, false},
+		{">=2, <3", `1`, false},
+		{"!=2", `3`, true},
+		{"<=1 || >=3", `2`, false},
+		{"<=1 || >=3", `3`, true},
+		{"2", `"two"`, false},
+		{"2", `null`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			c := &versionConstraint{raw: tt.constraint, path: "version"}
+			err := c.compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := c.matches(gjson.Parse(`{"version":` + tt.version + `}`)); got != tt.want {
+				t.Errorf("matches(%s) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionConstraintOverlaps(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1", "2", false},
+		{"<2", ">=2", false},
+		{"<=2", ">=2", true},
+		{">=1 <3", ">=2", true},
+		{"1 || 3", "2 || 4", false},
+		{"1 || 3", "3", true},
+		{"!=2", "2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, b := &versionConstraint{raw: tt.a}, &versionConstraint{raw: tt.b}
+			if err := errors.Join(a.compile(), b.compile()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := a.overlaps(b); got != tt.want {
+				t.Errorf("overlaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type versionedV1 struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+}
+
+type versionedV2 struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+}
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    any
+		wantErr error
+	}{
+		{
+			name:    "first version",
+			payload: `{"version":1,"name":"a"}`,
+			want:    &versionedV1{Version: 1, Name: "a"},
+		},
+		{
+			name:    "second version",
+			payload: `{"version":2,"name":"a"}`,
+			want:    &versionedV2{Version: 2, Name: "a"},
+		},
+		{
+			name:    "no version matching",
+			payload: `{"version":3,"name":"a"}`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name:    "version field",
+			params:  []Parameter{VersionField("/meta/v")},
+			payload: `{"meta":{"v":"2.0"},"version":1,"name":"a"}`,
+			want:    &versionedV2{Version: 1, Name: "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(append(tt.params, Version(versionedV1{}, "<2"), Version(versionedV2{}, "2"))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalJSON() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		wantErr error
+	}{
+		{"invalid constraint", []Parameter{Version(versionedV1{}, ">=x")}, ErrInvalidParameter},
+		{"empty constraint", []Parameter{Version(versionedV1{}, "1 ||")}, ErrInvalidParameter},
+		{"overlapping", []Parameter{Version(versionedV1{}, "<=2"), Version(versionedV2{}, ">=2")}, ErrUnreachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.params...)
+			if err == nil {
+				err = u.load().ready()
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package turnip
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// WarningKind is the kind of issue a Warning is about
+type WarningKind int
+
+const (
+	// WarnAmbiguous is for payloads matching several selectors or candidates, resolved to the first one checked
+	WarnAmbiguous WarningKind = iota + 1
+	// WarnFuzzy is for payloads matching no candidate exactly, resolved by their FuzzyMatch score
+	WarnFuzzy
+	// WarnDeprecated is for payloads resolved to a candidate declared with Deprecated
+	WarnDeprecated
+	// WarnCoerced is for payloads that needed the scalars of some fields coerced to decode
+	WarnCoerced
+)
+
+func (k WarningKind) String() string {
+	switch k {
+	case WarnAmbiguous:
+		return "ambiguous"
+	case WarnFuzzy:
+		return "fuzzy"
+	case WarnDeprecated:
+		return "deprecated"
+	case WarnCoerced:
+		return "coerced"
+	default:
+		return fmt.Sprintf("WarningKind(%d)", int(k))
+	}
+}
+
+// Warning is a non-fatal issue found while unmarshaling a payload, as given to the functions of Warnings
+type Warning struct {
+	Kind WarningKind
+	// Type is the type the payload was resolved to
+	Type reflect.Type
+	// Matched are all the types the payload matched, in the order they are checked. Only set for WarnAmbiguous
+	Matched []reflect.Type
+	// Message describes the issue
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Kind, w.Message)
+}
+
+// Warnings calls warn with the issues that don't fail the unmarshaling but operators may want to know about: payloads
+// matching several types, matched by fuzzy scoring, resolved to deprecated candidates or needing coercions. It's
+// called from the goroutine doing the unmarshaling, so it must be quick and safe for concurrent use. To get them on a
+// channel without ever blocking:
+//
+//	warnings := make(chan turnip.Warning, 64)
+//	turnip.Warnings(func(w turnip.Warning) {
+//		select {
+//		case warnings <- w:
+//		default:
+//		}
+//	})
+//
+// Finding every type a payload matches takes another pass over the fingerprints, done only if there are warning
+// functions. Resolvers given with UseResolver are only checked for deprecated candidates
+func Warnings(warn func(w Warning)) Parameter {
+	return warner(warn)
+}
+
+type warner func(w Warning)
+
+func (w warner) Name() string {
+	return "Warnings"
+}
+
+// Deprecated marks candidate c as deprecated, for payloads resolved to it to be reported to the functions of Warnings
+// with the reason. They are still unmarshaled as usual
+func Deprecated(c Parameter, reason string) Parameter {
+	cand, ok := c.(*candidate)
+	if !ok {
+		// Rejected once given to New
+		return &deprecation{of: c}
+	}
+
+	deprecated := *cand
+	deprecated.deprecated = reason
+	return &deprecated
+}
+
+// deprecation stands for a Deprecated given something other than a candidate
+type deprecation struct {
+	of Parameter
+}
+
+func (d *deprecation) Name() string {
+	return "Deprecated"
+}
+
+func (u *unmarshaler) warn(w Warning) {
+	for _, warn := range u.env.warners {
+		warn(w)
+	}
+}
+
+// warnResolved tells the warning functions about the issues of resolving the payload of q to typ
+func (u *unmarshaler) warnResolved(q *query, typ reflect.Type) {
+	if len(u.env.warners) == 0 {
+		return
+	}
+
+	if c, ok := u.candidates[typ]; ok && c.deprecated != "" {
+		u.warn(Warning{
+			Kind:    WarnDeprecated,
+			Type:    typ,
+			Message: fmt.Sprintf("resolved to deprecated %s: %s", typ, c.deprecated),
+		})
+	}
+
+	r, ok := u.resolver.(*traverseResolver)
+	if !ok {
+		return
+	}
+
+	matched := r.resolveAll(q)
+	switch {
+	case len(matched) == 0:
+		// Nothing matched exactly, so it was resolved by score
+		u.warn(Warning{
+			Kind:    WarnFuzzy,
+			Type:    typ,
+			Message: fmt.Sprintf("resolved to %s by fuzzy matching", typ),
+		})
+	case len(matched) > 1:
+		others := slices.DeleteFunc(slices.Clone(matched), func(t reflect.Type) bool { return t == typ })
+		u.warn(Warning{
+			Kind:    WarnAmbiguous,
+			Type:    typ,
+			Matched: matched,
+			Message: fmt.Sprintf("resolved to %s, but it also matches %v", typ, others),
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package turnip
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+type warnOrder struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+	Notes string `json:"notes"`
+}
+
+type warnRefund struct {
+	RefundOf string `json:"refund_of"`
+	Reason   string `json:"reason"`
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []Parameter
+		payload string
+		want    []WarningKind
+	}{
+		{
+			name:    "none",
+			params:  []Parameter{Candidate(warnOrder{}), Candidate(warnRefund{})},
+			payload: `{"id":"a","total":1,"notes":""}`,
+		},
+		{
+			name:    "deprecated",
+			params:  []Parameter{Candidate(warnOrder{}), Deprecated(Candidate(warnRefund{}), "use credit notes")},
+			payload: `{"refund_of":"a","reason":"x"}`,
+			want:    []WarningKind{WarnDeprecated},
+		},
+		{
+			name: "ambiguous",
+			params: []Parameter{
+				SelectWhen(Exists("id"), warnOrder{}),
+				SelectWhen(Exists("refund_of"), warnRefund{}),
+				Candidate(warnOrder{}),
+			},
+			payload: `{"id":"a","refund_of":"b"}`,
+			want:    []WarningKind{WarnAmbiguous},
+		},
+		{
+			name:    "fuzzy",
+			params:  []Parameter{Candidate(warnOrder{}), Candidate(warnRefund{}), FuzzyMatch(50)},
+			payload: `{"total":1,"notes":"x"}`,
+			want:    []WarningKind{WarnFuzzy},
+		},
+		{
+			name:    "coerced",
+			params:  []Parameter{Candidate(warnOrder{}), Candidate(warnRefund{}), CoerceQuotedNumbers()},
+			payload: `{"id":"a","total":"1","notes":""}`,
+			want:    []WarningKind{WarnCoerced},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var got []WarningKind
+			u, err := New(append(tt.params, Warnings(func(w Warning) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				got = append(got, w.Kind)
+			}))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = u.UnmarshalJSON([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("warnings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeprecatedNotCandidate(t *testing.T) {
+	_, err := New(Candidate(warnOrder{}), Deprecated(Default(warnRefund{}), "gone"))
+	if !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("New() error = %v, want %v", err, ErrInvalidParameter)
+	}
+}
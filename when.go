@@ -0,0 +1,125 @@
+package turnip
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// whenOption is the option of the turnip tag routing payloads to the candidate, like `turnip:"when=type==user"`
+const whenOption = "when"
+
+// whenOperators are the comparisons allowed in when options. Longer ones go first, so <= isn't read as <
+var whenOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// whenSelector returns the selector declared by the when options in the turnip tags of the candidate, or nil if there
+// are none. Only the fields of the struct itself are looked at, not those of nested structs
+func (c *candidate) whenSelector() (*selector, error) {
+	t := c.typ
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var conds []Condition
+	for i := 0; i < t.NumField(); i++ {
+		when, ok := parseTurnipTag(t.Field(i).Tag.Get(turnipTag))[whenOption]
+		if !ok {
+			continue
+		}
+
+		cond, err := parseWhen(when)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", t.Field(i).Name, err)
+		}
+
+		conds = append(conds, cond)
+	}
+
+	if len(conds) == 0 {
+		return nil, nil
+	}
+
+	sel := &selector{
+		cond: conds[0],
+		typ:  c.typ,
+	}
+
+	if len(conds) > 1 {
+		sel.cond = And(conds...)
+	}
+
+	err := sel.cond.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	return sel, nil
+}
+
+// parseWhen reads the condition of a when option
+func parseWhen(when string) (Condition, error) {
+	at, op := -1, ""
+	for _, candidateOp := range whenOperators {
+		i := strings.Index(when, candidateOp)
+		if i >= 0 && (at < 0 || i < at) {
+			at, op = i, candidateOp
+		}
+	}
+
+	if at < 0 {
+		path := strings.TrimSpace(when)
+		if path == "" {
+			return nil, fmt.Errorf("when: empty condition")
+		}
+
+		return Exists(path), nil
+	}
+
+	path, literal := strings.TrimSpace(when[:at]), strings.TrimSpace(when[at+len(op):])
+	if path == "" {
+		return nil, fmt.Errorf("when %s: missing path", when)
+	}
+
+	value := parseWhenLiteral(literal)
+	switch op {
+	case "==":
+		return Eq(path, value), nil
+	case "!=":
+		return Not(Eq(path, value)), nil
+	}
+
+	n, ok := value.(float64)
+	if !ok {
+		return nil, fmt.Errorf("when %s: %s only compares numbers", when, op)
+	}
+
+	switch op {
+	case "<":
+		return Where(path, LT(n)), nil
+	case "<=":
+		return Where(path, LTE(n)), nil
+	case ">":
+		return Where(path, GT(n)), nil
+	default:
+		return Where(path, GTE(n)), nil
+	}
+}
+
+// parseWhenLiteral reads literals as JSON if they are valid JSON, and as strings otherwise
+func parseWhenLiteral(literal string) any {
+	if len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'' {
+		return literal[1 : len(literal)-1]
+	}
+
+	var v any
+	if json.Unmarshal([]byte(literal), &v) != nil {
+		return literal
+	}
+
+	return v
+}
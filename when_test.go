@@ -0,0 +1,117 @@
+package turnip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestParseWhen(t *testing.T) {
+	tests := []struct {
+		name    string
+		when    string
+		payload string
+		want    bool
+		wantErr bool
+	}{
+		{"exists", "deleted_at", `{"deleted_at":null}`, true, false},
+		{"missing", "deleted_at", `{}`, false, false},
+		{"equal string", "type==user", `{"type":"user"}`, true, false},
+		{"quoted string", "type=='1'", `{"type":"1"}`, true, false},
+		{"equal number", "version==2", `{"version":2}`, true, false},
+		{"number against a string", "version==2", `{"version":"2"}`, false, false},
+		{"equal bool", "admin==true", `{"admin":true}`, true, false},
+		{"not equal", "type!=user", `{"type":"group"}`, true, false},
+		{"spaces", " type == user ", `{"type":"user"}`, true, false},
+		{"less or equal", "amount<=10", `{"amount":10}`, true, false},
+		{"less", "amount<10", `{"amount":10}`, false, false},
+		{"greater or equal", "amount>=10", `{"amount":10}`, true, false},
+		{"greater", "amount>10", `{"amount":11}`, true, false},
+		{"nested path", "meta.kind==x", `{"meta":{"kind":"x"}}`, true, false},
+		{"empty", " ", ``, false, true},
+		{"missing path", "==user", ``, false, true},
+		{"comparing a string", "type<user", ``, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, err := parseWhen(tt.when)
+			if err == nil {
+				err = cond.compile()
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWhen(%q) error = %v, want error %v", tt.when, err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got := cond.matches(&query{res: gjson.Parse(tt.payload)}); got != tt.want {
+				t.Errorf("parseWhen(%q) matches %s = %v, want %v", tt.when, tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+type whenUser struct {
+	Type string `json:"type" turnip:"when=type==user"`
+	Name string `json:"name"`
+}
+
+type whenBigOrder struct {
+	Type  string  `json:"type" turnip:"when=type==order"`
+	Total float64 `json:"total" turnip:"when=total>=1000"`
+}
+
+type whenOrder struct {
+	Type     string  `json:"type"`
+	Total    float64 `json:"total"`
+	Currency string  `json:"currency"`
+}
+
+type whenInvalid struct {
+	Type string `json:"type" turnip:"when=type<user"`
+}
+
+func TestWhenTags(t *testing.T) {
+	u, err := New(Candidate(whenUser{}), Candidate(whenBigOrder{}), Candidate(whenOrder{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		want    any
+	}{
+		{"single option", `{"type":"user","name":"ada"}`, &whenUser{Type: "user", Name: "ada"}},
+		{"every option", `{"type":"order","total":1000}`, &whenBigOrder{Type: "order", Total: 1000}},
+		{
+			name:    "not every option",
+			payload: `{"type":"order","total":10,"currency":"EUR"}`,
+			want:    &whenOrder{Type: "order", Total: 10, Currency: "EUR"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.UnmarshalJSON([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	_, err = New(Candidate(whenInvalid{}))
+	if !errors.Is(err, ErrInvalidCandidate) {
+		t.Errorf("New() error = %v, want %v", err, ErrInvalidCandidate)
+	}
+}